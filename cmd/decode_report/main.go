@@ -0,0 +1,59 @@
+// decode_report prints the fields of an OCR2 report, for inspecting what was transmitted.
+//
+// The argument is either the report, hex or base64 encoded, or the JSON execute msg of a transmission:
+//
+//	go run ./cmd/decode_report '{"transmit":{"report_context":"...","report":"...","signatures":[...]}}'
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2/types"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatalf("usage: %s <report or transmit msg>", os.Args[0])
+	}
+	report, err := parseReport(strings.TrimSpace(os.Args[1]))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	parsed, err := terra.ReportCodec{}.DecodeReport(report)
+	if err != nil {
+		log.Fatalf("failed to decode report: %v", err)
+	}
+	fmt.Printf("observations timestamp: %d\n", parsed.ObservationsTimestamp)
+	fmt.Printf("median:                 %s\n", parsed.Median())
+	fmt.Printf("juels per fee coin:     %s\n", parsed.JuelsPerFeeCoin)
+	fmt.Printf("observations (%d):\n", len(parsed.Observations))
+	for i, o := range parsed.Observations {
+		fmt.Printf("  oracle %2d: %s\n", parsed.Observers[i], o)
+	}
+}
+
+func parseReport(arg string) (types.Report, error) {
+	if strings.HasPrefix(arg, "{") {
+		var msg terra.TransmitMsg
+		if err := json.Unmarshal([]byte(arg), &msg); err != nil {
+			return nil, fmt.Errorf("failed to parse transmit msg: %w", err)
+		}
+		return msg.Transmit.Report, nil
+	}
+	if b, err := hex.DecodeString(strings.TrimPrefix(arg, "0x")); err == nil {
+		return b, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return nil, fmt.Errorf("report is neither hex nor base64")
+	}
+	return b, nil
+}
@@ -15,7 +15,7 @@ import (
 	pkgClient "github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
 )
 
-// ChainReader is a subset of the pkg/terra/client.Reader interface enhanced with context support.
+// ChainReader is a subset of the pkg/terra/client.Reader interface.
 type ChainReader interface {
 	TxsEvents(ctx context.Context, events []string, paginationParams *query.PageRequest) (*txtypes.GetTxsEventResponse, error)
 	ContractStore(ctx context.Context, contractAddress sdk.AccAddress, queryMsg []byte) ([]byte, error)
@@ -45,7 +45,7 @@ type chainReader struct {
 	rateLimiter     ratelimit.Limiter
 }
 
-func (c *chainReader) TxsEvents(_ context.Context, events []string, paginationParams *query.PageRequest) (*txtypes.GetTxsEventResponse, error) {
+func (c *chainReader) TxsEvents(ctx context.Context, events []string, paginationParams *query.PageRequest) (*txtypes.GetTxsEventResponse, error) {
 	c.globalSequencer.Lock()
 	defer c.globalSequencer.Unlock()
 	client, err := pkgClient.NewClient(
@@ -58,10 +58,10 @@ func (c *chainReader) TxsEvents(_ context.Context, events []string, paginationPa
 		return nil, fmt.Errorf("failed to create a terra client: %w", err)
 	}
 	_ = c.rateLimiter.Take()
-	return client.TxsEvents(events, paginationParams)
+	return client.TxsEvents(ctx, events, paginationParams)
 }
 
-func (c *chainReader) ContractStore(_ context.Context, contractAddress sdk.AccAddress, queryMsg []byte) ([]byte, error) {
+func (c *chainReader) ContractStore(ctx context.Context, contractAddress sdk.AccAddress, queryMsg []byte) ([]byte, error) {
 	c.globalSequencer.Lock()
 	defer c.globalSequencer.Unlock()
 	client, err := pkgClient.NewClient(
@@ -74,5 +74,5 @@ func (c *chainReader) ContractStore(_ context.Context, contractAddress sdk.AccAd
 		return nil, fmt.Errorf("failed to create a terra client: %w", err)
 	}
 	_ = c.rateLimiter.Take()
-	return client.ContractStore(contractAddress, queryMsg)
+	return client.ContractStore(ctx, contractAddress, queryMsg)
 }
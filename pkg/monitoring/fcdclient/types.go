@@ -2,6 +2,7 @@ package fcdclient
 
 import (
 	"context"
+	"encoding/json"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
@@ -16,6 +17,27 @@ type Tx struct {
 	Code   int    `json:"code"` // Error code if present
 	Logs   []Log  `json:"logs"`
 	RawLog string `json:"raw_log"`
+	Tx     StdTx  `json:"tx"`
+}
+
+// StdTx is the body of a tx, with its msgs in amino JSON.
+type StdTx struct {
+	Value struct {
+		Msg []Msg `json:"msg"`
+	} `json:"value"`
+}
+
+type Msg struct {
+	Typ   string   `json:"type"`
+	Value MsgValue `json:"value"`
+}
+
+// MsgValue holds the fields of a wasm/MsgExecuteContract, or the msgs of a msgauthz/MsgExec.
+// They are empty for other msg types.
+type MsgValue struct {
+	Contract   string          `json:"contract"`
+	ExecuteMsg json.RawMessage `json:"execute_msg"`
+	Msgs       []Msg           `json:"msgs"`
 }
 
 type Log struct {
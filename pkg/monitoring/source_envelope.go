@@ -213,9 +213,78 @@ func (e *envelopeSource) fetchLatestTransmission(ctx context.Context) (transmiss
 	if err != nil {
 		return data, fmt.Errorf("failed to parse block height from fcd data '%s': %w", res.Txs[0].Height, err)
 	}
+	e.checkTransmittedReport(res, data)
 	return data, nil
 }
 
+// checkTransmittedReport decodes the report from the latest transmit msg in res, and logs a warning if it
+// does not match the transmission event. The envelope is still populated from the event, which is authoritative.
+func (e *envelopeSource) checkTransmittedReport(res fcdclient.Response, data transmissionData) {
+	report, err := latestTransmittedReport(res, e.terraFeedConfig.ContractAddressBech32)
+	if err != nil {
+		e.log.Warnw("failed to decode latest transmitted report", "contract_address", e.terraFeedConfig.ContractAddressBech32, "error", err)
+		return
+	}
+	e.log.Debugw("latest transmitted report", "contract_address", e.terraFeedConfig.ContractAddressBech32, "report", report.String())
+	if mismatches := reportMismatches(report, data); len(mismatches) > 0 {
+		e.log.Warnw("latest transmitted report does not match the transmission event",
+			"contract_address", e.terraFeedConfig.ContractAddressBech32, "mismatches", mismatches, "report", report.String())
+	}
+}
+
+// latestTransmittedReport decodes the report transmitted to the contract by the most recent successful tx in res,
+// which must already be sorted most recent first. Transmissions may be wrapped in an authz MsgExec.
+func latestTransmittedReport(res fcdclient.Response, contractAddressBech32 string) (pkgTerra.ParsedReport, error) {
+	for _, tx := range res.Txs {
+		if tx.Code != 0 {
+			continue
+		}
+		for _, msg := range flattenMsgs(tx.Tx.Value.Msg) {
+			if msg.Value.Contract != contractAddressBech32 || len(msg.Value.ExecuteMsg) == 0 {
+				continue
+			}
+			var transmit pkgTerra.TransmitMsg
+			if err := json.Unmarshal(msg.Value.ExecuteMsg, &transmit); err != nil || transmit.Transmit.Report == nil {
+				continue
+			}
+			report, err := pkgTerra.ReportCodec{}.DecodeReport(transmit.Transmit.Report)
+			if err != nil {
+				return pkgTerra.ParsedReport{}, fmt.Errorf("tx %d: %w", tx.ID, err)
+			}
+			return report, nil
+		}
+	}
+	return pkgTerra.ParsedReport{}, fmt.Errorf("no transmit msg found for contract_address='%s'", contractAddressBech32)
+}
+
+// flattenMsgs returns msgs, with the msgs of any authz MsgExec in place of the MsgExec itself.
+func flattenMsgs(msgs []fcdclient.Msg) []fcdclient.Msg {
+	var flat []fcdclient.Msg
+	for _, msg := range msgs {
+		if len(msg.Value.Msgs) > 0 {
+			flat = append(flat, flattenMsgs(msg.Value.Msgs)...)
+			continue
+		}
+		flat = append(flat, msg)
+	}
+	return flat
+}
+
+// reportMismatches describes each field of the transmission event which differs from the report.
+func reportMismatches(report pkgTerra.ParsedReport, data transmissionData) []string {
+	var mismatches []string
+	if data.latestAnswer != nil && report.Median().Cmp(data.latestAnswer) != 0 {
+		mismatches = append(mismatches, fmt.Sprintf("answer %s != median %s", data.latestAnswer, report.Median()))
+	}
+	if data.juelsPerFeeCoin != nil && report.JuelsPerFeeCoin.Cmp(data.juelsPerFeeCoin) != 0 {
+		mismatches = append(mismatches, fmt.Sprintf("juels_per_fee_coin %s != %s", data.juelsPerFeeCoin, report.JuelsPerFeeCoin))
+	}
+	if ts := int64(report.ObservationsTimestamp); data.latestTimestamp.Unix() != ts {
+		mismatches = append(mismatches, fmt.Sprintf("observations_timestamp %d != %d", data.latestTimestamp.Unix(), ts))
+	}
+	return mismatches
+}
+
 func (e *envelopeSource) fetchLatestConfig(ctx context.Context) (types.ContractConfig, error) {
 	var cachedConfig types.ContractConfig
 	var cachedConfigBlock uint64
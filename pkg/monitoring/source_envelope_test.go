@@ -184,3 +184,48 @@ func mustHexaToByteArr(encoded string) []byte {
 	}
 	return decoded
 }
+
+func TestLatestTransmittedReport(t *testing.T) {
+	const contract = "terra10kc4n52rk4xqny3hdew3ggjfk9r420pqxs9ylf"
+	getTxsRaw, err := os.ReadFile("./fixtures/new_transmission-txs.json")
+	require.NoError(t, err)
+	res := fcdclient.Response{}
+	require.NoError(t, json.Unmarshal(getTxsRaw, &res))
+	// sorts the txs, most recent first
+	require.NotEmpty(t, extractMatchingEvents(res, "wasm-new_transmission", contract))
+
+	report, err := latestTransmittedReport(res, contract)
+	require.NoError(t, err)
+	// See the new_transmission event in ./fixtures/new_transmission-txs.json
+	data := transmissionData{
+		latestAnswer:    big.NewInt(295998430000),
+		latestTimestamp: time.Unix(1650737158, 0),
+		juelsPerFeeCoin: big.NewInt(6795709425983940047),
+	}
+	require.Equal(t, data.latestAnswer, report.Median())
+	require.Empty(t, reportMismatches(report, data))
+
+	data.latestAnswer = big.NewInt(1)
+	require.Len(t, reportMismatches(report, data), 1)
+
+	_, err = latestTransmittedReport(res, "terra1other")
+	require.Error(t, err)
+
+	t.Run("authz", func(t *testing.T) {
+		// Wrap each msg in a MsgExec, as sent by a grantee.
+		var wrapped fcdclient.Response
+		require.NoError(t, json.Unmarshal(getTxsRaw, &wrapped))
+		for i, tx := range wrapped.Txs {
+			msgs, err := json.Marshal(tx.Tx.Value.Msg)
+			require.NoError(t, err)
+			var exec []fcdclient.Msg
+			require.NoError(t, json.Unmarshal([]byte(`[{"type":"msgauthz/MsgExec","value":{"grantee":"terra1grantee","msgs":`+string(msgs)+`}}]`), &exec))
+			wrapped.Txs[i].Tx.Value.Msg = exec
+		}
+		require.NotEmpty(t, extractMatchingEvents(wrapped, "wasm-new_transmission", contract))
+
+		report, err := latestTransmittedReport(wrapped, contract)
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(295998430000), report.Median())
+	})
+}
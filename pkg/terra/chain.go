@@ -25,3 +25,11 @@ type Chain interface {
 	// Reader returns a new Reader. If nodeName is provided, the underlying client must use that node.
 	Reader(nodeName string) (client.Reader, error)
 }
+
+// NodesChain is a Chain which can list its nodes, so that they can all be read from through a client.MultiNodeClient.
+// Otherwise, Reader("") is used when no node is requested.
+type NodesChain interface {
+	Chain
+	// Nodes returns all nodes configured for this chain.
+	Nodes(ctx context.Context) ([]db.Node, error)
+}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"math"
 	"net/http"
 	"regexp"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/types/query"
@@ -22,22 +24,32 @@ import (
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	"github.com/cosmos/cosmos-sdk/std"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
 	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
 	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
 	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/tendermint/tendermint/light/provider"
+	lighthttp "github.com/tendermint/tendermint/light/provider/http"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
 	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmcore "github.com/tendermint/tendermint/types"
 	"github.com/terra-money/core/app"
 	"github.com/terra-money/core/app/params"
 	wasmtypes "github.com/terra-money/core/x/wasm/types"
+	"google.golang.org/grpc/metadata"
 
-	"github.com/smartcontractkit/terra.go/key"
 	"github.com/smartcontractkit/terra.go/msg"
 	"github.com/smartcontractkit/terra.go/tx"
 )
 
 const httpResponseLimit = 10_000_000 // 10MB
 
+// subscriptionCapacity is the number of events buffered per subscription before they are dropped.
+const subscriptionCapacity = 100
+
 var encodingConfig = params.MakeEncodingConfig()
 
 func init() {
@@ -61,25 +73,39 @@ type ReaderWriter interface {
 
 // Reader provides methods for reading from a terra chain.
 type Reader interface {
-	Account(address sdk.AccAddress) (uint64, uint64, error)
-	ContractStore(contractAddress sdk.AccAddress, queryMsg []byte) ([]byte, error)
-	TxsEvents(events []string, paginationParams *query.PageRequest) (*txtypes.GetTxsEventResponse, error)
-	Tx(hash string) (*txtypes.GetTxResponse, error)
-	LatestBlock() (*tmtypes.GetLatestBlockResponse, error)
-	BlockByHeight(height int64) (*tmtypes.GetBlockByHeightResponse, error)
-	Balance(addr sdk.AccAddress, denom string) (*sdk.Coin, error)
+	Account(ctx context.Context, address sdk.AccAddress) (uint64, uint64, error)
+	ContractStore(ctx context.Context, contractAddress sdk.AccAddress, queryMsg []byte) ([]byte, error)
+	// ContractStoreAtHeight is like ContractStore, but reads the state as of the given block height.
+	// Returns ErrHeightUnavailable if the node has pruned that height.
+	ContractStoreAtHeight(ctx context.Context, contractAddress sdk.AccAddress, queryMsg []byte, height int64) ([]byte, error)
+	// BatchContractStore sends queries in a single JSON-RPC batch request, returning results in the same order.
+	// The error is only for the batch as a whole, while each result may have its own error.
+	BatchContractStore(ctx context.Context, queries []ContractQuery) ([]ContractQueryResult, error)
+	// ContractRawStoreWithProof reads the value stored under key by a contract as of height, along with a merkle proof.
+	ContractRawStoreWithProof(ctx context.Context, contractAddress sdk.AccAddress, key []byte, height int64) (*RawStoreProof, error)
+	// LightBlock returns the signed header and validator set at height, or the latest if height is 0.
+	LightBlock(ctx context.Context, height int64) (*tmcore.LightBlock, error)
+	TxsEvents(ctx context.Context, events []string, paginationParams *query.PageRequest) (*txtypes.GetTxsEventResponse, error)
+	Tx(ctx context.Context, hash string) (*txtypes.GetTxResponse, error)
+	LatestBlock(ctx context.Context) (*tmtypes.GetLatestBlockResponse, error)
+	BlockByHeight(ctx context.Context, height int64) (*tmtypes.GetBlockByHeightResponse, error)
+	Balance(ctx context.Context, addr sdk.AccAddress, denom string) (*sdk.Coin, error)
+	// SubscribeEvents subscribes to events matching any of queries over the tendermint websocket.
+	// The returned channel is closed when ctx is done.
+	SubscribeEvents(ctx context.Context, queries ...string) (<-chan ctypes.ResultEvent, error)
 }
 
 // Writer provides methods for writing to a terra chain.
 // Assumes all msgs are for the same from address.
 // We may want to support multiple from addresses + signers if a use case arises.
+// If feeGranter is not nil, it pays the fee instead of the signer, via a feegrant allowance.
 type Writer interface {
-	SignAndBroadcast(msgs []sdk.Msg, accountNum uint64, sequence uint64, gasPrice sdk.DecCoin, signer key.PrivKey, mode txtypes.BroadcastMode) (*txtypes.BroadcastTxResponse, error)
-	Broadcast(txBytes []byte, mode txtypes.BroadcastMode) (*txtypes.BroadcastTxResponse, error)
-	Simulate(txBytes []byte) (*txtypes.SimulateResponse, error)
-	BatchSimulateUnsigned(msgs SimMsgs, sequence uint64) (*BatchSimResults, error)
-	SimulateUnsigned(msgs []sdk.Msg, sequence uint64) (*txtypes.SimulateResponse, error)
-	CreateAndSign(msgs []sdk.Msg, account uint64, sequence uint64, gasLimit uint64, gasLimitMultiplier float64, gasPrice sdk.DecCoin, signer key.PrivKey, timeoutHeight uint64) ([]byte, error)
+	SignAndBroadcast(ctx context.Context, msgs []sdk.Msg, accountNum uint64, sequence uint64, gasPrice sdk.DecCoin, signer Signer, feeGranter sdk.AccAddress, mode txtypes.BroadcastMode) (*txtypes.BroadcastTxResponse, error)
+	Broadcast(ctx context.Context, txBytes []byte, mode txtypes.BroadcastMode) (*txtypes.BroadcastTxResponse, error)
+	Simulate(ctx context.Context, txBytes []byte) (*txtypes.SimulateResponse, error)
+	BatchSimulateUnsigned(ctx context.Context, msgs SimMsgs, sequence uint64) (*BatchSimResults, error)
+	SimulateUnsigned(ctx context.Context, msgs []sdk.Msg, sequence uint64) (*txtypes.SimulateResponse, error)
+	CreateAndSign(ctx context.Context, msgs []sdk.Msg, account uint64, sequence uint64, gasLimit uint64, gasLimitMultiplier float64, gasPrice sdk.DecCoin, signer Signer, feeGranter sdk.AccAddress, timeoutHeight uint64) ([]byte, error)
 }
 
 var _ ReaderWriter = (*Client)(nil)
@@ -105,12 +131,15 @@ const (
 // Client is a terra client
 type Client struct {
 	chainID                 string
+	tendermintURL           string
 	clientCtx               cosmosclient.Context
 	cosmosServiceClient     txtypes.ServiceClient
 	authClient              authtypes.QueryClient
 	wasmClient              wasmtypes.QueryClient
 	bankClient              banktypes.QueryClient
 	tendermintServiceClient tmtypes.ServiceClient
+	tmClient                *rpchttp.HTTP
+	lightProvider           provider.Provider
 	log                     logger.Logger
 }
 
@@ -173,7 +202,6 @@ func NewClient(chainID string,
 	ec := encodingConfig
 	// Note should terra nodes start exposing grpc, its preferable
 	// to connect directly with grpc.Dial to avoid using clientCtx (according to tendermint team).
-	// If so then we would start putting timeouts on the ctx we pass in to the generate grpc client calls.
 	clientCtx := cosmosclient.Context{}.
 		WithClient(tmClient).
 		WithChainID(chainID).
@@ -183,28 +211,34 @@ func NewClient(chainID string,
 		WithInterfaceRegistry(ec.InterfaceRegistry).
 		WithTxConfig(ec.TxConfig)
 
-	cosmosServiceClient := txtypes.NewServiceClient(clientCtx)
-	authClient := authtypes.NewQueryClient(clientCtx)
-	wasmClient := wasmtypes.NewQueryClient(clientCtx)
-	tendermintServiceClient := tmtypes.NewServiceClient(clientCtx)
-	bankClient := banktypes.NewQueryClient(clientCtx)
+	// Route the generated grpc clients through our own conn, so that the ctx passed to each call
+	// is respected by the underlying tendermint rpc requests.
+	conn := &tmGRPCConn{clientCtx: clientCtx, tmClient: tmClient}
+	cosmosServiceClient := txtypes.NewServiceClient(conn)
+	authClient := authtypes.NewQueryClient(conn)
+	wasmClient := wasmtypes.NewQueryClient(conn)
+	tendermintServiceClient := tmtypes.NewServiceClient(conn)
+	bankClient := banktypes.NewQueryClient(conn)
 
 	return &Client{
 		chainID:                 chainID,
+		tendermintURL:           tendermintURL,
 		cosmosServiceClient:     cosmosServiceClient,
 		authClient:              authClient,
 		wasmClient:              wasmClient,
 		tendermintServiceClient: tendermintServiceClient,
 		bankClient:              bankClient,
 		clientCtx:               clientCtx,
+		tmClient:                tmClient,
+		lightProvider:           lighthttp.NewWithClient(chainID, tmClient),
 		log:                     lggr,
 	}, nil
 }
 
 // Account read the account address for the account number and sequence number.
-// !!Note only one sequence number can be used per account per block!!
-func (c *Client) Account(addr sdk.AccAddress) (uint64, uint64, error) {
-	r, err := c.authClient.Account(context.Background(), &authtypes.QueryAccountRequest{Address: addr.String()})
+// Use a SequenceManager to broadcast multiple txs per account per block.
+func (c *Client) Account(ctx context.Context, addr sdk.AccAddress) (uint64, uint64, error) {
+	r, err := c.authClient.Account(ctx, &authtypes.QueryAccountRequest{Address: addr.String()})
 	if err != nil {
 		return 0, 0, err
 	}
@@ -217,8 +251,8 @@ func (c *Client) Account(addr sdk.AccAddress) (uint64, uint64, error) {
 }
 
 // ContractStore reads from a WASM contract store
-func (c *Client) ContractStore(contractAddress sdk.AccAddress, queryMsg []byte) ([]byte, error) {
-	s, err := c.wasmClient.ContractStore(context.Background(), &wasmtypes.QueryContractStoreRequest{
+func (c *Client) ContractStore(ctx context.Context, contractAddress sdk.AccAddress, queryMsg []byte) ([]byte, error) {
+	s, err := c.wasmClient.ContractStore(ctx, &wasmtypes.QueryContractStoreRequest{
 		ContractAddress: contractAddress.String(),
 		QueryMsg:        queryMsg,
 	})
@@ -229,12 +263,73 @@ func (c *Client) ContractStore(contractAddress sdk.AccAddress, queryMsg []byte)
 	return s.QueryResult, err
 }
 
+// ContractStoreAtHeight reads from a WASM contract store as of height, which must be positive.
+func (c *Client) ContractStoreAtHeight(ctx context.Context, contractAddress sdk.AccAddress, queryMsg []byte, height int64) ([]byte, error) {
+	if height <= 0 {
+		return nil, fmt.Errorf("invalid height %d", height)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatInt(height, 10))
+	return c.ContractStore(ctx, contractAddress, queryMsg)
+}
+
+// contractStorePath is the ABCI query path for ContractStore.
+const contractStorePath = "/terra.wasm.v1beta1.Query/ContractStore"
+
+// ContractQuery is a query of a WASM contract store, for BatchContractStore.
+type ContractQuery struct {
+	ContractAddress sdk.AccAddress
+	QueryMsg        []byte
+}
+
+// ContractQueryResult is the result of a ContractQuery.
+type ContractQueryResult struct {
+	Result []byte
+	Err    error
+}
+
+// BatchContractStore reads from WASM contract stores, with all queries sent in one JSON-RPC batch request.
+func (c *Client) BatchContractStore(ctx context.Context, queries []ContractQuery) ([]ContractQueryResult, error) {
+	batch := c.tmClient.NewBatch()
+	responses := make([]*ctypes.ResultABCIQuery, len(queries))
+	for i, q := range queries {
+		reqBz, err := protoCodec.Marshal(&wasmtypes.QueryContractStoreRequest{
+			ContractAddress: q.ContractAddress.String(),
+			QueryMsg:        q.QueryMsg,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Results are only populated by Send.
+		responses[i], err = batch.ABCIQueryWithOptions(ctx, contractStorePath, reqBz, rpcclient.DefaultABCIQueryOptions)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := batch.Send(ctx); err != nil {
+		return nil, err
+	}
+	results := make([]ContractQueryResult, len(queries))
+	for i, resp := range responses {
+		if !resp.Response.IsOK() {
+			results[i].Err = queryError(resp.Response)
+			continue
+		}
+		var s wasmtypes.QueryContractStoreResponse
+		if err := protoCodec.Unmarshal(resp.Response.Value, &s); err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Result = s.QueryResult
+	}
+	return results, nil
+}
+
 // TxsEvents returns in tx events in descending order (latest txes first).
 // Each event is ANDed together and follows the query language defined
 // https://docs.cosmos.network/master/core/events.html
 // Note one current issue https://github.com/cosmos/cosmos-sdk/issues/10448
-func (c *Client) TxsEvents(events []string, paginationParams *query.PageRequest) (*txtypes.GetTxsEventResponse, error) {
-	e, err := c.cosmosServiceClient.GetTxsEvent(context.Background(), &txtypes.GetTxsEventRequest{
+func (c *Client) TxsEvents(ctx context.Context, events []string, paginationParams *query.PageRequest) (*txtypes.GetTxsEventResponse, error) {
+	e, err := c.cosmosServiceClient.GetTxsEvent(ctx, &txtypes.GetTxsEventRequest{
 		Events:     events,
 		Pagination: paginationParams,
 		OrderBy:    txtypes.OrderBy_ORDER_BY_DESC,
@@ -243,25 +338,25 @@ func (c *Client) TxsEvents(events []string, paginationParams *query.PageRequest)
 }
 
 // Tx gets a tx by hash
-func (c *Client) Tx(hash string) (*txtypes.GetTxResponse, error) {
-	e, err := c.cosmosServiceClient.GetTx(context.Background(), &txtypes.GetTxRequest{
+func (c *Client) Tx(ctx context.Context, hash string) (*txtypes.GetTxResponse, error) {
+	e, err := c.cosmosServiceClient.GetTx(ctx, &txtypes.GetTxRequest{
 		Hash: hash,
 	})
 	return e, err
 }
 
 // LatestBlock returns the latest block
-func (c *Client) LatestBlock() (*tmtypes.GetLatestBlockResponse, error) {
-	return c.tendermintServiceClient.GetLatestBlock(context.Background(), &tmtypes.GetLatestBlockRequest{})
+func (c *Client) LatestBlock(ctx context.Context) (*tmtypes.GetLatestBlockResponse, error) {
+	return c.tendermintServiceClient.GetLatestBlock(ctx, &tmtypes.GetLatestBlockRequest{})
 }
 
 // BlockByHeight gets a block by height
-func (c *Client) BlockByHeight(height int64) (*tmtypes.GetBlockByHeightResponse, error) {
-	return c.tendermintServiceClient.GetBlockByHeight(context.Background(), &tmtypes.GetBlockByHeightRequest{Height: height})
+func (c *Client) BlockByHeight(ctx context.Context, height int64) (*tmtypes.GetBlockByHeightResponse, error) {
+	return c.tendermintServiceClient.GetBlockByHeight(ctx, &tmtypes.GetBlockByHeightRequest{Height: height})
 }
 
 // CreateAndSign creates and signs a transaction
-func (c *Client) CreateAndSign(msgs []sdk.Msg, account uint64, sequence uint64, gasLimit uint64, gasLimitMultiplier float64, gasPrice sdk.DecCoin, signer key.PrivKey, timeoutHeight uint64) ([]byte, error) {
+func (c *Client) CreateAndSign(ctx context.Context, msgs []sdk.Msg, account uint64, sequence uint64, gasLimit uint64, gasLimitMultiplier float64, gasPrice sdk.DecCoin, signer Signer, feeGranter sdk.AccAddress, timeoutHeight uint64) ([]byte, error) {
 	txbuilder := tx.NewTxBuilder(encodingConfig.TxConfig)
 	err := txbuilder.SetMsgs(msgs...)
 	if err != nil {
@@ -271,16 +366,31 @@ func (c *Client) CreateAndSign(msgs []sdk.Msg, account uint64, sequence uint64,
 	txbuilder.SetGasLimit(gasLimitBuffered)
 	gasFee := msg.NewCoin(gasPrice.Denom, gasPrice.Amount.MulInt64(int64(gasLimitBuffered)).Ceil().RoundInt())
 	txbuilder.SetFeeAmount(sdk.NewCoins(gasFee))
+	// nil fee granter means unset.
+	txbuilder.SetFeeGranter(feeGranter)
 	// 0 timeout height means unset.
 	txbuilder.SetTimeoutHeight(timeoutHeight)
-	err = txbuilder.Sign(tx.SignModeDirect, tx.SignerData{
+	// Set the signer info, without a signature, since it is included in the sign bytes.
+	sigData := &signing.SingleSignatureData{SignMode: signing.SignMode_SIGN_MODE_DIRECT}
+	sig := signing.SignatureV2{PubKey: signer.PubKey(), Data: sigData, Sequence: sequence}
+	if err = txbuilder.SetSignatures(sig); err != nil {
+		return nil, err
+	}
+	signBytes, err := encodingConfig.TxConfig.SignModeHandler().GetSignBytes(sigData.SignMode, authsigning.SignerData{
 		AccountNumber: account,
 		ChainID:       c.chainID,
 		Sequence:      sequence,
-	}, signer, true)
+	}, txbuilder.GetTx())
 	if err != nil {
 		return nil, err
 	}
+	sigData.Signature, err = signer.Sign(ctx, signBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign tx")
+	}
+	if err = txbuilder.SetSignatures(sig); err != nil {
+		return nil, err
+	}
 	signedTx, err := txbuilder.GetTxBytes()
 	if err != nil {
 		return nil, err
@@ -319,6 +429,8 @@ func (simMsgs SimMsgs) GetSimMsgsIDs() []int64 {
 type BatchSimResults struct {
 	Failed    SimMsgs
 	Succeeded SimMsgs
+	// Errors holds the simulation error of each failed msg, by ID.
+	Errors map[int64]error
 }
 
 var failedMsgIndexRe = regexp.MustCompile(`^.*failed to execute message; message index: (?P<Index>\d+):.*$`)
@@ -327,6 +439,10 @@ func (c *Client) failedMsgIndex(err error) (bool, int) {
 	if err == nil {
 		return false, 0
 	}
+	var msgErr *ErrMsgFailed
+	if errors.As(err, &msgErr) {
+		return true, msgErr.MsgIndex
+	}
 
 	m := failedMsgIndexRe.FindStringSubmatch(err.Error())
 	if len(m) != 2 {
@@ -339,50 +455,99 @@ func (c *Client) failedMsgIndex(err error) (bool, int) {
 	return true, int(index)
 }
 
-// BatchSimulateUnsigned simulates a group of msgs.
+// BatchSimulateUnsigned simulates a group of msgs, and isolates those which fail.
 // Assumes at least one msg is present.
-// If we fail to simulate the batch, remove the offending tx
-// and try again. Repeat until we have a successful batch.
-// Keep track of failures so we can mark them as errored.
-// Note that the error from simulating indicates the first
-// msg in the slice which failed (it simply loops over the msgs
-// and simulates them one by one, breaking at the first failure).
-func (c *Client) BatchSimulateUnsigned(msgs SimMsgs, sequence uint64) (*BatchSimResults, error) {
-	var succeeded []SimMsg
-	var failed []SimMsg
+// The msgs which succeed are simulated together, since msgs may depend on each other.
+// See batchSimulate.
+func (c *Client) BatchSimulateUnsigned(ctx context.Context, msgs SimMsgs, sequence uint64) (*BatchSimResults, error) {
+	return c.batchSimulate(ctx, msgs, func(ctx context.Context, msgs []sdk.Msg) error {
+		_, err := c.SimulateUnsigned(ctx, msgs, sequence)
+		return err
+	})
+}
+
+// batchSimulate repeatedly bisects msgs with simulate until the remaining msgs succeed together.
+func (c *Client) batchSimulate(ctx context.Context, msgs SimMsgs, simulate func(context.Context, []sdk.Msg) error) (*BatchSimResults, error) {
+	res := &BatchSimResults{Errors: make(map[int64]error)}
 	toSim := msgs
-	for {
-		_, err := c.SimulateUnsigned(toSim.GetMsgs(), sequence)
-		containsFailure, failureIndex := c.failedMsgIndex(err)
-		if err != nil && !containsFailure {
+	for len(toSim) > 0 {
+		succeeded, failed, err := c.bisectSimulate(ctx, toSim, simulate)
+		if err != nil {
 			return nil, err
 		}
-		if containsFailure {
-			failed = append(failed, toSim[failureIndex])
-			succeeded = append(succeeded, toSim[:failureIndex]...)
-			// remove offending msg and retry
-			if failureIndex == len(toSim)-1 {
-				// we're done, last one failed
-				c.log.Warnf("simulation error found in last msg, failure %v, index %v, err %v", toSim[failureIndex], failureIndex, err)
-				break
-			}
-			// otherwise there may be more to sim
-			c.log.Warnf("simulation error found in a msg, retrying with %v, failure %v, index %v, err %v", toSim[failureIndex+1:], toSim[failureIndex], failureIndex, err)
-			toSim = toSim[failureIndex+1:]
-		} else {
-			// we're done they all succeeded
-			succeeded = append(succeeded, toSim...)
+		for _, f := range failed {
+			res.Failed = append(res.Failed, f.msg)
+			res.Errors[f.msg.ID] = f.err
+		}
+		if len(failed) == 0 {
+			res.Succeeded = succeeded
 			break
 		}
+		// msgs which succeeded in separate sub-batches must be re-simulated together
+		toSim = succeeded
 	}
-	return &BatchSimResults{
-		Failed:    failed,
-		Succeeded: succeeded,
-	}, nil
+	return res, nil
+}
+
+type simFailure struct {
+	msg SimMsg
+	err error
+}
+
+// bisectSimulate isolates the msgs which fail simulation.
+// Simulation executes msgs in order and stops at the first failure, which is reported by index, so
+// the msgs before it succeeded together. The msgs after it are split in half and simulated in parallel,
+// which isolates k failures out of n msgs in O(k log n) simulations, in O(log n) sequential steps.
+// Note that msgs in different halves are not simulated together.
+func (c *Client) bisectSimulate(ctx context.Context, msgs SimMsgs, simulate func(context.Context, []sdk.Msg) error) (succeeded SimMsgs, failed []simFailure, err error) {
+	simErr := simulate(ctx, msgs.GetMsgs())
+	if simErr == nil {
+		return msgs, nil, nil
+	}
+	containsFailure, i := c.failedMsgIndex(simErr)
+	if !containsFailure {
+		return nil, nil, simErr
+	}
+	if i >= len(msgs) {
+		return nil, nil, errors.Wrapf(simErr, "failed msg index %d out of range for %d msgs", i, len(msgs))
+	}
+	c.log.Warnf("simulation error found in a msg, failure %v, index %v, err %v", msgs[i], i, simErr)
+	succeeded = append(succeeded, msgs[:i]...)
+	failed = append(failed, simFailure{msg: msgs[i], err: simErr})
+
+	rest := msgs[i+1:]
+	halves := []SimMsgs{rest[:len(rest)/2], rest[len(rest)/2:]}
+	type result struct {
+		succeeded SimMsgs
+		failed    []simFailure
+		err       error
+	}
+	results := make([]result, len(halves))
+	var wg sync.WaitGroup
+	for j := range halves {
+		if len(halves[j]) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(j int) {
+			defer wg.Done()
+			r := &results[j]
+			r.succeeded, r.failed, r.err = c.bisectSimulate(ctx, halves[j], simulate)
+		}(j)
+	}
+	wg.Wait()
+	for _, r := range results {
+		if r.err != nil {
+			return nil, nil, r.err
+		}
+		succeeded = append(succeeded, r.succeeded...)
+		failed = append(failed, r.failed...)
+	}
+	return succeeded, failed, nil
 }
 
 // SimulateUnsigned simulates an unsigned msg
-func (c *Client) SimulateUnsigned(msgs []sdk.Msg, sequence uint64) (*txtypes.SimulateResponse, error) {
+func (c *Client) SimulateUnsigned(ctx context.Context, msgs []sdk.Msg, sequence uint64) (*txtypes.SimulateResponse, error) {
 	txbuilder := tx.NewTxBuilder(encodingConfig.TxConfig)
 	if err := txbuilder.SetMsgs(msgs...); err != nil {
 		return nil, err
@@ -404,23 +569,23 @@ func (c *Client) SimulateUnsigned(msgs []sdk.Msg, sequence uint64) (*txtypes.Sim
 	if err != nil {
 		return nil, err
 	}
-	s, err := c.cosmosServiceClient.Simulate(context.Background(), &txtypes.SimulateRequest{
+	s, err := c.cosmosServiceClient.Simulate(ctx, &txtypes.SimulateRequest{
 		TxBytes: txBytes,
 	})
 	return s, err
 }
 
 // Simulate simulates a signed transaction
-func (c *Client) Simulate(txBytes []byte) (*txtypes.SimulateResponse, error) {
-	s, err := c.cosmosServiceClient.Simulate(context.Background(), &txtypes.SimulateRequest{
+func (c *Client) Simulate(ctx context.Context, txBytes []byte) (*txtypes.SimulateResponse, error) {
+	s, err := c.cosmosServiceClient.Simulate(ctx, &txtypes.SimulateRequest{
 		TxBytes: txBytes,
 	})
 	return s, err
 }
 
 // Broadcast broadcasts a tx
-func (c *Client) Broadcast(txBytes []byte, mode txtypes.BroadcastMode) (*txtypes.BroadcastTxResponse, error) {
-	res, err := c.cosmosServiceClient.BroadcastTx(context.Background(), &txtypes.BroadcastTxRequest{
+func (c *Client) Broadcast(ctx context.Context, txBytes []byte, mode txtypes.BroadcastMode) (*txtypes.BroadcastTxResponse, error) {
+	res, err := c.cosmosServiceClient.BroadcastTx(ctx, &txtypes.BroadcastTxRequest{
 		Mode:    mode,
 		TxBytes: txBytes,
 	})
@@ -430,30 +595,84 @@ func (c *Client) Broadcast(txBytes []byte, mode txtypes.BroadcastMode) (*txtypes
 	if res.TxResponse == nil {
 		return nil, errors.Errorf("got nil tx response")
 	}
-	if res.TxResponse.Code != 0 {
-		return res, errors.Errorf("tx failed with error code: %d, resp %v", res.TxResponse.Code, res.TxResponse)
+	if err = txError(res.TxResponse); err != nil {
+		return res, errors.Wrapf(err, "tx %s failed", res.TxResponse.TxHash)
 	}
-	return res, err
+	return res, nil
 }
 
 // SignAndBroadcast signs and broadcasts a group of msgs.
-func (c *Client) SignAndBroadcast(msgs []sdk.Msg, account uint64, sequence uint64, gasPrice sdk.DecCoin, signer key.PrivKey, mode txtypes.BroadcastMode) (*txtypes.BroadcastTxResponse, error) {
-	sim, err := c.SimulateUnsigned(msgs, sequence)
+func (c *Client) SignAndBroadcast(ctx context.Context, msgs []sdk.Msg, account uint64, sequence uint64, gasPrice sdk.DecCoin, signer Signer, feeGranter sdk.AccAddress, mode txtypes.BroadcastMode) (*txtypes.BroadcastTxResponse, error) {
+	sim, err := c.SimulateUnsigned(ctx, msgs, sequence)
 	if err != nil {
 		return nil, err
 	}
-	txBytes, err := c.CreateAndSign(msgs, account, sequence, sim.GasInfo.GasUsed, DefaultGasLimitMultiplier, gasPrice, signer, 0)
+	txBytes, err := c.CreateAndSign(ctx, msgs, account, sequence, sim.GasInfo.GasUsed, DefaultGasLimitMultiplier, gasPrice, signer, feeGranter, 0)
 	if err != nil {
 		return nil, err
 	}
-	return c.Broadcast(txBytes, mode)
+	return c.Broadcast(ctx, txBytes, mode)
 }
 
 // Balance returns the balance of an address
-func (c *Client) Balance(addr sdk.AccAddress, denom string) (*sdk.Coin, error) {
-	b, err := c.bankClient.Balance(context.Background(), &banktypes.QueryBalanceRequest{Address: addr.String(), Denom: denom})
+func (c *Client) Balance(ctx context.Context, addr sdk.AccAddress, denom string) (*sdk.Coin, error) {
+	b, err := c.bankClient.Balance(ctx, &banktypes.QueryBalanceRequest{Address: addr.String(), Denom: denom})
 	if err != nil {
 		return nil, err
 	}
 	return b.Balance, nil
 }
+
+// SubscribeEvents subscribes to events matching any of queries over a new tendermint websocket connection,
+// which is closed along with the returned channel when ctx is done.
+// The connection is re-established automatically if it drops, but events may be missed in the meantime.
+// See https://docs.tendermint.com/v0.34/rpc/#/Websocket/subscribe for the query syntax.
+func (c *Client) SubscribeEvents(ctx context.Context, queries ...string) (<-chan ctypes.ResultEvent, error) {
+	wsClient, err := rpchttp.NewWithClient(c.tendermintURL, "/websocket", &http.Client{})
+	if err != nil {
+		return nil, err
+	}
+	if err = wsClient.Start(); err != nil {
+		return nil, errors.Wrap(err, "failed to start websocket client")
+	}
+	stop := func() {
+		if err := wsClient.Stop(); err != nil {
+			c.log.Warnf("failed to stop websocket client: %v", err)
+		}
+	}
+	var subs []<-chan ctypes.ResultEvent
+	for _, q := range queries {
+		sub, err := wsClient.Subscribe(ctx, "", q, subscriptionCapacity)
+		if err != nil {
+			stop()
+			return nil, errors.Wrapf(err, "failed to subscribe to %q", q)
+		}
+		subs = append(subs, sub)
+	}
+	out := make(chan ctypes.ResultEvent, subscriptionCapacity)
+	var wg sync.WaitGroup
+	wg.Add(len(subs))
+	for _, sub := range subs {
+		go func(sub <-chan ctypes.ResultEvent) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case ev := <-sub:
+					select {
+					case out <- ev:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}(sub)
+	}
+	go func() {
+		wg.Wait()
+		stop()
+		close(out)
+	}()
+	return out, nil
+}
@@ -1,7 +1,18 @@
 package client
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -35,7 +46,88 @@ func TestErrMatch(t *testing.T) {
 	assert.Equal(t, m[1], "10000")
 }
 
+func TestContextCancellation(t *testing.T) {
+	// Node which never responds
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(done) })
+	tc, err := NewClient("42", srv.URL, DefaultTimeout, logger.Test(t))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = tc.LatestBlock(ctx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), DefaultTimeout)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = tc.ContractStore(ctx, sdk.AccAddress{}, []byte(`"latest_config_details"`))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBatchContractStore(t *testing.T) {
+	contract := sdk.AccAddress("contract")
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		var batch []struct {
+			ID     json.RawMessage `json:"id"`
+			Params struct {
+				Path string `json:"path"`
+				Data string `json:"data"`
+			} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		var resps []string
+		for _, req := range batch {
+			assert.Equal(t, contractStorePath, req.Params.Path)
+			data, err := hex.DecodeString(req.Params.Data)
+			require.NoError(t, err)
+			var query wasmtypes.QueryContractStoreRequest
+			require.NoError(t, query.Unmarshal(data))
+			assert.Equal(t, contract.String(), query.ContractAddress)
+			if string(query.QueryMsg) == `"unknown"` {
+				resps = append(resps, fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"response":{"code":2,"codespace":"wasm","log":"contract query failed"}}}`, req.ID))
+				continue
+			}
+			value, err := (&wasmtypes.QueryContractStoreResponse{QueryResult: append([]byte("re:"), query.QueryMsg...)}).Marshal()
+			require.NoError(t, err)
+			resps = append(resps, fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"response":{"code":0,"value":"%s","height":"5"}}}`,
+				req.ID, base64.StdEncoding.EncodeToString(value)))
+		}
+		_, err := w.Write([]byte("[" + strings.Join(resps, ",") + "]"))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+	tc, err := NewClient("42", srv.URL, DefaultTimeout, logger.Test(t))
+	require.NoError(t, err)
+
+	results, err := tc.BatchContractStore(context.Background(), []ContractQuery{
+		{ContractAddress: contract, QueryMsg: []byte(`"latest_config_details"`)},
+		{ContractAddress: contract, QueryMsg: []byte(`"unknown"`)},
+		{ContractAddress: contract, QueryMsg: []byte(`"latest_transmission_details"`)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	require.Len(t, results, 3)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, `re:"latest_config_details"`, string(results[0].Result))
+	var abciErr *ABCIError
+	require.ErrorAs(t, results[1].Err, &abciErr)
+	assert.Equal(t, "wasm", abciErr.Codespace)
+	require.NoError(t, results[2].Err)
+	assert.Equal(t, `re:"latest_transmission_details"`, string(results[2].Result))
+}
+
 func TestBatchSim(t *testing.T) {
+	ctx := context.Background()
 	accounts, testdir, tendermintURL := SetupLocalTerraNode(t, "42")
 
 	lggr, logs := logger.TestObserved(t, zap.WarnLevel)
@@ -54,10 +146,10 @@ func TestBatchSim(t *testing.T) {
 	var fail sdk.Msg = &wasmtypes.MsgExecuteContract{Sender: accounts[0].Address.String(), Contract: contract.String(), ExecuteMsg: []byte(`{"blah":{"count":5}}`)}
 
 	t.Run("single success", func(t *testing.T) {
-		_, sn, err := tc.Account(accounts[0].Address)
+		_, sn, err := tc.Account(ctx, accounts[0].Address)
 		require.NoError(t, err)
 		t.Cleanup(assertLogsLen(t, 0))
-		res, err := tc.BatchSimulateUnsigned([]SimMsg{{ID: int64(1), Msg: succeed}}, sn)
+		res, err := tc.BatchSimulateUnsigned(ctx, []SimMsg{{ID: int64(1), Msg: succeed}}, sn)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(res.Succeeded))
 		assert.Equal(t, int64(1), res.Succeeded[0].ID)
@@ -65,10 +157,10 @@ func TestBatchSim(t *testing.T) {
 	})
 
 	t.Run("single failure", func(t *testing.T) {
-		_, sn, err := tc.Account(accounts[0].Address)
+		_, sn, err := tc.Account(ctx, accounts[0].Address)
 		require.NoError(t, err)
 		t.Cleanup(assertLogsLen(t, 1))
-		res, err := tc.BatchSimulateUnsigned([]SimMsg{{ID: int64(1), Msg: fail}}, sn)
+		res, err := tc.BatchSimulateUnsigned(ctx, []SimMsg{{ID: int64(1), Msg: fail}}, sn)
 		require.NoError(t, err)
 		assert.Equal(t, 0, len(res.Succeeded))
 		require.Equal(t, 1, len(res.Failed))
@@ -76,10 +168,10 @@ func TestBatchSim(t *testing.T) {
 	})
 
 	t.Run("multi failure", func(t *testing.T) {
-		_, sn, err := tc.Account(accounts[0].Address)
+		_, sn, err := tc.Account(ctx, accounts[0].Address)
 		require.NoError(t, err)
 		t.Cleanup(assertLogsLen(t, 2))
-		res, err := tc.BatchSimulateUnsigned([]SimMsg{{ID: int64(1), Msg: succeed}, {ID: int64(2), Msg: fail}, {ID: int64(3), Msg: fail}}, sn)
+		res, err := tc.BatchSimulateUnsigned(ctx, []SimMsg{{ID: int64(1), Msg: succeed}, {ID: int64(2), Msg: fail}, {ID: int64(3), Msg: fail}}, sn)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(res.Succeeded))
 		assert.Equal(t, int64(1), res.Succeeded[0].ID)
@@ -89,38 +181,114 @@ func TestBatchSim(t *testing.T) {
 	})
 
 	t.Run("multi succeed", func(t *testing.T) {
-		_, sn, err := tc.Account(accounts[0].Address)
+		_, sn, err := tc.Account(ctx, accounts[0].Address)
 		require.NoError(t, err)
 		t.Cleanup(assertLogsLen(t, 1))
-		res, err := tc.BatchSimulateUnsigned([]SimMsg{{ID: int64(1), Msg: succeed}, {ID: int64(2), Msg: succeed}, {ID: int64(3), Msg: fail}}, sn)
+		res, err := tc.BatchSimulateUnsigned(ctx, []SimMsg{{ID: int64(1), Msg: succeed}, {ID: int64(2), Msg: succeed}, {ID: int64(3), Msg: fail}}, sn)
 		require.NoError(t, err)
 		assert.Equal(t, 2, len(res.Succeeded))
 		assert.Equal(t, 1, len(res.Failed))
 	})
 
 	t.Run("all succeed", func(t *testing.T) {
-		_, sn, err := tc.Account(accounts[0].Address)
+		_, sn, err := tc.Account(ctx, accounts[0].Address)
 		require.NoError(t, err)
 		t.Cleanup(assertLogsLen(t, 0))
-		res, err := tc.BatchSimulateUnsigned([]SimMsg{{ID: int64(1), Msg: succeed}, {ID: int64(2), Msg: succeed}, {ID: int64(3), Msg: succeed}}, sn)
+		res, err := tc.BatchSimulateUnsigned(ctx, []SimMsg{{ID: int64(1), Msg: succeed}, {ID: int64(2), Msg: succeed}, {ID: int64(3), Msg: succeed}}, sn)
 		require.NoError(t, err)
 		assert.Equal(t, 3, len(res.Succeeded))
 		assert.Equal(t, 0, len(res.Failed))
 	})
 
 	t.Run("all fail", func(t *testing.T) {
-		_, sn, err := tc.Account(accounts[0].Address)
+		_, sn, err := tc.Account(ctx, accounts[0].Address)
 		require.NoError(t, err)
 		t.Cleanup(assertLogsLen(t, 3))
-		res, err := tc.BatchSimulateUnsigned([]SimMsg{{ID: int64(1), Msg: fail}, {ID: int64(2), Msg: fail}, {ID: int64(3), Msg: fail}}, sn)
+		res, err := tc.BatchSimulateUnsigned(ctx, []SimMsg{{ID: int64(1), Msg: fail}, {ID: int64(2), Msg: fail}, {ID: int64(3), Msg: fail}}, sn)
 		require.NoError(t, err)
 		assert.Equal(t, 0, len(res.Succeeded))
 		assert.Equal(t, 3, len(res.Failed))
 	})
 }
 
+func TestBatchSimulate(t *testing.T) {
+	ctx := context.Background()
+	lggr, logs := logger.TestObserved(t, zap.WarnLevel)
+	c := &Client{log: lggr}
+	newMsgs := func(n int) (msgs SimMsgs) {
+		for i := 0; i < n; i++ {
+			msgs = append(msgs, SimMsg{ID: int64(i), Msg: &wasmtypes.MsgExecuteContract{ExecuteMsg: []byte(strconv.Itoa(i))}})
+		}
+		return
+	}
+	// fakeSim fails the first msg for which fails returns true, given the msgs executed before it.
+	fakeSim := func(sims *int64, fails func(msg string, prev []string) bool) func(context.Context, []sdk.Msg) error {
+		return func(_ context.Context, msgs []sdk.Msg) error {
+			atomic.AddInt64(sims, 1)
+			var prev []string
+			for i, m := range msgs {
+				msg := string(m.(*wasmtypes.MsgExecuteContract).ExecuteMsg)
+				if fails(msg, prev) {
+					return fmt.Errorf("rpc error: code = InvalidArgument desc = failed to execute message; message index: %d: msg %s failed: execute wasm contract failed: invalid request", i, msg)
+				}
+				prev = append(prev, msg)
+			}
+			return nil
+		}
+	}
+
+	t.Run("many failures", func(t *testing.T) {
+		const n = 100
+		failing := map[string]bool{"3": true, "41": true, "42": true, "97": true}
+		var sims int64
+		res, err := c.batchSimulate(ctx, newMsgs(n), fakeSim(&sims, func(msg string, _ []string) bool {
+			return failing[msg]
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3, 41, 42, 97}, sortedIDs(res.Failed))
+		require.Len(t, res.Succeeded, n-len(failing))
+		for i := 1; i < len(res.Succeeded); i++ {
+			assert.Less(t, res.Succeeded[i-1].ID, res.Succeeded[i].ID, "order is preserved")
+		}
+		for _, m := range res.Failed {
+			require.Error(t, res.Errors[m.ID])
+			assert.Contains(t, res.Errors[m.ID].Error(), fmt.Sprintf("msg %d failed", m.ID))
+		}
+		// O(k log n): less than the k sequential sims over all the msgs, plus a final sim of those which succeeded
+		assert.LessOrEqual(t, sims, int64(len(failing)*(7+1)+1))
+		assert.Len(t, logs.TakeAll(), len(failing))
+	})
+
+	t.Run("dependent failure", func(t *testing.T) {
+		// "2" fails if "1" was executed before it, e.g. a duplicate report
+		var sims int64
+		res, err := c.batchSimulate(ctx, newMsgs(3), fakeSim(&sims, func(msg string, prev []string) bool {
+			return msg == "0" || msg == "2" && len(prev) > 0 && prev[len(prev)-1] == "1"
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, []int64{0, 2}, sortedIDs(res.Failed))
+		require.Len(t, res.Succeeded, 1)
+		assert.Equal(t, int64(1), res.Succeeded[0].ID)
+		assert.Len(t, logs.TakeAll(), 2)
+	})
+
+	t.Run("other error", func(t *testing.T) {
+		_, err := c.batchSimulate(ctx, newMsgs(3), func(context.Context, []sdk.Msg) error {
+			return errors.New("connection refused")
+		})
+		require.EqualError(t, err, "connection refused")
+	})
+}
+
+func sortedIDs(msgs SimMsgs) []int64 {
+	ids := msgs.GetSimMsgsIDs()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
 func TestTerraClient(t *testing.T) {
 	// Local only for now, could maybe run on CI if we install terrad there?
+	ctx := context.Background()
 	accounts, testdir, tendermintURL := SetupLocalTerraNode(t, "42")
 	lggr := logger.Test(t)
 	tc, err := NewClient(
@@ -136,23 +304,23 @@ func TestTerraClient(t *testing.T) {
 
 	t.Run("send tx between accounts", func(t *testing.T) {
 		// Assert balance before
-		b, err := tc.Balance(accounts[1].Address, "uluna")
+		b, err := tc.Balance(ctx, accounts[1].Address, "uluna")
 		require.NoError(t, err)
 		assert.Equal(t, "100000000", b.Amount.String())
 
 		// Send a uluna from one account to another and ensure balances update
-		an, sn, err := tc.Account(accounts[0].Address)
+		an, sn, err := tc.Account(ctx, accounts[0].Address)
 		require.NoError(t, err)
 		fund := msg.NewMsgSend(accounts[0].Address, accounts[1].Address, msg.NewCoins(msg.NewInt64Coin("uluna", 1)))
-		gasLimit, err := tc.SimulateUnsigned([]msg.Msg{fund}, sn)
+		gasLimit, err := tc.SimulateUnsigned(ctx, []msg.Msg{fund}, sn)
 		require.NoError(t, err)
 		gasPrices, err := gpe.GasPrices()
 		require.NoError(t, err)
-		txBytes, err := tc.CreateAndSign([]msg.Msg{fund}, an, sn, gasLimit.GasInfo.GasUsed, DefaultGasLimitMultiplier, gasPrices["uluna"], accounts[0].PrivateKey, 0)
+		txBytes, err := tc.CreateAndSign(ctx, []msg.Msg{fund}, an, sn, gasLimit.GasInfo.GasUsed, DefaultGasLimitMultiplier, gasPrices["uluna"], NewPrivKeySigner(accounts[0].PrivateKey), nil, 0)
 		require.NoError(t, err)
-		_, err = tc.Simulate(txBytes)
+		_, err = tc.Simulate(ctx, txBytes)
 		require.NoError(t, err)
-		resp, err := tc.Broadcast(txBytes, txtypes.BroadcastMode_BROADCAST_MODE_BLOCK)
+		resp, err := tc.Broadcast(ctx, txBytes, txtypes.BroadcastMode_BROADCAST_MODE_BLOCK)
 		require.NoError(t, err)
 		require.Equal(t, types.CodeTypeOK, resp.TxResponse.Code)
 
@@ -160,28 +328,28 @@ func TestTerraClient(t *testing.T) {
 		time.Sleep(1 * time.Second)
 
 		// Assert balance changed
-		b, err = tc.Balance(accounts[1].Address, "uluna")
+		b, err = tc.Balance(ctx, accounts[1].Address, "uluna")
 		require.NoError(t, err)
 		assert.Equal(t, "100000001", b.Amount.String())
 
 		// Invalid tx should error
-		_, err = tc.Tx("1234")
+		_, err = tc.Tx(ctx, "1234")
 		require.Error(t, err)
 
 		// Ensure we can read back the tx with Query
-		tr, err := tc.TxsEvents([]string{fmt.Sprintf("tx.height=%v", resp.TxResponse.Height)}, nil)
+		tr, err := tc.TxsEvents(ctx, []string{fmt.Sprintf("tx.height=%v", resp.TxResponse.Height)}, nil)
 		require.NoError(t, err)
 		assert.Equal(t, 1, len(tr.TxResponses))
 		assert.Equal(t, resp.TxResponse.TxHash, tr.TxResponses[0].TxHash)
 		// And also Tx
-		getTx, err := tc.Tx(resp.TxResponse.TxHash)
+		getTx, err := tc.Tx(ctx, resp.TxResponse.TxHash)
 		require.NoError(t, err)
 		assert.Equal(t, getTx.TxResponse.TxHash, resp.TxResponse.TxHash)
 	})
 
 	t.Run("can get height", func(t *testing.T) {
 		// Check getting the height works
-		latestBlock, err := tc.LatestBlock()
+		latestBlock, err := tc.LatestBlock(ctx)
 		require.NoError(t, err)
 		assert.True(t, latestBlock.Block.Header.Height > 1)
 	})
@@ -189,6 +357,7 @@ func TestTerraClient(t *testing.T) {
 	t.Run("contract event querying", func(t *testing.T) {
 		// Query initial contract state
 		count, err := tc.ContractStore(
+			ctx,
 			contract,
 			[]byte(`{"get_count":{}}`),
 		)
@@ -196,6 +365,7 @@ func TestTerraClient(t *testing.T) {
 		assert.Equal(t, `{"count":0}`, string(count))
 		// Query invalid state should give an error
 		count, err = tc.ContractStore(
+			ctx,
 			contract,
 			[]byte(`{"blah":{}}`),
 		)
@@ -204,23 +374,24 @@ func TestTerraClient(t *testing.T) {
 
 		// Change the contract state
 		rawMsg := wasmtypes.NewMsgExecuteContract(accounts[0].Address, contract, []byte(`{"reset":{"count":5}}`), sdk.Coins{})
-		an, sn, err := tc.Account(accounts[0].Address)
+		an, sn, err := tc.Account(ctx, accounts[0].Address)
 		require.NoError(t, err)
 		gasPrices, err := gpe.GasPrices()
 		require.NoError(t, err)
-		resp1, err := tc.SignAndBroadcast([]msg.Msg{rawMsg}, an, sn, gasPrices["uluna"], accounts[0].PrivateKey, txtypes.BroadcastMode_BROADCAST_MODE_BLOCK)
+		resp1, err := tc.SignAndBroadcast(ctx, []msg.Msg{rawMsg}, an, sn, gasPrices["uluna"], NewPrivKeySigner(accounts[0].PrivateKey), nil, txtypes.BroadcastMode_BROADCAST_MODE_BLOCK)
 		require.NoError(t, err)
 		time.Sleep(1 * time.Second)
 		// Do it again so there are multiple executions
 		rawMsg = wasmtypes.NewMsgExecuteContract(accounts[0].Address, contract, []byte(`{"reset":{"count":4}}`), sdk.Coins{})
-		an, sn, err = tc.Account(accounts[0].Address)
+		an, sn, err = tc.Account(ctx, accounts[0].Address)
 		require.NoError(t, err)
-		_, err = tc.SignAndBroadcast([]msg.Msg{rawMsg}, an, sn, gasPrices["uluna"], accounts[0].PrivateKey, txtypes.BroadcastMode_BROADCAST_MODE_BLOCK)
+		_, err = tc.SignAndBroadcast(ctx, []msg.Msg{rawMsg}, an, sn, gasPrices["uluna"], NewPrivKeySigner(accounts[0].PrivateKey), nil, txtypes.BroadcastMode_BROADCAST_MODE_BLOCK)
 		require.NoError(t, err)
 		time.Sleep(1 * time.Second)
 
 		// Observe changed contract state
 		count, err = tc.ContractStore(
+			ctx,
 			contract,
 			[]byte(`{"get_count":{}}`),
 		)
@@ -229,7 +400,7 @@ func TestTerraClient(t *testing.T) {
 
 		// Check events querying works
 		// TxEvents sorts in a descending manner, so latest txes are first
-		ev, err := tc.TxsEvents([]string{fmt.Sprintf("wasm-reset.contract_address='%s'", contract.String())}, nil)
+		ev, err := tc.TxsEvents(ctx, []string{fmt.Sprintf("wasm-reset.contract_address='%s'", contract.String())}, nil)
 		require.NoError(t, err)
 		require.Equal(t, 2, len(ev.TxResponses))
 		foundCount := false
@@ -253,10 +424,10 @@ func TestTerraClient(t *testing.T) {
 		assert.True(t, foundContract)
 
 		// Ensure the height filtering works
-		ev, err = tc.TxsEvents([]string{fmt.Sprintf("tx.height>=%d", resp1.TxResponse.Height+1), fmt.Sprintf("wasm-reset.contract_address='%s'", contract.String())}, nil)
+		ev, err = tc.TxsEvents(ctx, []string{fmt.Sprintf("tx.height>=%d", resp1.TxResponse.Height+1), fmt.Sprintf("wasm-reset.contract_address='%s'", contract.String())}, nil)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(ev.TxResponses))
-		ev, err = tc.TxsEvents([]string{fmt.Sprintf("tx.height=%d", resp1.TxResponse.Height), fmt.Sprintf("wasm-reset.contract_address='%s'", contract)}, nil)
+		ev, err = tc.TxsEvents(ctx, []string{fmt.Sprintf("tx.height=%d", resp1.TxResponse.Height), fmt.Sprintf("wasm-reset.contract_address='%s'", contract)}, nil)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(ev.TxResponses))
 		for _, ev := range ev.TxResponses[0].Logs[0].Events {
@@ -301,9 +472,9 @@ func TestTerraClient(t *testing.T) {
 		} {
 			t.Run(tt.name, func(t *testing.T) {
 				t.Log("Gas price:", tt.gasPrice)
-				an, sn, err := tc.Account(accounts[0].Address)
+				an, sn, err := tc.Account(ctx, accounts[0].Address)
 				require.NoError(t, err)
-				resp, err := tc.SignAndBroadcast([]msg.Msg{rawMsg}, an, sn, tt.gasPrice, accounts[0].PrivateKey, txtypes.BroadcastMode_BROADCAST_MODE_BLOCK)
+				resp, err := tc.SignAndBroadcast(ctx, []msg.Msg{rawMsg}, an, sn, tt.gasPrice, NewPrivKeySigner(accounts[0].PrivateKey), nil, txtypes.BroadcastMode_BROADCAST_MODE_BLOCK)
 				if tt.expCode == 0 {
 					require.NoError(t, err)
 				} else {
@@ -318,7 +489,7 @@ func TestTerraClient(t *testing.T) {
 				require.Equal(t, tt.expCode, resp.TxResponse.Code)
 				if tt.expCode == 0 {
 					time.Sleep(2 * time.Second)
-					txResp, err := tc.Tx(resp.TxResponse.TxHash)
+					txResp, err := tc.Tx(ctx, resp.TxResponse.TxHash)
 					require.NoError(t, err)
 					t.Log("Fee:", txResp.Tx.GetFee())
 					t.Log("Height:", txResp.TxResponse.Height)
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ABCIError is an error response from the ABCI application, to a query (including simulation) or a tx.
+// Use errors.As with the more specific types below to react to particular failures, or errors.Is with
+// registered sdk errors, e.g. errors.Is(err, sdkerrors.ErrMempoolIsFull).
+type ABCIError struct {
+	Codespace string
+	Code      uint32
+	Log       string
+}
+
+func (e *ABCIError) Error() string {
+	return fmt.Sprintf("%s (codespace: %s, code: %d)", e.Log, e.Codespace, e.Code)
+}
+
+// Is returns true if target is a registered sdk error with the same codespace and code.
+func (e *ABCIError) Is(target error) bool {
+	t, ok := target.(*sdkerrors.Error)
+	return ok && t.Codespace() == e.Codespace && t.ABCICode() == e.Code
+}
+
+// GRPCStatus implements the interface used by status.FromError, since query errors stand in for grpc errors.
+func (e *ABCIError) GRPCStatus() *status.Status {
+	c := codes.Unknown
+	if e.Codespace == sdkerrors.RootCodespace {
+		switch e.Code {
+		case sdkerrors.ErrInvalidRequest.ABCICode():
+			c = codes.InvalidArgument
+		case sdkerrors.ErrUnauthorized.ABCICode():
+			c = codes.Unauthenticated
+		case sdkerrors.ErrKeyNotFound.ABCICode():
+			c = codes.NotFound
+		}
+	}
+	return status.New(c, e.Log)
+}
+
+// ErrOutOfGas is returned when a tx runs out of gas.
+type ErrOutOfGas struct {
+	*ABCIError
+	GasWanted, GasUsed uint64
+}
+
+func (e *ErrOutOfGas) Unwrap() error { return e.ABCIError }
+
+// ErrInsufficientFee is returned when a tx fee is below the node's minimum gas price.
+type ErrInsufficientFee struct {
+	*ABCIError
+	Got, Required string // coins, e.g. 100uluna
+}
+
+func (e *ErrInsufficientFee) Unwrap() error { return e.ABCIError }
+
+// ErrSequenceMismatch is returned when a tx sequence does not match the account sequence.
+type ErrSequenceMismatch struct {
+	*ABCIError
+	Expected, Got uint64
+}
+
+func (e *ErrSequenceMismatch) Unwrap() error { return e.ABCIError }
+
+// ErrTxTooLarge is returned when a tx exceeds the mempool's max tx size.
+type ErrTxTooLarge struct {
+	*ABCIError
+}
+
+func (e *ErrTxTooLarge) Unwrap() error { return e.ABCIError }
+
+// ErrHeightUnavailable is returned when querying state at a height which the node has pruned, or not yet reached.
+type ErrHeightUnavailable struct {
+	*ABCIError
+	Height, Latest int64 // 0 if unknown
+}
+
+func (e *ErrHeightUnavailable) Unwrap() error { return e.ABCIError }
+
+// ErrMsgFailed is returned when a msg fails to execute. Only the first failing msg of a tx is reported.
+type ErrMsgFailed struct {
+	*ABCIError
+	MsgIndex int
+	// Reason is the error after the msg index.
+	Reason string
+	// ContractError is the contract's own error, if a wasm contract failed to execute.
+	ContractError string
+}
+
+func (e *ErrMsgFailed) Unwrap() error { return e.ABCIError }
+
+var (
+	failedMsgRe        = regexp.MustCompile(`failed to execute message; message index: (\d+): (.*)$`)
+	contractErrorRe    = regexp.MustCompile(`^(.*): ` + regexp.QuoteMeta(wasmExecuteFailed) + `(: .*)?$`)
+	sequenceMismatchRe = regexp.MustCompile(`account sequence mismatch, expected (\d+), got (\d+)`)
+	outOfGasRe         = regexp.MustCompile(`gasWanted: (\d+), gasUsed: (\d+)`)
+	insufficientFeeRe  = regexp.MustCompile(`insufficient fees; got: ([^\s:]*) required: ([^\s:]*)`)
+	heightPrunedRe     = regexp.MustCompile(`failed to load state at height (\d+); .*\(latest height: (\d+)\)`)
+	heightFutureRe     = regexp.MustCompile(`cannot query with height in the future; please provide a valid height`)
+)
+
+// wasmExecuteFailed is the description of the wasm module's ErrExecuteFailed.
+const wasmExecuteFailed = "execute wasm contract failed"
+
+// newABCIError returns the most specific error for the response.
+// Simulation errors may be re-wrapped with generic codes, so the log is also matched.
+func newABCIError(codespace string, code uint32, log string) error {
+	base := &ABCIError{Codespace: codespace, Code: code, Log: log}
+	is := func(err *sdkerrors.Error) bool { return base.Is(err) }
+	if m := failedMsgRe.FindStringSubmatch(log); m != nil {
+		if i, err := strconv.Atoi(m[1]); err == nil {
+			e := &ErrMsgFailed{ABCIError: base, MsgIndex: i, Reason: m[2]}
+			if c := contractErrorRe.FindStringSubmatch(m[2]); c != nil {
+				e.ContractError = c[1]
+			}
+			return e
+		}
+	}
+	if m := sequenceMismatchRe.FindStringSubmatch(log); is(sdkerrors.ErrWrongSequence) || m != nil {
+		e := &ErrSequenceMismatch{ABCIError: base}
+		if m != nil {
+			e.Expected, _ = strconv.ParseUint(m[1], 10, 64)
+			e.Got, _ = strconv.ParseUint(m[2], 10, 64)
+		}
+		return e
+	}
+	if is(sdkerrors.ErrOutOfGas) {
+		e := &ErrOutOfGas{ABCIError: base}
+		if m := outOfGasRe.FindStringSubmatch(log); m != nil {
+			e.GasWanted, _ = strconv.ParseUint(m[1], 10, 64)
+			e.GasUsed, _ = strconv.ParseUint(m[2], 10, 64)
+		}
+		return e
+	}
+	if m := insufficientFeeRe.FindStringSubmatch(log); is(sdkerrors.ErrInsufficientFee) || m != nil {
+		e := &ErrInsufficientFee{ABCIError: base}
+		if m != nil {
+			e.Got, e.Required = m[1], m[2]
+		}
+		return e
+	}
+	if m := heightPrunedRe.FindStringSubmatch(log); m != nil {
+		e := &ErrHeightUnavailable{ABCIError: base}
+		e.Height, _ = strconv.ParseInt(m[1], 10, 64)
+		e.Latest, _ = strconv.ParseInt(m[2], 10, 64)
+		return e
+	}
+	if heightFutureRe.MatchString(log) {
+		return &ErrHeightUnavailable{ABCIError: base}
+	}
+	if is(sdkerrors.ErrTxTooLarge) {
+		return &ErrTxTooLarge{ABCIError: base}
+	}
+	return base
+}
+
+// queryError returns the error for a failed ABCI query.
+func queryError(resp abci.ResponseQuery) error {
+	return newABCIError(resp.Codespace, resp.Code, resp.Log)
+}
+
+// txError returns the error for a failed tx, or nil if it succeeded.
+func txError(resp *sdk.TxResponse) error {
+	if resp.Code == 0 {
+		return nil
+	}
+	err := newABCIError(resp.Codespace, resp.Code, resp.RawLog)
+	if oog, ok := err.(*ErrOutOfGas); ok {
+		oog.GasWanted, oog.GasUsed = uint64(resp.GasWanted), uint64(resp.GasUsed)
+	}
+	return err
+}
+
+// ErrorClass returns a short, stable name for the kind of err, e.g. for labelling metrics.
+// Errors which are not from the ABCI application, like connection failures, are "other".
+func ErrorClass(err error) string {
+	var (
+		msgFailed *ErrMsgFailed
+		seq       *ErrSequenceMismatch
+		oog       *ErrOutOfGas
+		fee       *ErrInsufficientFee
+		tooLarge  *ErrTxTooLarge
+		height    *ErrHeightUnavailable
+		abciErr   *ABCIError
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &msgFailed):
+		return "msg_failed"
+	case errors.As(err, &seq):
+		return "sequence_mismatch"
+	case errors.As(err, &oog):
+		return "out_of_gas"
+	case errors.As(err, &fee):
+		return "insufficient_fee"
+	case errors.As(err, &tooLarge):
+		return "tx_too_large"
+	case errors.As(err, &height):
+		return "height_unavailable"
+	case errors.Is(err, sdkerrors.ErrMempoolIsFull):
+		return "mempool_full"
+	case errors.As(err, &abciErr):
+		return "abci"
+	}
+	return "other"
+}
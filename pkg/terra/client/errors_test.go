@@ -0,0 +1,121 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewABCIError(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		codespace string
+		code      uint32
+		log       string
+		exp       error
+	}{
+		{"generic", "sdk", 5, "1uluna is smaller than 2uluna: insufficient funds", nil},
+		{"msg failed", "sdk", 18,
+			"failed to execute message; message index: 10: Error parsing into type my_first_contract::msg::ExecuteMsg: unknown variant `blah`: execute wasm contract failed: invalid request",
+			&ErrMsgFailed{MsgIndex: 10,
+				Reason:        "Error parsing into type my_first_contract::msg::ExecuteMsg: unknown variant `blah`: execute wasm contract failed: invalid request",
+				ContractError: "Error parsing into type my_first_contract::msg::ExecuteMsg: unknown variant `blah`"}},
+		{"msg failed wasm", "wasm", 4,
+			"failed to execute message; message index: 0: Generic error: stale report: execute wasm contract failed",
+			&ErrMsgFailed{MsgIndex: 0, Reason: "Generic error: stale report: execute wasm contract failed", ContractError: "Generic error: stale report"}},
+		{"msg failed other", "sdk", 5,
+			"failed to execute message; message index: 2: 1uluna is smaller than 2uluna: insufficient funds",
+			&ErrMsgFailed{MsgIndex: 2, Reason: "1uluna is smaller than 2uluna: insufficient funds"}},
+		{"sequence", "sdk", 32, "account sequence mismatch, expected 5, got 4: incorrect account sequence",
+			&ErrSequenceMismatch{Expected: 5, Got: 4}},
+		{"sequence simulation", "sdk", 18, "rpc error: code = Unknown desc = account sequence mismatch, expected 12, got 11: incorrect account sequence: invalid request",
+			&ErrSequenceMismatch{Expected: 12, Got: 11}},
+		{"out of gas", "sdk", 11, "out of gas in location: WriteFlat; gasWanted: 1000, gasUsed: 1200: out of gas",
+			&ErrOutOfGas{GasWanted: 1000, GasUsed: 1200}},
+		{"insufficient fee", "sdk", 13, "insufficient fees; got: 100uluna required: 150uluna: insufficient fee",
+			&ErrInsufficientFee{Got: "100uluna", Required: "150uluna"}},
+		{"too large", "sdk", 21, "", &ErrTxTooLarge{}},
+		{"height pruned", "sdk", 18, "failed to load state at height 5; version does not exist (latest height: 100): invalid request",
+			&ErrHeightUnavailable{Height: 5, Latest: 100}},
+		{"height future", "sdk", 18, "cannot query with height in the future; please provide a valid height: invalid height",
+			&ErrHeightUnavailable{}},
+		{"other codespace", "wasm", 21, "not too large", nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newABCIError(tt.codespace, tt.code, tt.log)
+			base := &ABCIError{Codespace: tt.codespace, Code: tt.code, Log: tt.log}
+			switch exp := tt.exp.(type) {
+			case nil:
+				assert.Equal(t, base, err)
+			case *ErrMsgFailed:
+				exp.ABCIError = base
+			case *ErrSequenceMismatch:
+				exp.ABCIError = base
+			case *ErrOutOfGas:
+				exp.ABCIError = base
+			case *ErrInsufficientFee:
+				exp.ABCIError = base
+			case *ErrTxTooLarge:
+				exp.ABCIError = base
+			case *ErrHeightUnavailable:
+				exp.ABCIError = base
+			}
+			if tt.exp != nil {
+				assert.Equal(t, tt.exp, err)
+			}
+			var abciErr *ABCIError
+			require.True(t, errors.As(err, &abciErr))
+			assert.Equal(t, base, abciErr)
+		})
+	}
+}
+
+func TestABCIError(t *testing.T) {
+	err := newABCIError("sdk", 32, "account sequence mismatch, expected 5, got 4: incorrect account sequence")
+	assert.True(t, errors.Is(err, sdkerrors.ErrWrongSequence))
+	assert.False(t, errors.Is(err, sdkerrors.ErrOutOfGas))
+
+	// query errors are grpc status errors, e.g. so MultiNodeClient does not fail over
+	s, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.Unknown, s.Code())
+	s, ok = status.FromError(newABCIError("sdk", 18, "bad"))
+	require.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, s.Code())
+	assert.Equal(t, "bad", s.Message())
+
+	assert.NoError(t, txError(&sdk.TxResponse{}))
+	err = txError(&sdk.TxResponse{Codespace: "sdk", Code: 11, RawLog: "out of gas in location: WriteFlat: out of gas", GasWanted: 10, GasUsed: 12})
+	var oog *ErrOutOfGas
+	require.ErrorAs(t, err, &oog)
+	assert.Equal(t, uint64(10), oog.GasWanted)
+	assert.Equal(t, uint64(12), oog.GasUsed)
+}
+
+func TestErrorClass(t *testing.T) {
+	for _, tt := range []struct {
+		err error
+		exp string
+	}{
+		{nil, ""},
+		{errors.New("connection refused"), "other"},
+		{newABCIError("sdk", 5, "1uluna is smaller than 2uluna: insufficient funds"), "abci"},
+		{newABCIError("sdk", 20, "mempool is full"), "mempool_full"},
+		{newABCIError("sdk", 32, "account sequence mismatch, expected 5, got 4: incorrect account sequence"), "sequence_mismatch"},
+		{newABCIError("sdk", 11, "out of gas in location: WriteFlat: out of gas"), "out_of_gas"},
+		{newABCIError("sdk", 13, "insufficient fees; got: 100uluna required: 150uluna: insufficient fee"), "insufficient_fee"},
+		{newABCIError("sdk", 21, ""), "tx_too_large"},
+		{newABCIError("sdk", 18, "cannot query with height in the future; please provide a valid height: invalid height"), "height_unavailable"},
+		{newABCIError("wasm", 4, "failed to execute message; message index: 0: Generic error: stale report: execute wasm contract failed"), "msg_failed"},
+		{pkgerrors.Wrap(newABCIError("sdk", 32, "account sequence mismatch, expected 5, got 4"), "tx 0x1 failed"), "sequence_mismatch"},
+	} {
+		assert.Equal(t, tt.exp, ErrorClass(tt.err), "%v", tt.err)
+	}
+}
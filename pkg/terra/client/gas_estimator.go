@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/cosmos/cosmos-sdk/x/authz"
+	"github.com/pkg/errors"
+	wasmtypes "github.com/terra-money/core/x/wasm/types"
+
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+
+	"github.com/smartcontractkit/terra.go/tx"
+)
+
+const (
+	// correctionDecay is the weight of each new observation when a correction factor decreases.
+	// Increases are applied immediately, to avoid repeatedly running out of gas.
+	correctionDecay = 0.2
+	// gasHeadroom is added on top of corrected estimates, to absorb variation between txs.
+	gasHeadroom = 0.05
+	// secp256k1SigSize is the size of a secp256k1 signature.
+	secp256k1SigSize = 64
+)
+
+// Simulator simulates signed txs.
+type Simulator interface {
+	Simulate(ctx context.Context, txBytes []byte) (*txtypes.SimulateResponse, error)
+}
+
+// UnsignedTx describes a tx to be signed.
+type UnsignedTx struct {
+	Msgs          []sdk.Msg
+	Sequence      uint64
+	PubKey        cryptotypes.PubKey // of the signer
+	GasPrice      sdk.DecCoin
+	FeeGranter    sdk.AccAddress // nil if unset
+	TimeoutHeight uint64         // 0 if unset
+}
+
+// GasEstimate is an estimated gas limit, along with the simulated gas it was derived from.
+type GasEstimate struct {
+	// Simulated is the simulated gas, including the cost of any bytes not present in the simulated tx.
+	Simulated uint64
+	// Limit is the gas limit to sign with.
+	Limit uint64
+}
+
+// GasEstimator estimates gas limits by simulating txs as they will be signed, and correcting the estimates
+// by a factor learned per contract from the gas actually used by confirmed txs.
+//
+// Unsigned simulations omit the fee, so the gas to deduct it is not counted, and approximate the size of the signature.
+// Instead, txs are simulated with the signer's public key, a placeholder signature, and a minimal fee, so that
+// signature verification, fee deduction, and the size of all but the fee and gas limit are counted. The known
+// per byte cost of the final fee and gas limit is then added.
+// It is safe for concurrent use.
+type GasEstimator struct {
+	s    Simulator
+	lggr logger.Logger
+
+	mu          sync.RWMutex
+	corrections map[string]float64 // by contract address
+}
+
+// NewGasEstimator returns a GasEstimator which simulates via s.
+func NewGasEstimator(s Simulator, lggr logger.Logger) *GasEstimator {
+	return &GasEstimator{s: s, lggr: lggr, corrections: make(map[string]float64)}
+}
+
+// Estimate returns the estimated gas limit for utx. The simulated gas is multiplied by the learned correction factor,
+// or by fallbackMultiplier if there is no history for one or more contracts executed by utx.
+func (e *GasEstimator) Estimate(ctx context.Context, utx UnsignedTx, fallbackMultiplier float64) (GasEstimate, error) {
+	minFee := sdk.NewCoins(sdk.NewCoin(utx.GasPrice.Denom, sdk.OneInt()))
+	simBytes, err := buildWithPlaceholderSig(utx, 0, minFee)
+	if err != nil {
+		return GasEstimate{}, errors.Wrap(err, "failed to build tx")
+	}
+	sim, err := e.s.Simulate(ctx, simBytes)
+	if err != nil {
+		return GasEstimate{}, errors.Wrap(err, "failed to simulate")
+	}
+	simulated := sim.GasInfo.GasUsed
+
+	multiplier, ok := e.correction(utx.Msgs)
+	if ok {
+		multiplier *= 1 + gasHeadroom
+	} else {
+		multiplier = fallbackMultiplier
+	}
+	// Size the final fee and gas limit generously, since they depend on the result. Each extra byte costs little.
+	upper := gasLimit(simulated, 2*multiplier)
+	finalBytes, err := buildWithPlaceholderSig(utx, upper, fee(utx.GasPrice, upper))
+	if err != nil {
+		return GasEstimate{}, errors.Wrap(err, "failed to build tx")
+	}
+	if extra := len(finalBytes) - len(simBytes); extra > 0 {
+		simulated += uint64(extra) * authtypes.DefaultTxSizeCostPerByte
+	}
+	return GasEstimate{Simulated: simulated, Limit: gasLimit(simulated, multiplier)}, nil
+}
+
+// Observe records the gasUsed by a confirmed tx of msgs, for which simulated gas was estimated.
+func (e *GasEstimator) Observe(msgs []sdk.Msg, simulated, gasUsed uint64) {
+	if simulated == 0 || gasUsed == 0 {
+		return
+	}
+	ratio := float64(gasUsed) / float64(simulated)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, c := range executedContracts(msgs) {
+		prev, ok := e.corrections[c]
+		if !ok || ratio > prev {
+			e.corrections[c] = ratio
+		} else {
+			e.corrections[c] = prev + correctionDecay*(ratio-prev)
+		}
+		e.lggr.Debugw("Updated gas correction", "contract", c, "ratio", ratio, "correction", e.corrections[c])
+	}
+}
+
+// correction returns the highest correction factor of the contracts executed by msgs, but no less than 1,
+// or false if any contract has no history.
+func (e *GasEstimator) correction(msgs []sdk.Msg) (float64, bool) {
+	contracts := executedContracts(msgs)
+	if len(contracts) == 0 {
+		return 0, false
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	factor := 1.0
+	for _, c := range contracts {
+		f, ok := e.corrections[c]
+		if !ok {
+			return 0, false
+		}
+		factor = math.Max(factor, f)
+	}
+	return factor, true
+}
+
+// executedContracts returns the addresses of the contracts executed by msgs, including via authz.
+func executedContracts(msgs []sdk.Msg) (contracts []string) {
+	for _, m := range msgs {
+		switch m := m.(type) {
+		case *wasmtypes.MsgExecuteContract:
+			contracts = append(contracts, m.Contract)
+		case *authz.MsgExec:
+			inner, err := m.GetMessages()
+			if err == nil {
+				contracts = append(contracts, executedContracts(inner)...)
+			}
+		}
+	}
+	return
+}
+
+func gasLimit(gas uint64, multiplier float64) uint64 {
+	return uint64(math.Ceil(float64(gas) * multiplier))
+}
+
+func fee(gasPrice sdk.DecCoin, gasLimit uint64) sdk.Coins {
+	return sdk.NewCoins(sdk.NewCoin(gasPrice.Denom, gasPrice.Amount.MulInt64(int64(gasLimit)).Ceil().RoundInt()))
+}
+
+// buildWithPlaceholderSig returns utx encoded with gasLimit, fees, and a placeholder signature of the right size.
+func buildWithPlaceholderSig(utx UnsignedTx, gasLimit uint64, fees sdk.Coins) ([]byte, error) {
+	txbuilder := tx.NewTxBuilder(encodingConfig.TxConfig)
+	if err := txbuilder.SetMsgs(utx.Msgs...); err != nil {
+		return nil, err
+	}
+	txbuilder.SetGasLimit(gasLimit)
+	txbuilder.SetFeeAmount(fees)
+	txbuilder.SetFeeGranter(utx.FeeGranter)
+	txbuilder.SetTimeoutHeight(utx.TimeoutHeight)
+	sig := signing.SignatureV2{
+		PubKey: utx.PubKey,
+		Data: &signing.SingleSignatureData{
+			SignMode:  signing.SignMode_SIGN_MODE_DIRECT,
+			Signature: make([]byte, secp256k1SigSize),
+		},
+		Sequence: utx.Sequence,
+	}
+	if err := txbuilder.SetSignatures(sig); err != nil {
+		return nil, err
+	}
+	return txbuilder.GetTxBytes()
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/cosmos/cosmos-sdk/x/authz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	wasmtypes "github.com/terra-money/core/x/wasm/types"
+
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+)
+
+type simulatorFunc func(ctx context.Context, txBytes []byte) (*txtypes.SimulateResponse, error)
+
+func (f simulatorFunc) Simulate(ctx context.Context, txBytes []byte) (*txtypes.SimulateResponse, error) {
+	return f(ctx, txBytes)
+}
+
+func TestGasEstimator(t *testing.T) {
+	ctx := context.Background()
+	pubKey := secp256k1.GenPrivKey().PubKey()
+	sender := sdk.AccAddress(pubKey.Address())
+	contractA, contractB := sdk.AccAddress("contract_a__________"), sdk.AccAddress("contract_b__________")
+	gasPrice := sdk.NewDecCoinFromDec("uluna", sdk.MustNewDecFromStr("0.015"))
+	newTx := func(contracts ...sdk.AccAddress) UnsignedTx {
+		var msgs []sdk.Msg
+		for _, c := range contracts {
+			msgs = append(msgs, wasmtypes.NewMsgExecuteContract(sender, c, []byte(`{}`), nil))
+		}
+		return UnsignedTx{Msgs: msgs, Sequence: 7, PubKey: pubKey, GasPrice: gasPrice, TimeoutHeight: 100}
+	}
+
+	var simulated int
+	ge := NewGasEstimator(simulatorFunc(func(_ context.Context, txBytes []byte) (*txtypes.SimulateResponse, error) {
+		simulated++
+		decoded, err := encodingConfig.TxConfig.TxDecoder()(txBytes)
+		require.NoError(t, err)
+		// Simulated as signed, with a payable fee.
+		sigTx := decoded.(authsigning.Tx)
+		sigs, err := sigTx.GetSignaturesV2()
+		require.NoError(t, err)
+		require.Len(t, sigs, 1)
+		assert.True(t, pubKey.Equals(sigs[0].PubKey))
+		assert.Equal(t, uint64(7), sigs[0].Sequence)
+		assert.Len(t, sigs[0].Data.(*signing.SingleSignatureData).Signature, secp256k1SigSize)
+		assert.Equal(t, "1uluna", sigTx.GetFee().String())
+		return &txtypes.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 100_000}}, nil
+	}), logger.Test(t))
+
+	// The simulated gas includes the bytes of the final fee and gas limit.
+	est, err := ge.Estimate(ctx, newTx(contractA), 1.5)
+	require.NoError(t, err)
+	assert.Equal(t, 1, simulated)
+	assert.Greater(t, est.Simulated, uint64(100_000))
+	assert.Less(t, est.Simulated, uint64(100_000+10*authtypes.DefaultTxSizeCostPerByte))
+	assert.Equal(t, gasLimit(est.Simulated, 1.5), est.Limit, "no history: fallback")
+
+	// Corrected by the observed ratio, with headroom.
+	ge.Observe(newTx(contractA).Msgs, est.Simulated, est.Simulated*11/10)
+	corrected, err := ge.Estimate(ctx, newTx(contractA), 1.5)
+	require.NoError(t, err)
+	assert.Equal(t, est.Simulated, corrected.Simulated)
+	ratio := float64(est.Simulated*11/10) / float64(est.Simulated)
+	assert.Equal(t, gasLimit(est.Simulated, ratio*(1+gasHeadroom)), corrected.Limit)
+	assert.Less(t, corrected.Limit, est.Limit)
+
+	// Lower observations decay the correction, but never below the simulated gas.
+	for i := 0; i < 50; i++ {
+		ge.Observe(newTx(contractA).Msgs, est.Simulated, est.Simulated/2)
+	}
+	f, ok := ge.correction(newTx(contractA).Msgs)
+	require.True(t, ok)
+	assert.Equal(t, 1.0, f)
+	// Higher observations apply immediately.
+	ge.Observe(newTx(contractA).Msgs, est.Simulated, est.Simulated*2)
+	f, ok = ge.correction(newTx(contractA).Msgs)
+	require.True(t, ok)
+	assert.InDelta(t, 2.0, f, 0.001)
+
+	// Every contract needs history.
+	mixed, err := ge.Estimate(ctx, newTx(contractA, contractB), 1.5)
+	require.NoError(t, err)
+	assert.Equal(t, gasLimit(mixed.Simulated, 1.5), mixed.Limit)
+	ge.Observe(newTx(contractB).Msgs, est.Simulated, est.Simulated)
+	mixed, err = ge.Estimate(ctx, newTx(contractA, contractB), 1.5)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(math.Ceil(float64(mixed.Simulated)*f*(1+gasHeadroom))), mixed.Limit)
+
+	// Including via authz.
+	exec := authz.NewMsgExec(sender, newTx(contractB).Msgs)
+	f, ok = ge.correction([]sdk.Msg{&exec})
+	require.True(t, ok)
+	assert.Equal(t, 1.0, f)
+}
@@ -1,19 +1,25 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/url"
-	"reflect"
-	"strings"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
 	"go.uber.org/multierr"
 
+	tmtypes "github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
@@ -66,30 +72,6 @@ func NewFCDGasPriceEstimator(cfg Config, requestTimeout time.Duration, lggr logg
 	return &gpe
 }
 
-type pricesFCD struct {
-	Uluna string `json:"uluna"`
-	Usdr  string `json:"usdr"`
-	Uusd  string `json:"uusd"`
-	Ukrw  string `json:"ukrw"`
-	Umnt  string `json:"umnt"`
-	Ueur  string `json:"ueur"`
-	Ucny  string `json:"ucny"`
-	Ujpy  string `json:"ujpy"`
-	Ugbp  string `json:"ugbp"`
-	Uinr  string `json:"uinr"`
-	Ucad  string `json:"ucad"`
-	Uchf  string `json:"uchf"`
-	Uaud  string `json:"uaud"`
-	Usgd  string `json:"usgd"`
-	Uthb  string `json:"uthb"`
-	Usek  string `json:"usek"`
-	Unok  string `json:"unok"`
-	Udkk  string `json:"udkk"`
-	Uidr  string `json:"uidr"`
-	Uphp  string `json:"uphp"`
-	Uhkd  string `json:"uhkd"`
-}
-
 func (gpe *FCDGasPriceEstimator) request() (map[string]sdk.DecCoin, error) {
 	fcdURL := gpe.cfg.FCDURL()
 	if fcdURL == (url.URL{}) {
@@ -110,24 +92,48 @@ func (gpe *FCDGasPriceEstimator) request() (map[string]sdk.DecCoin, error) {
 		gpe.lggr.Errorf("error reading body from %s, err %v", fcdURL, err)
 		return nil, err
 	}
-	var prices pricesFCD
+	// Parsed per denom, so that one malformed entry does not prevent using the others.
+	var prices map[string]json.RawMessage
 	if err := json.Unmarshal(b, &prices); err != nil {
 		gpe.lggr.Errorf("error unmarshalling from %s, err %v", fcdURL, err)
 		return nil, err
 	}
-	results := make(map[string]sdk.DecCoin)
-	v := reflect.ValueOf(prices)
-	for i := 0; i < v.NumField(); i++ {
-		name, value := strings.ToLower(v.Type().Field(i).Name), v.Field(i).String()
-		amount, err := sdk.NewDecFromStr(value)
+	results := make(map[string]sdk.DecCoin, len(prices))
+	for denom, raw := range prices {
+		var value string
+		err := json.Unmarshal(raw, &value)
+		if err != nil {
+			gpe.lggr.Warnw("Skipping invalid gas price", "url", fcdURL.String(), "denom", denom, "price", string(raw), "err", err)
+			continue
+		}
+		price, err := parseGasPrice(denom, value)
 		if err != nil {
-			return nil, err
+			gpe.lggr.Warnw("Skipping invalid gas price", "url", fcdURL.String(), "denom", denom, "price", value, "err", err)
+			continue
 		}
-		results[name] = sdk.NewDecCoinFromDec(name, amount)
+		results[denom] = price
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no valid gas prices from %s", fcdURL.String())
 	}
 	return results, nil
 }
 
+// parseGasPrice returns the gas price of value in denom, which must be positive.
+func parseGasPrice(denom, value string) (sdk.DecCoin, error) {
+	if err := sdk.ValidateDenom(denom); err != nil {
+		return sdk.DecCoin{}, err
+	}
+	amount, err := sdk.NewDecFromStr(value)
+	if err != nil {
+		return sdk.DecCoin{}, err
+	}
+	if !amount.IsPositive() {
+		return sdk.DecCoin{}, fmt.Errorf("non-positive price %s", amount)
+	}
+	return sdk.NewDecCoinFromDec(denom, amount), nil
+}
+
 func (gpe *FCDGasPriceEstimator) GasPrices() (map[string]sdk.DecCoin, error) {
 	return gpe.request()
 }
@@ -157,26 +163,444 @@ func (gpe *CachingGasPriceEstimator) GasPrices() (map[string]sdk.DecCoin, error)
 	return latestPrices, nil
 }
 
+var promGasPriceSelected = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "terra_gas_price_estimator_selected",
+	Help: "Number of times each estimator's gas price was selected by a composed estimator",
+}, []string{"estimator", "denom", "strategy"})
+
+// GasPriceStrategy is how a ComposedGasPriceEstimator combines the prices from its estimators.
+type GasPriceStrategy int
+
+const (
+	// GasPriceFirstSuccess uses the prices from the first estimator which succeeds, in order.
+	GasPriceFirstSuccess GasPriceStrategy = iota
+	// GasPriceMedian uses the lower median price per denom across the estimators which succeed,
+	// so that each price is one reported by an estimator.
+	GasPriceMedian
+	// GasPriceMax uses the highest price per denom across the estimators which succeed.
+	GasPriceMax
+)
+
+func (s GasPriceStrategy) String() string {
+	switch s {
+	case GasPriceFirstSuccess:
+		return "first_success"
+	case GasPriceMedian:
+		return "median"
+	case GasPriceMax:
+		return "max"
+	}
+	return fmt.Sprintf("GasPriceStrategy(%d)", int(s))
+}
+
+// NamedGasPricesEstimator is a GasPricesEstimator with a name for logs and metrics.
+type NamedGasPricesEstimator struct {
+	Name string
+	GasPricesEstimator
+}
+
+var _ GasPricesEstimator = (*ComposedGasPriceEstimator)(nil)
+
+// ComposedGasPriceEstimator is a GasPricesEstimator which combines the prices from multiple estimators,
+// according to a GasPriceStrategy. It only fails if every estimator fails.
 type ComposedGasPriceEstimator struct {
-	estimators []GasPricesEstimator
+	estimators []NamedGasPricesEstimator
+	strategy   GasPriceStrategy
 	lggr       logger.Logger
 }
 
+func NewComposedGasPriceEstimator(strategy GasPriceStrategy, estimators []NamedGasPricesEstimator, lggr logger.Logger) *ComposedGasPriceEstimator {
+	return &ComposedGasPriceEstimator{estimators: estimators, strategy: strategy, lggr: lggr}
+}
+
+// NewMustGasPriceEstimator returns a ComposedGasPriceEstimator which uses the first of estimators to succeed,
+// named by their index.
+//
+// Deprecated: use NewComposedGasPriceEstimator with GasPriceFirstSuccess.
 func NewMustGasPriceEstimator(estimators []GasPricesEstimator, lggr logger.Logger) *ComposedGasPriceEstimator {
-	return &ComposedGasPriceEstimator{estimators: estimators, lggr: lggr}
+	named := make([]NamedGasPricesEstimator, len(estimators))
+	for i, e := range estimators {
+		named[i] = NamedGasPricesEstimator{Name: strconv.Itoa(i), GasPricesEstimator: e}
+	}
+	return NewComposedGasPriceEstimator(GasPriceFirstSuccess, named, lggr)
 }
 
-func (gpe *ComposedGasPriceEstimator) GasPrices() map[string]sdk.DecCoin {
-	// Try each estimator in order
+func (gpe *ComposedGasPriceEstimator) GasPrices() (map[string]sdk.DecCoin, error) {
+	if gpe.strategy == GasPriceFirstSuccess {
+		var finalError error
+		for _, estimator := range gpe.estimators {
+			latestPrices, err := estimator.GasPrices()
+			if err != nil {
+				finalError = multierr.Append(finalError, errors.Wrap(err, estimator.Name))
+				gpe.lggr.Warnw("Error using gas price estimator, trying next one", "estimator", estimator.Name, "err", err)
+				continue
+			}
+			for denom := range latestPrices {
+				gpe.selected(estimator.Name, denom)
+			}
+			return latestPrices, nil
+		}
+		return nil, gpe.noneSucceeded(finalError)
+	}
+
+	type sourcedPrice struct {
+		estimator string
+		price     sdk.DecCoin
+	}
 	var finalError error
+	byDenom := make(map[string][]sourcedPrice)
 	for _, estimator := range gpe.estimators {
 		latestPrices, err := estimator.GasPrices()
 		if err != nil {
-			finalError = multierr.Combine(finalError, err)
-			gpe.lggr.Warnf("error using estimator, trying next one, err %v", err)
+			finalError = multierr.Append(finalError, errors.Wrap(err, estimator.Name))
+			gpe.lggr.Warnw("Error using gas price estimator, skipping", "estimator", estimator.Name, "err", err)
+			continue
+		}
+		for denom, price := range latestPrices {
+			byDenom[denom] = append(byDenom[denom], sourcedPrice{estimator.Name, price})
+		}
+	}
+	if len(byDenom) == 0 {
+		return nil, gpe.noneSucceeded(finalError)
+	}
+	results := make(map[string]sdk.DecCoin, len(byDenom))
+	for denom, ps := range byDenom {
+		sort.SliceStable(ps, func(i, j int) bool { return ps[i].price.Amount.LT(ps[j].price.Amount) })
+		var chosen sourcedPrice
+		switch gpe.strategy {
+		case GasPriceMedian:
+			chosen = ps[(len(ps)-1)/2]
+		case GasPriceMax:
+			chosen = ps[len(ps)-1]
+		default:
+			return nil, fmt.Errorf("unsupported gas price strategy %s", gpe.strategy)
+		}
+		gpe.selected(chosen.estimator, denom)
+		results[denom] = chosen.price
+	}
+	return results, nil
+}
+
+func (gpe *ComposedGasPriceEstimator) selected(estimator, denom string) {
+	promGasPriceSelected.WithLabelValues(estimator, denom, gpe.strategy.String()).Inc()
+}
+
+func (gpe *ComposedGasPriceEstimator) noneSucceeded(err error) error {
+	if err == nil {
+		return errors.New("no gas price estimators")
+	}
+	return errors.Wrap(err, "no gas price estimator succeeded")
+}
+
+var _ GasPricesEstimator = (*BoundedGasPriceEstimator)(nil)
+
+// BoundsConfig is a subset of pkg/terra.Config, which cannot be imported here.
+type BoundsConfig interface {
+	FallbackGasPriceULuna() sdk.Dec
+	MaxGasPriceULuna() sdk.Dec
+}
+
+// BoundedGasPriceEstimator is a GasPricesEstimator which bounds the uluna price from another estimator
+// to no less than FallbackGasPriceULuna and no more than MaxGasPriceULuna. The ceiling takes precedence.
+type BoundedGasPriceEstimator struct {
+	estimator GasPricesEstimator
+	cfg       BoundsConfig
+	lggr      logger.Logger
+}
+
+func NewBoundedGasPriceEstimator(estimator GasPricesEstimator, cfg BoundsConfig, lggr logger.Logger) *BoundedGasPriceEstimator {
+	return &BoundedGasPriceEstimator{estimator: estimator, cfg: cfg, lggr: lggr}
+}
+
+func (gpe *BoundedGasPriceEstimator) GasPrices() (map[string]sdk.DecCoin, error) {
+	latestPrices, err := gpe.estimator.GasPrices()
+	if err != nil {
+		return nil, err
+	}
+	price, ok := latestPrices["uluna"]
+	if !ok {
+		return latestPrices, nil
+	}
+	bounded := sdk.MinDec(sdk.MaxDec(price.Amount, gpe.cfg.FallbackGasPriceULuna()), gpe.cfg.MaxGasPriceULuna())
+	if bounded.Equal(price.Amount) {
+		return latestPrices, nil
+	}
+	gpe.lggr.Warnw("Gas price out of bounds", "price", price, "bounded", bounded,
+		"min", gpe.cfg.FallbackGasPriceULuna(), "max", gpe.cfg.MaxGasPriceULuna())
+	results := make(map[string]sdk.DecCoin, len(latestPrices))
+	for denom, p := range latestPrices {
+		results[denom] = p
+	}
+	results["uluna"] = sdk.NewDecCoinFromDec("uluna", bounded)
+	return results, nil
+}
+
+var _ GasPricesEstimator = (*BlockFeeHistoryGasPriceEstimator)(nil)
+
+// FeeHistoryConfig is a subset of pkg/terra.Config, which cannot be imported here.
+type FeeHistoryConfig interface {
+	FeeHistoryBlocks() int64
+	FeeHistoryPercentile() int64
+}
+
+// BlockReader reads blocks.
+type BlockReader interface {
+	LatestBlock(ctx context.Context) (*tmtypes.GetLatestBlockResponse, error)
+	BlockByHeight(ctx context.Context, height int64) (*tmtypes.GetBlockByHeightResponse, error)
+}
+
+// BlockFeeHistoryGasPriceEstimator is a GasPricesEstimator which estimates from the fees paid by txs onchain,
+// as the FeeHistoryPercentile gas price per denom over the last FeeHistoryBlocks blocks.
+// Blocks are cached, so each call only fetches blocks produced since the last.
+type BlockFeeHistoryGasPriceEstimator struct {
+	cfg            FeeHistoryConfig
+	r              BlockReader
+	requestTimeout time.Duration
+	lggr           logger.Logger
+
+	mu     sync.Mutex
+	blocks map[int64]map[string][]sdk.Dec // gas prices by denom, by height
+}
+
+func NewBlockFeeHistoryGasPriceEstimator(cfg FeeHistoryConfig, r BlockReader, requestTimeout time.Duration, lggr logger.Logger) *BlockFeeHistoryGasPriceEstimator {
+	return &BlockFeeHistoryGasPriceEstimator{
+		cfg:            cfg,
+		r:              r,
+		requestTimeout: requestTimeout,
+		lggr:           lggr,
+		blocks:         make(map[int64]map[string][]sdk.Dec),
+	}
+}
+
+func (gpe *BlockFeeHistoryGasPriceEstimator) GasPrices() (map[string]sdk.DecCoin, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gpe.requestTimeout)
+	defer cancel()
+	latest, err := gpe.r.LatestBlock(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get latest block")
+	}
+	n, p := gpe.cfg.FeeHistoryBlocks(), gpe.cfg.FeeHistoryPercentile()
+	if n < 1 {
+		return nil, fmt.Errorf("invalid FeeHistoryBlocks %d", n)
+	}
+	if p < 1 || p > 100 {
+		return nil, fmt.Errorf("invalid FeeHistoryPercentile %d", p)
+	}
+	height := latest.Block.Header.Height
+	oldest := height - n + 1
+	if oldest < 1 {
+		oldest = 1
+	}
+
+	gpe.mu.Lock()
+	defer gpe.mu.Unlock()
+	for h := range gpe.blocks {
+		if h < oldest || h > height {
+			delete(gpe.blocks, h)
+		}
+	}
+	gpe.blocks[height] = blockGasPrices(latest.Block.Data.Txs)
+	for h := oldest; h < height; h++ {
+		if _, ok := gpe.blocks[h]; ok {
+			continue
+		}
+		b, err := gpe.r.BlockByHeight(ctx, h)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get block %d", h)
+		}
+		gpe.blocks[h] = blockGasPrices(b.Block.Data.Txs)
+	}
+
+	byDenom := make(map[string][]sdk.Dec)
+	for _, prices := range gpe.blocks {
+		for denom, ps := range prices {
+			byDenom[denom] = append(byDenom[denom], ps...)
+		}
+	}
+	if len(byDenom) == 0 {
+		return nil, fmt.Errorf("no fees paid in blocks %d to %d", oldest, height)
+	}
+	results := make(map[string]sdk.DecCoin, len(byDenom))
+	for denom, ps := range byDenom {
+		results[denom] = sdk.NewDecCoinFromDec(denom, percentile(ps, p))
+	}
+	return results, nil
+}
+
+// blockGasPrices returns the gas prices paid by txs, by denom. Txs which cannot be decoded or have no gas limit are skipped.
+func blockGasPrices(txs [][]byte) map[string][]sdk.Dec {
+	prices := make(map[string][]sdk.Dec)
+	decode := encodingConfig.TxConfig.TxDecoder()
+	for _, txBytes := range txs {
+		decoded, err := decode(txBytes)
+		if err != nil {
+			continue
+		}
+		feeTx, ok := decoded.(sdk.FeeTx)
+		if !ok || feeTx.GetGas() == 0 {
 			continue
 		}
-		return latestPrices
+		gas := sdk.NewDecFromInt(sdk.NewIntFromUint64(feeTx.GetGas()))
+		for _, c := range feeTx.GetFee() {
+			prices[c.Denom] = append(prices[c.Denom], sdk.NewDecFromInt(c.Amount).Quo(gas))
+		}
+	}
+	return prices
+}
+
+// percentile returns the p-th percentile of ds, using the nearest rank. ds is sorted in place.
+func percentile(ds []sdk.Dec, p int64) sdk.Dec {
+	sort.Slice(ds, func(i, j int) bool { return ds[i].LT(ds[j]) })
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(ds))))
+	if rank < 1 {
+		rank = 1
+	}
+	return ds[rank-1]
+}
+
+const (
+	// guardWindow is the number of accepted prices per denom in the trailing average.
+	guardWindow = 10
+	// guardResetAfter is the number of consecutive rejected prices for a denom after which they are accepted
+	// as a new level, rather than pausing indefinitely.
+	guardResetAfter = 10
+)
+
+// GasPriceCap bounds the gas price of a denom. A nil Min or Max is unbounded.
+type GasPriceCap struct {
+	Min, Max sdk.Dec
+}
+
+// GuardConfig is a subset of pkg/terra.Config, which cannot be imported here.
+type GuardConfig interface {
+	GasPriceSpikeRatio() float64
+}
+
+// PausableGasPricesEstimator is a GasPricesEstimator which may pause broadcasting until prices normalize.
+type PausableGasPricesEstimator interface {
+	GasPricesEstimator
+	// Paused returns true if the last prices were abnormal, or could not be estimated, and only urgent txs should be
+	// broadcast.
+	Paused() bool
+}
+
+var _ PausableGasPricesEstimator = (*GuardedGasPriceEstimator)(nil)
+
+// GuardedGasPriceEstimator is a GasPricesEstimator which guards against a bad or manipulated price feed.
+// Prices are first capped per denom. A price more than GasPriceSpikeRatio times above or below the trailing
+// average of accepted prices is then rejected in favour of the last accepted price, and the estimator is
+// paused until prices normalize. It is also paused while prices cannot be estimated, since they cannot be checked.
+// A GasPriceSpikeRatio of 0 disables spike detection.
+type GuardedGasPriceEstimator struct {
+	estimator GasPricesEstimator
+	cfg       GuardConfig
+	caps      map[string]GasPriceCap
+	lggr      logger.Logger
+
+	mu         sync.Mutex
+	accepted   map[string][]sdk.Dec // last guardWindow accepted prices, by denom
+	rejections map[string]int       // consecutive rejected prices, by denom
+	paused     bool
+}
+
+func NewGuardedGasPriceEstimator(estimator GasPricesEstimator, cfg GuardConfig, caps map[string]GasPriceCap, lggr logger.Logger) *GuardedGasPriceEstimator {
+	return &GuardedGasPriceEstimator{
+		estimator:  estimator,
+		cfg:        cfg,
+		caps:       caps,
+		lggr:       lggr,
+		accepted:   make(map[string][]sdk.Dec),
+		rejections: make(map[string]int),
+	}
+}
+
+func (gpe *GuardedGasPriceEstimator) GasPrices() (map[string]sdk.DecCoin, error) {
+	latestPrices, err := gpe.estimator.GasPrices()
+	gpe.mu.Lock()
+	defer gpe.mu.Unlock()
+	if err != nil {
+		gpe.setPaused(true)
+		return nil, err
+	}
+	ratio := gpe.cfg.GasPriceSpikeRatio()
+
+	var paused bool
+	results := make(map[string]sdk.DecCoin, len(latestPrices))
+	for denom, price := range latestPrices {
+		amount := gpe.capped(denom, price.Amount)
+		accepted := gpe.accepted[denom]
+		if ratio > 0 && len(accepted) > 0 && isSpike(amount, average(accepted), ratio) {
+			gpe.rejections[denom]++
+			if gpe.rejections[denom] < guardResetAfter {
+				last := accepted[len(accepted)-1]
+				gpe.lggr.Warnw("Rejected gas price spike", "denom", denom, "price", amount, "average", average(accepted),
+					"ratio", ratio, "using", last, "rejections", gpe.rejections[denom])
+				paused = true
+				results[denom] = sdk.NewDecCoinFromDec(denom, last)
+				continue
+			}
+			gpe.lggr.Warnw("Accepting new gas price level", "denom", denom, "price", amount, "rejections", gpe.rejections[denom])
+			accepted = nil
+		}
+		gpe.rejections[denom] = 0
+		accepted = append(accepted, amount)
+		if len(accepted) > guardWindow {
+			accepted = accepted[len(accepted)-guardWindow:]
+		}
+		gpe.accepted[denom] = accepted
+		results[denom] = sdk.NewDecCoinFromDec(denom, amount)
+	}
+	gpe.setPaused(paused)
+	return results, nil
+}
+
+func (gpe *GuardedGasPriceEstimator) setPaused(paused bool) {
+	if paused != gpe.paused {
+		gpe.lggr.Warnw("Gas price guard state changed", "paused", paused)
+	}
+	gpe.paused = paused
+}
+
+func (gpe *GuardedGasPriceEstimator) Paused() bool {
+	gpe.mu.Lock()
+	defer gpe.mu.Unlock()
+	return gpe.paused
+}
+
+func (gpe *GuardedGasPriceEstimator) capped(denom string, price sdk.Dec) sdk.Dec {
+	c, ok := gpe.caps[denom]
+	if !ok {
+		return price
+	}
+	capped := price
+	if !c.Min.IsNil() && capped.LT(c.Min) {
+		capped = c.Min
+	}
+	if !c.Max.IsNil() && capped.GT(c.Max) {
+		capped = c.Max
+	}
+	if !capped.Equal(price) {
+		gpe.lggr.Warnw("Gas price capped", "denom", denom, "price", price, "capped", capped)
+	}
+	return capped
+}
+
+// isSpike returns true if price is more than ratio times above or below avg.
+func isSpike(price, avg sdk.Dec, ratio float64) bool {
+	if !avg.IsPositive() || !price.IsPositive() {
+		return !price.Equal(avg)
+	}
+	f, err := price.Quo(avg).Float64()
+	if err != nil {
+		return true
+	}
+	return f > ratio || f < 1/ratio
+}
+
+func average(ds []sdk.Dec) sdk.Dec {
+	sum := sdk.ZeroDec()
+	for _, d := range ds {
+		sum = sum.Add(d)
 	}
-	panic(fmt.Sprintf("no estimator succeeded errs %v", finalError))
+	return sum.QuoInt64(int64(len(ds)))
 }
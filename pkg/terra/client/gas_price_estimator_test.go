@@ -1,14 +1,24 @@
 package client
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
 	"go.uber.org/zap"
 
+	tmtypes "github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	wasmtypes "github.com/terra-money/core/x/wasm/types"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -64,6 +74,29 @@ func TestGasPriceEstimators(t *testing.T) {
 		}
 	})
 
+	t.Run("fcd parsing", func(t *testing.T) {
+		var body string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write([]byte(body))
+			require.NoError(t, err)
+		}))
+		t.Cleanup(srv.Close)
+		gpeFCD := NewFCDGasPriceEstimator(newConfig(t, srv.URL), time.Second, lggr)
+
+		// Unknown denoms are included, and malformed entries skipped individually.
+		body = `{"uluna": "0.015", "ibc/0471F1C4E7AFD3F07702BEF6DC365268D64570F7C1FDC98EA6098DD6DE59817B": "0.2", "uusd": "", "ukrw": 1.7, "u": "1", "ueur": "-1"}`
+		t.Cleanup(assertLogsLen(t, 5))
+		p, err := gpeFCD.GasPrices()
+		require.NoError(t, err)
+		assert.Len(t, p, 2)
+		assert.Equal(t, "0.015000000000000000", p["uluna"].Amount.String())
+		assert.Equal(t, "0.200000000000000000", p["ibc/0471F1C4E7AFD3F07702BEF6DC365268D64570F7C1FDC98EA6098DD6DE59817B"].Amount.String())
+
+		body = `{"uusd": ""}`
+		_, err = gpeFCD.GasPrices()
+		require.ErrorContains(t, err, "no valid gas prices")
+	})
+
 	t.Run("caching", func(t *testing.T) {
 		gpeFCD := NewFCDGasPriceEstimator(newConfig(t, "https://fcd.terra.dev:443/v1/txs/gas_prices"), 10*time.Second, lggr)
 		cachingFCD := NewCachingGasPriceEstimator(gpeFCD, lggr)
@@ -95,22 +128,77 @@ func TestGasPriceEstimators(t *testing.T) {
 		assert.Equal(t, "10.000000000000000000", price.Amount.String())
 	})
 
+	t.Run("fee history", func(t *testing.T) {
+		pubKey := secp256k1.GenPrivKey().PubKey()
+		newTx := func(gasLimit uint64, fees ...sdk.Coin) []byte {
+			b, err := buildWithPlaceholderSig(UnsignedTx{
+				Msgs:   []sdk.Msg{wasmtypes.NewMsgExecuteContract(sdk.AccAddress(pubKey.Address()), sdk.AccAddress("contract"), []byte(`{}`), nil)},
+				PubKey: pubKey,
+			}, gasLimit, sdk.NewCoins(fees...))
+			require.NoError(t, err)
+			return b
+		}
+		// Block h pays 0.01*h uluna, and 0.1 uusd from h=4.
+		blocks := make(map[int64]*tmproto.Block)
+		for h := int64(1); h <= 6; h++ {
+			txs := [][]byte{newTx(1000, sdk.NewInt64Coin("uluna", 10*h)), []byte("not a tx"), newTx(0)}
+			if h >= 4 {
+				txs = append(txs, newTx(1000, sdk.NewInt64Coin("uusd", 100)))
+			}
+			blocks[h] = &tmproto.Block{Header: tmproto.Header{Height: h}, Data: tmproto.Data{Txs: txs}}
+		}
+		br := &blockReader{blocks: blocks, latest: 5}
+		cfg := &feeHistoryConfig{blocks: 3, percentile: 50}
+		gpe := NewBlockFeeHistoryGasPriceEstimator(cfg, br, time.Second, lggr)
+
+		p, err := gpe.GasPrices()
+		require.NoError(t, err)
+		assert.Equal(t, "0.040000000000000000", p["uluna"].Amount.String())
+		assert.Equal(t, "0.100000000000000000", p["uusd"].Amount.String())
+		assert.Equal(t, []int64{3, 4}, br.fetched)
+
+		// Only new blocks are fetched.
+		br.latest = 6
+		cfg.percentile = 100
+		p, err = gpe.GasPrices()
+		require.NoError(t, err)
+		assert.Equal(t, "0.060000000000000000", p["uluna"].Amount.String())
+		assert.Equal(t, []int64{3, 4}, br.fetched)
+
+		cfg.percentile = 1
+		p, err = gpe.GasPrices()
+		require.NoError(t, err)
+		assert.Equal(t, "0.040000000000000000", p["uluna"].Amount.String())
+
+		// Falls back to the cache.
+		caching := NewCachingGasPriceEstimator(gpe, lggr)
+		_, err = caching.GasPrices()
+		require.NoError(t, err)
+		br.latest = 7
+		t.Cleanup(assertLogsLen(t, 1))
+		cached, err := caching.GasPrices()
+		require.NoError(t, err)
+		assert.Equal(t, p, cached)
+	})
+
 	t.Run("composed", func(t *testing.T) {
 		gpeFCD := NewFCDGasPriceEstimator(newConfig(t, "https://does.not.exist:443/v1/txs/gas_prices"), 10*time.Second, lggr)
 		cachingFCD := NewCachingGasPriceEstimator(gpeFCD, lggr)
 		gpeFixed := NewFixedGasPriceEstimator(map[string]sdk.DecCoin{
 			"uluna": sdk.NewDecCoinFromDec("uluna", sdk.MustNewDecFromStr("10")),
 		})
-		gpe := NewMustGasPriceEstimator([]GasPricesEstimator{cachingFCD, gpeFixed}, lggr)
+		gpe := NewComposedGasPriceEstimator(GasPriceFirstSuccess, []NamedGasPricesEstimator{{"fcd", cachingFCD}, {"fixed", gpeFixed}}, lggr)
 		t.Cleanup(assertLogsLen(t, 1))
-		fixedPrices := gpe.GasPrices()
+		fixedPrices, err := gpe.GasPrices()
+		require.NoError(t, err)
 		uluna, ok := fixedPrices["uluna"]
 		assert.True(t, ok)
 		assert.Equal(t, "10.000000000000000000", uluna.Amount.String())
 		// If the url starts working, it should use that.
 		const goodURL = "https://fcd.terra.dev:443/v1/txs/gas_prices"
 		gpeFCD.cfg = newConfig(t, goodURL)
-		fcdPrices := gpe.GasPrices()
+		fcdPrices, err := gpe.GasPrices()
+		require.NoError(t, err)
 		uluna, ok = fcdPrices["uluna"]
 		assert.True(t, ok)
 		assert.NotEqual(t, "10.000000000000000000", uluna.Amount.String())
@@ -130,3 +218,178 @@ func newConfig(t *testing.T, u string) *config {
 func (c *config) FCDURL() url.URL {
 	return c.fcdURL
 }
+
+type feeHistoryConfig struct {
+	blocks, percentile int64
+}
+
+func (c *feeHistoryConfig) FeeHistoryBlocks() int64 { return c.blocks }
+
+func (c *feeHistoryConfig) FeeHistoryPercentile() int64 { return c.percentile }
+
+type blockReader struct {
+	blocks  map[int64]*tmproto.Block
+	latest  int64
+	fetched []int64
+}
+
+func (br *blockReader) LatestBlock(context.Context) (*tmtypes.GetLatestBlockResponse, error) {
+	b, ok := br.blocks[br.latest]
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", br.latest)
+	}
+	return &tmtypes.GetLatestBlockResponse{Block: b}, nil
+}
+
+func (br *blockReader) BlockByHeight(_ context.Context, height int64) (*tmtypes.GetBlockByHeightResponse, error) {
+	br.fetched = append(br.fetched, height)
+	b, ok := br.blocks[height]
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", height)
+	}
+	return &tmtypes.GetBlockByHeightResponse{Block: b}, nil
+}
+
+func TestComposedGasPriceEstimator(t *testing.T) {
+	lggr := logger.Test(t)
+	fixed := func(prices ...string) GasPricesEstimator {
+		m := make(map[string]sdk.DecCoin)
+		for i := 0; i < len(prices); i += 2 {
+			m[prices[i]] = sdk.NewDecCoinFromDec(prices[i], sdk.MustNewDecFromStr(prices[i+1]))
+		}
+		return NewFixedGasPriceEstimator(m)
+	}
+	failing := NewClosureGasPriceEstimator(func() (map[string]sdk.DecCoin, error) {
+		return nil, errors.New("unavailable")
+	})
+	estimators := []NamedGasPricesEstimator{
+		{"failing", failing},
+		{"a", fixed("uluna", "0.02", "uusd", "0.3")},
+		{"b", fixed("uluna", "0.01")},
+		{"c", fixed("uluna", "0.03", "uusd", "0.1")},
+	}
+
+	for _, tt := range []struct {
+		strategy  GasPriceStrategy
+		uluna     string
+		uusd      string
+		ulunaFrom string
+	}{
+		{GasPriceFirstSuccess, "0.02", "0.3", "a"},
+		{GasPriceMedian, "0.02", "0.1", "a"},
+		{GasPriceMax, "0.03", "0.3", "c"},
+	} {
+		t.Run(tt.strategy.String(), func(t *testing.T) {
+			before := testutil.ToFloat64(promGasPriceSelected.WithLabelValues(tt.ulunaFrom, "uluna", tt.strategy.String()))
+			gpe := NewComposedGasPriceEstimator(tt.strategy, estimators, lggr)
+			p, err := gpe.GasPrices()
+			require.NoError(t, err)
+			assert.Equal(t, sdk.MustNewDecFromStr(tt.uluna), p["uluna"].Amount)
+			assert.Equal(t, sdk.MustNewDecFromStr(tt.uusd), p["uusd"].Amount)
+			after := testutil.ToFloat64(promGasPriceSelected.WithLabelValues(tt.ulunaFrom, "uluna", tt.strategy.String()))
+			assert.Equal(t, before+1, after)
+
+			// Errors instead of panicking.
+			_, err = NewComposedGasPriceEstimator(tt.strategy, []NamedGasPricesEstimator{{"failing", failing}}, lggr).GasPrices()
+			require.ErrorContains(t, err, "failing: unavailable")
+			_, err = NewComposedGasPriceEstimator(tt.strategy, nil, lggr).GasPrices()
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("must", func(t *testing.T) {
+		gpe := NewMustGasPriceEstimator([]GasPricesEstimator{failing, fixed("uluna", "0.02")}, lggr)
+		p, err := gpe.GasPrices()
+		require.NoError(t, err)
+		assert.Equal(t, sdk.MustNewDecFromStr("0.02"), p["uluna"].Amount)
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		cfg := &boundsConfig{min: sdk.MustNewDecFromStr("0.015"), max: sdk.MustNewDecFromStr("0.025")}
+		for _, tt := range []struct {
+			price, exp string
+		}{
+			{"0.01", "0.015"},
+			{"0.02", "0.02"},
+			{"0.03", "0.025"},
+		} {
+			gpe := NewBoundedGasPriceEstimator(fixed("uluna", tt.price, "uusd", "0.1"), cfg, lggr)
+			p, err := gpe.GasPrices()
+			require.NoError(t, err)
+			assert.Equal(t, sdk.MustNewDecFromStr(tt.exp), p["uluna"].Amount, tt.price)
+			assert.Equal(t, sdk.MustNewDecFromStr("0.1"), p["uusd"].Amount)
+		}
+		_, err := NewBoundedGasPriceEstimator(failing, cfg, lggr).GasPrices()
+		require.Error(t, err)
+	})
+}
+
+type boundsConfig struct {
+	min, max sdk.Dec
+}
+
+func (c *boundsConfig) FallbackGasPriceULuna() sdk.Dec { return c.min }
+
+func (c *boundsConfig) MaxGasPriceULuna() sdk.Dec { return c.max }
+
+func TestGuardedGasPriceEstimator(t *testing.T) {
+	lggr, logs := logger.TestObserved(t, zap.WarnLevel)
+	var uluna, uusd string
+	var fail error
+	gpe := NewGuardedGasPriceEstimator(NewClosureGasPriceEstimator(func() (map[string]sdk.DecCoin, error) {
+		if fail != nil {
+			return nil, fail
+		}
+		return map[string]sdk.DecCoin{
+			"uluna": sdk.NewDecCoinFromDec("uluna", sdk.MustNewDecFromStr(uluna)),
+			"uusd":  sdk.NewDecCoinFromDec("uusd", sdk.MustNewDecFromStr(uusd)),
+		}, nil
+	}), &guardConfig{ratio: 3}, map[string]GasPriceCap{
+		"uusd": {Min: sdk.MustNewDecFromStr("0.1"), Max: sdk.MustNewDecFromStr("0.25")},
+	}, lggr)
+	requirePrices := func(t *testing.T, expULuna, expUUSD string, paused bool) {
+		p, err := gpe.GasPrices()
+		require.NoError(t, err)
+		assert.Equal(t, sdk.MustNewDecFromStr(expULuna), p["uluna"].Amount)
+		assert.Equal(t, sdk.MustNewDecFromStr(expUUSD), p["uusd"].Amount)
+		assert.Equal(t, paused, gpe.Paused())
+	}
+
+	// Capped.
+	uluna, uusd = "0.01", "0.05"
+	requirePrices(t, "0.01", "0.1", false)
+	uluna, uusd = "0.02", "5"
+	requirePrices(t, "0.02", "0.25", false)
+	assert.Len(t, logs.TakeAll(), 2)
+
+	// Average 0.015, so 0.05 is a spike, and the last accepted price is used.
+	uluna, uusd = "0.05", "0.2"
+	requirePrices(t, "0.02", "0.2", true)
+	// Normalized.
+	uluna = "0.04"
+	requirePrices(t, "0.04", "0.2", false)
+	assert.Len(t, logs.TakeAll(), 3, "rejected, paused, resumed")
+
+	// A sustained new level is eventually accepted.
+	uluna = "1"
+	for i := 1; i < guardResetAfter; i++ {
+		requirePrices(t, "0.04", "0.2", true)
+	}
+	requirePrices(t, "1", "0.2", false)
+	uluna = "0.9"
+	requirePrices(t, "0.9", "0.2", false)
+
+	// Errors pass through, and pause until prices can be checked again.
+	fail = errors.New("unavailable")
+	_, err := gpe.GasPrices()
+	require.Error(t, err)
+	assert.True(t, gpe.Paused())
+	fail = nil
+	requirePrices(t, "0.9", "0.2", false)
+}
+
+type guardConfig struct {
+	ratio float64
+}
+
+func (c *guardConfig) GasPriceSpikeRatio() float64 { return c.ratio }
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	cosmosclient "github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	gogogrpc "github.com/gogo/protobuf/grpc"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/proto"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var protoCodec = encoding.GetCodec(proto.Name)
+
+var _ gogogrpc.ClientConn = (*tmGRPCConn)(nil)
+
+// tmGRPCConn is a gogogrpc.ClientConn which serves the generated grpc query clients over tendermint rpc.
+// It mirrors cosmosclient.Context.Invoke, except that the grpc context is passed through
+// to the tendermint rpc client, so that deadlines and cancellation apply to the underlying http requests.
+// cosmosclient.Context always uses context.Background() for those requests.
+type tmGRPCConn struct {
+	clientCtx cosmosclient.Context
+	tmClient  rpcclient.Client
+}
+
+// Invoke implements the grpc ClientConn.Invoke method
+func (c *tmGRPCConn) Invoke(ctx context.Context, method string, req, reply interface{}, opts ...grpc.CallOption) error {
+	if reflect.ValueOf(req).IsNil() {
+		return sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, "request cannot be nil")
+	}
+	if reqProto, ok := req.(*txtypes.BroadcastTxRequest); ok {
+		res, ok := reply.(*txtypes.BroadcastTxResponse)
+		if !ok {
+			return sdkerrors.Wrapf(sdkerrors.ErrInvalidRequest, "expected %T, got %T", (*txtypes.BroadcastTxResponse)(nil), req)
+		}
+		broadcastRes, err := c.broadcast(ctx, reqProto)
+		if err != nil {
+			return err
+		}
+		*res = *broadcastRes
+		return nil
+	}
+	return c.query(ctx, method, req, reply, opts...)
+}
+
+// NewStream implements the grpc ClientConn.NewStream method
+func (c *tmGRPCConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, fmt.Errorf("streaming rpc not supported")
+}
+
+func (c *tmGRPCConn) query(ctx context.Context, method string, req, reply interface{}, opts ...grpc.CallOption) error {
+	reqBz, err := protoCodec.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var height int64
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if heights := md.Get(grpctypes.GRPCBlockHeightHeader); len(heights) > 0 {
+		height, err = strconv.ParseInt(heights[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		if height < 0 {
+			return sdkerrors.Wrapf(sdkerrors.ErrInvalidRequest,
+				"height (%d) from %q must be >= 0", height, grpctypes.GRPCBlockHeightHeader)
+		}
+	}
+	result, err := c.tmClient.ABCIQueryWithOptions(ctx, method, reqBz, rpcclient.ABCIQueryOptions{Height: height})
+	if err != nil {
+		return err
+	}
+	if !result.Response.IsOK() {
+		return queryError(result.Response)
+	}
+	if err = protoCodec.Unmarshal(result.Response.Value, reply); err != nil {
+		return err
+	}
+	md = metadata.Pairs(grpctypes.GRPCBlockHeightHeader, strconv.FormatInt(result.Response.Height, 10))
+	for _, callOpt := range opts {
+		header, ok := callOpt.(grpc.HeaderCallOption)
+		if !ok {
+			continue
+		}
+		*header.HeaderAddr = md
+	}
+	return types.UnpackInterfaces(reply, c.clientCtx.InterfaceRegistry)
+}
+
+func (c *tmGRPCConn) broadcast(ctx context.Context, req *txtypes.BroadcastTxRequest) (*txtypes.BroadcastTxResponse, error) {
+	if req == nil || req.TxBytes == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid empty tx")
+	}
+	var resp *sdk.TxResponse
+	switch req.Mode {
+	case txtypes.BroadcastMode_BROADCAST_MODE_BLOCK:
+		res, err := c.tmClient.BroadcastTxCommit(ctx, req.TxBytes)
+		if err != nil {
+			if errRes := cosmosclient.CheckTendermintError(err, req.TxBytes); errRes != nil {
+				return &txtypes.BroadcastTxResponse{TxResponse: errRes}, nil
+			}
+			return nil, err
+		}
+		resp = sdk.NewResponseFormatBroadcastTxCommit(res)
+	case txtypes.BroadcastMode_BROADCAST_MODE_SYNC:
+		res, err := c.tmClient.BroadcastTxSync(ctx, req.TxBytes)
+		if errRes := cosmosclient.CheckTendermintError(err, req.TxBytes); errRes != nil {
+			return &txtypes.BroadcastTxResponse{TxResponse: errRes}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		resp = sdk.NewResponseFormatBroadcastTx(res)
+	case txtypes.BroadcastMode_BROADCAST_MODE_ASYNC:
+		res, err := c.tmClient.BroadcastTxAsync(ctx, req.TxBytes)
+		if errRes := cosmosclient.CheckTendermintError(err, req.TxBytes); errRes != nil {
+			return &txtypes.BroadcastTxResponse{TxResponse: errRes}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		resp = sdk.NewResponseFormatBroadcastTx(res)
+	default:
+		return nil, fmt.Errorf("unsupported broadcast mode %s", req.Mode)
+	}
+	return &txtypes.BroadcastTxResponse{TxResponse: resp}, nil
+}
@@ -1,15 +1,22 @@
-// Code generated by mockery v2.9.4. DO NOT EDIT.
+// Code generated by mockery v2.12.0. DO NOT EDIT.
 
 package mocks
 
 import (
-	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	context "context"
+
 	client "github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
 
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+
 	mock "github.com/stretchr/testify/mock"
 
 	query "github.com/cosmos/cosmos-sdk/types/query"
 
+	tenderminttypes "github.com/tendermint/tendermint/types"
+
+	testing "testing"
+
 	tmservice "github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
 
 	tx "github.com/cosmos/cosmos-sdk/types/tx"
@@ -22,27 +29,27 @@ type ReaderWriter struct {
 	mock.Mock
 }
 
-// Account provides a mock function with given fields: address
-func (_m *ReaderWriter) Account(address types.AccAddress) (uint64, uint64, error) {
-	ret := _m.Called(address)
+// Account provides a mock function with given fields: ctx, address
+func (_m *ReaderWriter) Account(ctx context.Context, address types.AccAddress) (uint64, uint64, error) {
+	ret := _m.Called(ctx, address)
 
 	var r0 uint64
-	if rf, ok := ret.Get(0).(func(types.AccAddress) uint64); ok {
-		r0 = rf(address)
+	if rf, ok := ret.Get(0).(func(context.Context, types.AccAddress) uint64); ok {
+		r0 = rf(ctx, address)
 	} else {
 		r0 = ret.Get(0).(uint64)
 	}
 
 	var r1 uint64
-	if rf, ok := ret.Get(1).(func(types.AccAddress) uint64); ok {
-		r1 = rf(address)
+	if rf, ok := ret.Get(1).(func(context.Context, types.AccAddress) uint64); ok {
+		r1 = rf(ctx, address)
 	} else {
 		r1 = ret.Get(1).(uint64)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(types.AccAddress) error); ok {
-		r2 = rf(address)
+	if rf, ok := ret.Get(2).(func(context.Context, types.AccAddress) error); ok {
+		r2 = rf(ctx, address)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -50,13 +57,13 @@ func (_m *ReaderWriter) Account(address types.AccAddress) (uint64, uint64, error
 	return r0, r1, r2
 }
 
-// Balance provides a mock function with given fields: addr, denom
-func (_m *ReaderWriter) Balance(addr types.AccAddress, denom string) (*types.Coin, error) {
-	ret := _m.Called(addr, denom)
+// Balance provides a mock function with given fields: ctx, addr, denom
+func (_m *ReaderWriter) Balance(ctx context.Context, addr types.AccAddress, denom string) (*types.Coin, error) {
+	ret := _m.Called(ctx, addr, denom)
 
 	var r0 *types.Coin
-	if rf, ok := ret.Get(0).(func(types.AccAddress, string) *types.Coin); ok {
-		r0 = rf(addr, denom)
+	if rf, ok := ret.Get(0).(func(context.Context, types.AccAddress, string) *types.Coin); ok {
+		r0 = rf(ctx, addr, denom)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*types.Coin)
@@ -64,8 +71,31 @@ func (_m *ReaderWriter) Balance(addr types.AccAddress, denom string) (*types.Coi
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(types.AccAddress, string) error); ok {
-		r1 = rf(addr, denom)
+	if rf, ok := ret.Get(1).(func(context.Context, types.AccAddress, string) error); ok {
+		r1 = rf(ctx, addr, denom)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BatchContractStore provides a mock function with given fields: ctx, queries
+func (_m *ReaderWriter) BatchContractStore(ctx context.Context, queries []client.ContractQuery) ([]client.ContractQueryResult, error) {
+	ret := _m.Called(ctx, queries)
+
+	var r0 []client.ContractQueryResult
+	if rf, ok := ret.Get(0).(func(context.Context, []client.ContractQuery) []client.ContractQueryResult); ok {
+		r0 = rf(ctx, queries)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]client.ContractQueryResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []client.ContractQuery) error); ok {
+		r1 = rf(ctx, queries)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -73,13 +103,13 @@ func (_m *ReaderWriter) Balance(addr types.AccAddress, denom string) (*types.Coi
 	return r0, r1
 }
 
-// BatchSimulateUnsigned provides a mock function with given fields: msgs, sequence
-func (_m *ReaderWriter) BatchSimulateUnsigned(msgs client.SimMsgs, sequence uint64) (*client.BatchSimResults, error) {
-	ret := _m.Called(msgs, sequence)
+// BatchSimulateUnsigned provides a mock function with given fields: ctx, msgs, sequence
+func (_m *ReaderWriter) BatchSimulateUnsigned(ctx context.Context, msgs client.SimMsgs, sequence uint64) (*client.BatchSimResults, error) {
+	ret := _m.Called(ctx, msgs, sequence)
 
 	var r0 *client.BatchSimResults
-	if rf, ok := ret.Get(0).(func(client.SimMsgs, uint64) *client.BatchSimResults); ok {
-		r0 = rf(msgs, sequence)
+	if rf, ok := ret.Get(0).(func(context.Context, client.SimMsgs, uint64) *client.BatchSimResults); ok {
+		r0 = rf(ctx, msgs, sequence)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*client.BatchSimResults)
@@ -87,8 +117,8 @@ func (_m *ReaderWriter) BatchSimulateUnsigned(msgs client.SimMsgs, sequence uint
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(client.SimMsgs, uint64) error); ok {
-		r1 = rf(msgs, sequence)
+	if rf, ok := ret.Get(1).(func(context.Context, client.SimMsgs, uint64) error); ok {
+		r1 = rf(ctx, msgs, sequence)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -96,13 +126,13 @@ func (_m *ReaderWriter) BatchSimulateUnsigned(msgs client.SimMsgs, sequence uint
 	return r0, r1
 }
 
-// BlockByHeight provides a mock function with given fields: height
-func (_m *ReaderWriter) BlockByHeight(height int64) (*tmservice.GetBlockByHeightResponse, error) {
-	ret := _m.Called(height)
+// BlockByHeight provides a mock function with given fields: ctx, height
+func (_m *ReaderWriter) BlockByHeight(ctx context.Context, height int64) (*tmservice.GetBlockByHeightResponse, error) {
+	ret := _m.Called(ctx, height)
 
 	var r0 *tmservice.GetBlockByHeightResponse
-	if rf, ok := ret.Get(0).(func(int64) *tmservice.GetBlockByHeightResponse); ok {
-		r0 = rf(height)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *tmservice.GetBlockByHeightResponse); ok {
+		r0 = rf(ctx, height)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*tmservice.GetBlockByHeightResponse)
@@ -110,8 +140,8 @@ func (_m *ReaderWriter) BlockByHeight(height int64) (*tmservice.GetBlockByHeight
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(height)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, height)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -119,13 +149,13 @@ func (_m *ReaderWriter) BlockByHeight(height int64) (*tmservice.GetBlockByHeight
 	return r0, r1
 }
 
-// Broadcast provides a mock function with given fields: txBytes, mode
-func (_m *ReaderWriter) Broadcast(txBytes []byte, mode tx.BroadcastMode) (*tx.BroadcastTxResponse, error) {
-	ret := _m.Called(txBytes, mode)
+// Broadcast provides a mock function with given fields: ctx, txBytes, mode
+func (_m *ReaderWriter) Broadcast(ctx context.Context, txBytes []byte, mode tx.BroadcastMode) (*tx.BroadcastTxResponse, error) {
+	ret := _m.Called(ctx, txBytes, mode)
 
 	var r0 *tx.BroadcastTxResponse
-	if rf, ok := ret.Get(0).(func([]byte, tx.BroadcastMode) *tx.BroadcastTxResponse); ok {
-		r0 = rf(txBytes, mode)
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, tx.BroadcastMode) *tx.BroadcastTxResponse); ok {
+		r0 = rf(ctx, txBytes, mode)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*tx.BroadcastTxResponse)
@@ -133,8 +163,54 @@ func (_m *ReaderWriter) Broadcast(txBytes []byte, mode tx.BroadcastMode) (*tx.Br
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]byte, tx.BroadcastMode) error); ok {
-		r1 = rf(txBytes, mode)
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, tx.BroadcastMode) error); ok {
+		r1 = rf(ctx, txBytes, mode)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ContractRawStoreWithProof provides a mock function with given fields: ctx, contractAddress, key, height
+func (_m *ReaderWriter) ContractRawStoreWithProof(ctx context.Context, contractAddress types.AccAddress, key []byte, height int64) (*client.RawStoreProof, error) {
+	ret := _m.Called(ctx, contractAddress, key, height)
+
+	var r0 *client.RawStoreProof
+	if rf, ok := ret.Get(0).(func(context.Context, types.AccAddress, []byte, int64) *client.RawStoreProof); ok {
+		r0 = rf(ctx, contractAddress, key, height)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.RawStoreProof)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.AccAddress, []byte, int64) error); ok {
+		r1 = rf(ctx, contractAddress, key, height)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ContractStore provides a mock function with given fields: ctx, contractAddress, queryMsg
+func (_m *ReaderWriter) ContractStore(ctx context.Context, contractAddress types.AccAddress, queryMsg []byte) ([]byte, error) {
+	ret := _m.Called(ctx, contractAddress, queryMsg)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, types.AccAddress, []byte) []byte); ok {
+		r0 = rf(ctx, contractAddress, queryMsg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.AccAddress, []byte) error); ok {
+		r1 = rf(ctx, contractAddress, queryMsg)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -142,13 +218,13 @@ func (_m *ReaderWriter) Broadcast(txBytes []byte, mode tx.BroadcastMode) (*tx.Br
 	return r0, r1
 }
 
-// ContractStore provides a mock function with given fields: contractAddress, queryMsg
-func (_m *ReaderWriter) ContractStore(contractAddress types.AccAddress, queryMsg []byte) ([]byte, error) {
-	ret := _m.Called(contractAddress, queryMsg)
+// ContractStoreAtHeight provides a mock function with given fields: ctx, contractAddress, queryMsg, height
+func (_m *ReaderWriter) ContractStoreAtHeight(ctx context.Context, contractAddress types.AccAddress, queryMsg []byte, height int64) ([]byte, error) {
+	ret := _m.Called(ctx, contractAddress, queryMsg, height)
 
 	var r0 []byte
-	if rf, ok := ret.Get(0).(func(types.AccAddress, []byte) []byte); ok {
-		r0 = rf(contractAddress, queryMsg)
+	if rf, ok := ret.Get(0).(func(context.Context, types.AccAddress, []byte, int64) []byte); ok {
+		r0 = rf(ctx, contractAddress, queryMsg, height)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]byte)
@@ -156,8 +232,8 @@ func (_m *ReaderWriter) ContractStore(contractAddress types.AccAddress, queryMsg
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(types.AccAddress, []byte) error); ok {
-		r1 = rf(contractAddress, queryMsg)
+	if rf, ok := ret.Get(1).(func(context.Context, types.AccAddress, []byte, int64) error); ok {
+		r1 = rf(ctx, contractAddress, queryMsg, height)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -165,13 +241,13 @@ func (_m *ReaderWriter) ContractStore(contractAddress types.AccAddress, queryMsg
 	return r0, r1
 }
 
-// CreateAndSign provides a mock function with given fields: msgs, account, sequence, gasLimit, gasLimitMultiplier, gasPrice, signer, timeoutHeight
-func (_m *ReaderWriter) CreateAndSign(msgs []types.Msg, account uint64, sequence uint64, gasLimit uint64, gasLimitMultiplier float64, gasPrice types.DecCoin, signer cryptotypes.PrivKey, timeoutHeight uint64) ([]byte, error) {
-	ret := _m.Called(msgs, account, sequence, gasLimit, gasLimitMultiplier, gasPrice, signer, timeoutHeight)
+// CreateAndSign provides a mock function with given fields: ctx, msgs, account, sequence, gasLimit, gasLimitMultiplier, gasPrice, signer, feeGranter, timeoutHeight
+func (_m *ReaderWriter) CreateAndSign(ctx context.Context, msgs []types.Msg, account uint64, sequence uint64, gasLimit uint64, gasLimitMultiplier float64, gasPrice types.DecCoin, signer client.Signer, feeGranter types.AccAddress, timeoutHeight uint64) ([]byte, error) {
+	ret := _m.Called(ctx, msgs, account, sequence, gasLimit, gasLimitMultiplier, gasPrice, signer, feeGranter, timeoutHeight)
 
 	var r0 []byte
-	if rf, ok := ret.Get(0).(func([]types.Msg, uint64, uint64, uint64, float64, types.DecCoin, cryptotypes.PrivKey, uint64) []byte); ok {
-		r0 = rf(msgs, account, sequence, gasLimit, gasLimitMultiplier, gasPrice, signer, timeoutHeight)
+	if rf, ok := ret.Get(0).(func(context.Context, []types.Msg, uint64, uint64, uint64, float64, types.DecCoin, client.Signer, types.AccAddress, uint64) []byte); ok {
+		r0 = rf(ctx, msgs, account, sequence, gasLimit, gasLimitMultiplier, gasPrice, signer, feeGranter, timeoutHeight)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]byte)
@@ -179,8 +255,8 @@ func (_m *ReaderWriter) CreateAndSign(msgs []types.Msg, account uint64, sequence
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]types.Msg, uint64, uint64, uint64, float64, types.DecCoin, cryptotypes.PrivKey, uint64) error); ok {
-		r1 = rf(msgs, account, sequence, gasLimit, gasLimitMultiplier, gasPrice, signer, timeoutHeight)
+	if rf, ok := ret.Get(1).(func(context.Context, []types.Msg, uint64, uint64, uint64, float64, types.DecCoin, client.Signer, types.AccAddress, uint64) error); ok {
+		r1 = rf(ctx, msgs, account, sequence, gasLimit, gasLimitMultiplier, gasPrice, signer, feeGranter, timeoutHeight)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -188,13 +264,13 @@ func (_m *ReaderWriter) CreateAndSign(msgs []types.Msg, account uint64, sequence
 	return r0, r1
 }
 
-// LatestBlock provides a mock function with given fields:
-func (_m *ReaderWriter) LatestBlock() (*tmservice.GetLatestBlockResponse, error) {
-	ret := _m.Called()
+// LatestBlock provides a mock function with given fields: ctx
+func (_m *ReaderWriter) LatestBlock(ctx context.Context) (*tmservice.GetLatestBlockResponse, error) {
+	ret := _m.Called(ctx)
 
 	var r0 *tmservice.GetLatestBlockResponse
-	if rf, ok := ret.Get(0).(func() *tmservice.GetLatestBlockResponse); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) *tmservice.GetLatestBlockResponse); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*tmservice.GetLatestBlockResponse)
@@ -202,8 +278,31 @@ func (_m *ReaderWriter) LatestBlock() (*tmservice.GetLatestBlockResponse, error)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LightBlock provides a mock function with given fields: ctx, height
+func (_m *ReaderWriter) LightBlock(ctx context.Context, height int64) (*tenderminttypes.LightBlock, error) {
+	ret := _m.Called(ctx, height)
+
+	var r0 *tenderminttypes.LightBlock
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *tenderminttypes.LightBlock); ok {
+		r0 = rf(ctx, height)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*tenderminttypes.LightBlock)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, height)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -211,13 +310,13 @@ func (_m *ReaderWriter) LatestBlock() (*tmservice.GetLatestBlockResponse, error)
 	return r0, r1
 }
 
-// SignAndBroadcast provides a mock function with given fields: msgs, accountNum, sequence, gasPrice, signer, mode
-func (_m *ReaderWriter) SignAndBroadcast(msgs []types.Msg, accountNum uint64, sequence uint64, gasPrice types.DecCoin, signer cryptotypes.PrivKey, mode tx.BroadcastMode) (*tx.BroadcastTxResponse, error) {
-	ret := _m.Called(msgs, accountNum, sequence, gasPrice, signer, mode)
+// SignAndBroadcast provides a mock function with given fields: ctx, msgs, accountNum, sequence, gasPrice, signer, feeGranter, mode
+func (_m *ReaderWriter) SignAndBroadcast(ctx context.Context, msgs []types.Msg, accountNum uint64, sequence uint64, gasPrice types.DecCoin, signer client.Signer, feeGranter types.AccAddress, mode tx.BroadcastMode) (*tx.BroadcastTxResponse, error) {
+	ret := _m.Called(ctx, msgs, accountNum, sequence, gasPrice, signer, feeGranter, mode)
 
 	var r0 *tx.BroadcastTxResponse
-	if rf, ok := ret.Get(0).(func([]types.Msg, uint64, uint64, types.DecCoin, cryptotypes.PrivKey, tx.BroadcastMode) *tx.BroadcastTxResponse); ok {
-		r0 = rf(msgs, accountNum, sequence, gasPrice, signer, mode)
+	if rf, ok := ret.Get(0).(func(context.Context, []types.Msg, uint64, uint64, types.DecCoin, client.Signer, types.AccAddress, tx.BroadcastMode) *tx.BroadcastTxResponse); ok {
+		r0 = rf(ctx, msgs, accountNum, sequence, gasPrice, signer, feeGranter, mode)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*tx.BroadcastTxResponse)
@@ -225,8 +324,8 @@ func (_m *ReaderWriter) SignAndBroadcast(msgs []types.Msg, accountNum uint64, se
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]types.Msg, uint64, uint64, types.DecCoin, cryptotypes.PrivKey, tx.BroadcastMode) error); ok {
-		r1 = rf(msgs, accountNum, sequence, gasPrice, signer, mode)
+	if rf, ok := ret.Get(1).(func(context.Context, []types.Msg, uint64, uint64, types.DecCoin, client.Signer, types.AccAddress, tx.BroadcastMode) error); ok {
+		r1 = rf(ctx, msgs, accountNum, sequence, gasPrice, signer, feeGranter, mode)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -234,13 +333,13 @@ func (_m *ReaderWriter) SignAndBroadcast(msgs []types.Msg, accountNum uint64, se
 	return r0, r1
 }
 
-// Simulate provides a mock function with given fields: txBytes
-func (_m *ReaderWriter) Simulate(txBytes []byte) (*tx.SimulateResponse, error) {
-	ret := _m.Called(txBytes)
+// Simulate provides a mock function with given fields: ctx, txBytes
+func (_m *ReaderWriter) Simulate(ctx context.Context, txBytes []byte) (*tx.SimulateResponse, error) {
+	ret := _m.Called(ctx, txBytes)
 
 	var r0 *tx.SimulateResponse
-	if rf, ok := ret.Get(0).(func([]byte) *tx.SimulateResponse); ok {
-		r0 = rf(txBytes)
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) *tx.SimulateResponse); ok {
+		r0 = rf(ctx, txBytes)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*tx.SimulateResponse)
@@ -248,8 +347,8 @@ func (_m *ReaderWriter) Simulate(txBytes []byte) (*tx.SimulateResponse, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]byte) error); ok {
-		r1 = rf(txBytes)
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, txBytes)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -257,13 +356,13 @@ func (_m *ReaderWriter) Simulate(txBytes []byte) (*tx.SimulateResponse, error) {
 	return r0, r1
 }
 
-// SimulateUnsigned provides a mock function with given fields: msgs, sequence
-func (_m *ReaderWriter) SimulateUnsigned(msgs []types.Msg, sequence uint64) (*tx.SimulateResponse, error) {
-	ret := _m.Called(msgs, sequence)
+// SimulateUnsigned provides a mock function with given fields: ctx, msgs, sequence
+func (_m *ReaderWriter) SimulateUnsigned(ctx context.Context, msgs []types.Msg, sequence uint64) (*tx.SimulateResponse, error) {
+	ret := _m.Called(ctx, msgs, sequence)
 
 	var r0 *tx.SimulateResponse
-	if rf, ok := ret.Get(0).(func([]types.Msg, uint64) *tx.SimulateResponse); ok {
-		r0 = rf(msgs, sequence)
+	if rf, ok := ret.Get(0).(func(context.Context, []types.Msg, uint64) *tx.SimulateResponse); ok {
+		r0 = rf(ctx, msgs, sequence)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*tx.SimulateResponse)
@@ -271,8 +370,38 @@ func (_m *ReaderWriter) SimulateUnsigned(msgs []types.Msg, sequence uint64) (*tx
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]types.Msg, uint64) error); ok {
-		r1 = rf(msgs, sequence)
+	if rf, ok := ret.Get(1).(func(context.Context, []types.Msg, uint64) error); ok {
+		r1 = rf(ctx, msgs, sequence)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubscribeEvents provides a mock function with given fields: ctx, queries
+func (_m *ReaderWriter) SubscribeEvents(ctx context.Context, queries ...string) (<-chan coretypes.ResultEvent, error) {
+	_va := make([]interface{}, len(queries))
+	for _i := range queries {
+		_va[_i] = queries[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 <-chan coretypes.ResultEvent
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) <-chan coretypes.ResultEvent); ok {
+		r0 = rf(ctx, queries...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan coretypes.ResultEvent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, ...string) error); ok {
+		r1 = rf(ctx, queries...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -280,13 +409,13 @@ func (_m *ReaderWriter) SimulateUnsigned(msgs []types.Msg, sequence uint64) (*tx
 	return r0, r1
 }
 
-// Tx provides a mock function with given fields: hash
-func (_m *ReaderWriter) Tx(hash string) (*tx.GetTxResponse, error) {
-	ret := _m.Called(hash)
+// Tx provides a mock function with given fields: ctx, hash
+func (_m *ReaderWriter) Tx(ctx context.Context, hash string) (*tx.GetTxResponse, error) {
+	ret := _m.Called(ctx, hash)
 
 	var r0 *tx.GetTxResponse
-	if rf, ok := ret.Get(0).(func(string) *tx.GetTxResponse); ok {
-		r0 = rf(hash)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *tx.GetTxResponse); ok {
+		r0 = rf(ctx, hash)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*tx.GetTxResponse)
@@ -294,8 +423,8 @@ func (_m *ReaderWriter) Tx(hash string) (*tx.GetTxResponse, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(hash)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -303,13 +432,13 @@ func (_m *ReaderWriter) Tx(hash string) (*tx.GetTxResponse, error) {
 	return r0, r1
 }
 
-// TxsEvents provides a mock function with given fields: events, paginationParams
-func (_m *ReaderWriter) TxsEvents(events []string, paginationParams *query.PageRequest) (*tx.GetTxsEventResponse, error) {
-	ret := _m.Called(events, paginationParams)
+// TxsEvents provides a mock function with given fields: ctx, events, paginationParams
+func (_m *ReaderWriter) TxsEvents(ctx context.Context, events []string, paginationParams *query.PageRequest) (*tx.GetTxsEventResponse, error) {
+	ret := _m.Called(ctx, events, paginationParams)
 
 	var r0 *tx.GetTxsEventResponse
-	if rf, ok := ret.Get(0).(func([]string, *query.PageRequest) *tx.GetTxsEventResponse); ok {
-		r0 = rf(events, paginationParams)
+	if rf, ok := ret.Get(0).(func(context.Context, []string, *query.PageRequest) *tx.GetTxsEventResponse); ok {
+		r0 = rf(ctx, events, paginationParams)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*tx.GetTxsEventResponse)
@@ -317,11 +446,21 @@ func (_m *ReaderWriter) TxsEvents(events []string, paginationParams *query.PageR
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]string, *query.PageRequest) error); ok {
-		r1 = rf(events, paginationParams)
+	if rf, ok := ret.Get(1).(func(context.Context, []string, *query.PageRequest) error); ok {
+		r1 = rf(ctx, events, paginationParams)
 	} else {
 		r1 = ret.Error(1)
 	}
 
 	return r0, r1
 }
+
+// NewReaderWriter creates a new instance of ReaderWriter. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
+func NewReaderWriter(t testing.TB) *ReaderWriter {
+	mock := &ReaderWriter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,421 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc/status"
+
+	tmtypes "github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/smartcontractkit/chainlink-relay/pkg/utils"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmcore "github.com/tendermint/tendermint/types"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+const (
+	// MultiNodeHealthCheckPeriod is how often each node's latest block is polled.
+	MultiNodeHealthCheckPeriod = 6 * time.Second
+	// MultiNodeMaxBlockLag is the number of blocks a node may trail the highest known
+	// block before it is considered behind, and only used when no other node is available.
+	MultiNodeMaxBlockLag = 5
+	// multiNodeEWMAWeight is the weight given to the newest sample of latency and errors.
+	multiNodeEWMAWeight = 0.2
+	// multiNodeErrorPenalty is added to a node's latency score in proportion to its error rate.
+	// A node which always errors is scored as if it were this much slower.
+	multiNodeErrorPenalty = 5 * time.Second
+)
+
+var _ ReaderWriter = (*MultiNodeClient)(nil)
+
+// MultiNodeClient is a ReaderWriter which routes each request to the healthiest of
+// several nodes, and fails over to the next best node if the request fails.
+// Node health is tracked from request latency, the rate of failed requests and how far
+// a node's latest block lags the highest block seen across all nodes.
+//
+// Only failures to reach a node count as node errors and cause a fail over.
+// Errors returned by the node itself (e.g. a failed contract query or simulation)
+// are returned to the caller directly, since another node would give the same answer.
+type MultiNodeClient struct {
+	utils.StartStopOnce
+	nodes []*multiNode
+	lggr  logger.Logger
+
+	stop, done chan struct{}
+}
+
+type multiNode struct {
+	name string
+	rw   ReaderWriter
+
+	mu      sync.RWMutex
+	latency time.Duration // EWMA
+	errRate float64       // EWMA, 0 to 1
+	height  int64
+}
+
+// multiNodeStats is a snapshot of a multiNode's health.
+type multiNodeStats struct {
+	node    *multiNode
+	latency time.Duration
+	errRate float64
+	height  int64
+}
+
+// NewMultiNodeClient creates a MultiNodeClient with a Client for each of nodes.
+func NewMultiNodeClient(chainID string, nodes []db.Node, requestTimeout time.Duration, lggr logger.Logger) (*MultiNodeClient, error) {
+	if len(nodes) == 0 {
+		return nil, errors.Errorf("no nodes available for chain %s", chainID)
+	}
+	mns := make([]*multiNode, len(nodes))
+	for i, n := range nodes {
+		c, err := NewClient(chainID, n.TendermintURL, requestTimeout, logger.With(lggr, "node", n.Name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create client for node %s", n.Name)
+		}
+		mns[i] = &multiNode{name: n.Name, rw: c}
+	}
+	return newMultiNodeClient(mns, lggr), nil
+}
+
+func newMultiNodeClient(nodes []*multiNode, lggr logger.Logger) *MultiNodeClient {
+	return &MultiNodeClient{
+		nodes: nodes,
+		lggr:  lggr,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Start begins polling the nodes for their latest blocks.
+func (c *MultiNodeClient) Start(context.Context) error {
+	return c.StartOnce("MultiNodeClient", func() error {
+		go c.pollHeights()
+		return nil
+	})
+}
+
+func (c *MultiNodeClient) Close() error {
+	return c.StopOnce("MultiNodeClient", func() error {
+		close(c.stop)
+		<-c.done
+		return nil
+	})
+}
+
+func (c *MultiNodeClient) pollHeights() {
+	defer close(c.done)
+	tick := time.After(0)
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-tick:
+			ctx, cancel := utils.ContextFromChan(c.stop)
+			var wg sync.WaitGroup
+			wg.Add(len(c.nodes))
+			for _, n := range c.nodes {
+				go func(n *multiNode) {
+					defer wg.Done()
+					start := time.Now()
+					b, err := n.rw.LatestBlock(ctx)
+					if ctx.Err() != nil {
+						return
+					}
+					n.record(time.Since(start), err)
+					if err != nil {
+						c.lggr.Warnf("failed to get latest block from node %s: %v", n.name, err)
+						return
+					}
+					n.setHeight(b.Block.Header.Height)
+				}(n)
+			}
+			wg.Wait()
+			cancel()
+			tick = time.After(utils.WithJitter(MultiNodeHealthCheckPeriod))
+		}
+	}
+}
+
+func (n *multiNode) record(latency time.Duration, err error) {
+	var errSample float64
+	if err != nil {
+		errSample = 1
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.latency == 0 {
+		n.latency = latency
+	} else {
+		n.latency = time.Duration(multiNodeEWMAWeight*float64(latency) + (1-multiNodeEWMAWeight)*float64(n.latency))
+	}
+	n.errRate = multiNodeEWMAWeight*errSample + (1-multiNodeEWMAWeight)*n.errRate
+}
+
+func (n *multiNode) setHeight(height int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if height > n.height {
+		n.height = height
+	}
+}
+
+func (n *multiNode) stats() multiNodeStats {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return multiNodeStats{node: n, latency: n.latency, errRate: n.errRate, height: n.height}
+}
+
+func (s multiNodeStats) score() float64 {
+	return float64(s.latency) + float64(multiNodeErrorPenalty)*s.errRate
+}
+
+// bestNodes returns all nodes ordered from most to least preferred.
+// Nodes which are behind are always ordered after those which are not.
+func (c *MultiNodeClient) bestNodes() []*multiNode {
+	stats := make([]multiNodeStats, len(c.nodes))
+	var maxHeight int64
+	for i, n := range c.nodes {
+		stats[i] = n.stats()
+		if stats[i].height > maxHeight {
+			maxHeight = stats[i].height
+		}
+	}
+	behind := func(s multiNodeStats) bool {
+		return maxHeight-s.height > MultiNodeMaxBlockLag
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if bi, bj := behind(stats[i]), behind(stats[j]); bi != bj {
+			return bj
+		}
+		return stats[i].score() < stats[j].score()
+	})
+	nodes := make([]*multiNode, len(stats))
+	for i := range stats {
+		nodes[i] = stats[i].node
+	}
+	return nodes
+}
+
+// isNodeError returns true if err indicates that the node could not be reached or failed to respond,
+// as opposed to an error response from the node.
+func isNodeError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var respErr nodeResponseError
+	if errors.As(err, &respErr) {
+		return false
+	}
+	_, isStatus := status.FromError(err)
+	return !isStatus
+}
+
+// do calls fn with each node in order of preference, until one succeeds or returns an error response.
+func (c *MultiNodeClient) do(ctx context.Context, fn func(rw ReaderWriter) error) error {
+	return c.doSkipping(ctx, fn, func(error) bool { return false })
+}
+
+// doSkipping is like do, but also tries the next node after an error response for which skip returns true.
+// Such responses do not count against the node.
+func (c *MultiNodeClient) doSkipping(ctx context.Context, fn func(rw ReaderWriter) error, skip func(error) bool) error {
+	var errs error
+	for _, n := range c.bestNodes() {
+		start := time.Now()
+		err := fn(n.rw)
+		if !isNodeError(err) && err != nil && skip(unwrapNodeResponseError(err)) {
+			n.record(time.Since(start), nil)
+			c.lggr.Debugf("request to node %s failed, trying next node: %v", n.name, err)
+			errs = multierr.Append(errs, errors.Wrapf(unwrapNodeResponseError(err), "node %s", n.name))
+			continue
+		}
+		if !isNodeError(err) {
+			n.record(time.Since(start), nil)
+			return unwrapNodeResponseError(err)
+		}
+		if ctx.Err() != nil {
+			// not the node's fault
+			return multierr.Append(errs, err)
+		}
+		n.record(time.Since(start), err)
+		c.lggr.Warnf("request to node %s failed, trying next node: %v", n.name, err)
+		errs = multierr.Append(errs, errors.Wrapf(err, "node %s", n.name))
+	}
+	return errs
+}
+
+func (c *MultiNodeClient) Account(ctx context.Context, address sdk.AccAddress) (accountNum uint64, sequence uint64, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		accountNum, sequence, err = rw.Account(ctx, address)
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) ContractStore(ctx context.Context, contractAddress sdk.AccAddress, queryMsg []byte) (resp []byte, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.ContractStore(ctx, contractAddress, queryMsg)
+		return
+	})
+	return
+}
+
+// ContractStoreAtHeight tries each node until one has the state at height, since nodes may prune differently.
+func (c *MultiNodeClient) ContractStoreAtHeight(ctx context.Context, contractAddress sdk.AccAddress, queryMsg []byte, height int64) (resp []byte, err error) {
+	err = c.doSkipping(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.ContractStoreAtHeight(ctx, contractAddress, queryMsg, height)
+		return
+	}, func(err error) bool {
+		var unavailable *ErrHeightUnavailable
+		return errors.As(err, &unavailable)
+	})
+	return
+}
+
+func (c *MultiNodeClient) BatchContractStore(ctx context.Context, queries []ContractQuery) (resp []ContractQueryResult, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.BatchContractStore(ctx, queries)
+		return
+	})
+	return
+}
+
+// ContractRawStoreWithProof tries each node until one has the state at height, like ContractStoreAtHeight.
+func (c *MultiNodeClient) ContractRawStoreWithProof(ctx context.Context, contractAddress sdk.AccAddress, key []byte, height int64) (resp *RawStoreProof, err error) {
+	err = c.doSkipping(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.ContractRawStoreWithProof(ctx, contractAddress, key, height)
+		return
+	}, func(err error) bool {
+		var unavailable *ErrHeightUnavailable
+		return errors.As(err, &unavailable)
+	})
+	return
+}
+
+func (c *MultiNodeClient) LightBlock(ctx context.Context, height int64) (resp *tmcore.LightBlock, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.LightBlock(ctx, height)
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) TxsEvents(ctx context.Context, events []string, paginationParams *query.PageRequest) (resp *txtypes.GetTxsEventResponse, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.TxsEvents(ctx, events, paginationParams)
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) Tx(ctx context.Context, hash string) (resp *txtypes.GetTxResponse, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.Tx(ctx, hash)
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) LatestBlock(ctx context.Context) (resp *tmtypes.GetLatestBlockResponse, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.LatestBlock(ctx)
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) BlockByHeight(ctx context.Context, height int64) (resp *tmtypes.GetBlockByHeightResponse, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.BlockByHeight(ctx, height)
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) Balance(ctx context.Context, addr sdk.AccAddress, denom string) (resp *sdk.Coin, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.Balance(ctx, addr, denom)
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) SubscribeEvents(ctx context.Context, queries ...string) (resp <-chan ctypes.ResultEvent, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.SubscribeEvents(ctx, queries...)
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) SignAndBroadcast(ctx context.Context, msgs []sdk.Msg, accountNum uint64, sequence uint64, gasPrice sdk.DecCoin, signer Signer, feeGranter sdk.AccAddress, mode txtypes.BroadcastMode) (resp *txtypes.BroadcastTxResponse, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.SignAndBroadcast(ctx, msgs, accountNum, sequence, gasPrice, signer, feeGranter, mode)
+		if err != nil && resp != nil {
+			// the node responded, so don't fail over
+			return nodeResponseError{err}
+		}
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) Broadcast(ctx context.Context, txBytes []byte, mode txtypes.BroadcastMode) (resp *txtypes.BroadcastTxResponse, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.Broadcast(ctx, txBytes, mode)
+		if err != nil && resp != nil {
+			// the node responded, so don't fail over
+			return nodeResponseError{err}
+		}
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) Simulate(ctx context.Context, txBytes []byte) (resp *txtypes.SimulateResponse, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.Simulate(ctx, txBytes)
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) BatchSimulateUnsigned(ctx context.Context, msgs SimMsgs, sequence uint64) (resp *BatchSimResults, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.BatchSimulateUnsigned(ctx, msgs, sequence)
+		return
+	})
+	return
+}
+
+func (c *MultiNodeClient) SimulateUnsigned(ctx context.Context, msgs []sdk.Msg, sequence uint64) (resp *txtypes.SimulateResponse, err error) {
+	err = c.do(ctx, func(rw ReaderWriter) (err error) {
+		resp, err = rw.SimulateUnsigned(ctx, msgs, sequence)
+		return
+	})
+	return
+}
+
+// CreateAndSign does not make any requests, so it always uses the first node.
+func (c *MultiNodeClient) CreateAndSign(ctx context.Context, msgs []sdk.Msg, account uint64, sequence uint64, gasLimit uint64, gasLimitMultiplier float64, gasPrice sdk.DecCoin, signer Signer, feeGranter sdk.AccAddress, timeoutHeight uint64) ([]byte, error) {
+	return c.nodes[0].rw.CreateAndSign(ctx, msgs, account, sequence, gasLimit, gasLimitMultiplier, gasPrice, signer, feeGranter, timeoutHeight)
+}
+
+// nodeResponseError wraps an error response from a node, which should not cause a fail over.
+type nodeResponseError struct{ error }
+
+func unwrapNodeResponseError(err error) error {
+	if e, ok := err.(nodeResponseError); ok {
+		return e.error
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tmtypes "github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+)
+
+// stubNode is a ReaderWriter which only supports LatestBlock, ContractStore and ContractStoreAtHeight.
+type stubNode struct {
+	ReaderWriter
+	height   int64
+	storeErr error
+	calls    int
+}
+
+func (s *stubNode) LatestBlock(context.Context) (*tmtypes.GetLatestBlockResponse, error) {
+	return &tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: s.height}}}, nil
+}
+
+func (s *stubNode) ContractStore(context.Context, sdk.AccAddress, []byte) ([]byte, error) {
+	s.calls++
+	if s.storeErr != nil {
+		return nil, s.storeErr
+	}
+	return []byte(`{}`), nil
+}
+
+func (s *stubNode) ContractStoreAtHeight(ctx context.Context, addr sdk.AccAddress, query []byte, _ int64) ([]byte, error) {
+	return s.ContractStore(ctx, addr, query)
+}
+
+func TestMultiNodeClient(t *testing.T) {
+	ctx := context.Background()
+	newClient := func(nodes ...*stubNode) *MultiNodeClient {
+		var mns []*multiNode
+		for i, n := range nodes {
+			mns = append(mns, &multiNode{name: string(rune('a' + i)), rw: n})
+		}
+		return newMultiNodeClient(mns, logger.Test(t))
+	}
+
+	t.Run("fail over on node error", func(t *testing.T) {
+		a := &stubNode{storeErr: errors.New("connection refused")}
+		b := &stubNode{}
+		c := newClient(a, b)
+		_, err := c.ContractStore(ctx, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, a.calls)
+		assert.Equal(t, 1, b.calls)
+		// a is now penalized, so b is tried first
+		_, err = c.ContractStore(ctx, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, a.calls)
+		assert.Equal(t, 2, b.calls)
+	})
+
+	t.Run("no fail over on error response", func(t *testing.T) {
+		a := &stubNode{storeErr: status.Error(codes.Unknown, "contract query failed")}
+		b := &stubNode{}
+		c := newClient(a, b)
+		_, err := c.ContractStore(ctx, nil, nil)
+		require.Error(t, err)
+		assert.Equal(t, 1, a.calls)
+		assert.Equal(t, 0, b.calls)
+	})
+
+	t.Run("fail over on pruned height", func(t *testing.T) {
+		pruned := newABCIError("sdk", 18, "failed to load state at height 5; version does not exist (latest height: 100)")
+		a := &stubNode{storeErr: pruned}
+		b := &stubNode{}
+		c := newClient(a, b)
+		_, err := c.ContractStoreAtHeight(ctx, nil, nil, 5)
+		require.NoError(t, err)
+		assert.Equal(t, 1, a.calls)
+		assert.Equal(t, 1, b.calls)
+		assert.Zero(t, c.nodes[0].stats().errRate, "not penalized")
+
+		b.storeErr = pruned
+		_, err = c.ContractStoreAtHeight(ctx, nil, nil, 5)
+		var unavailable *ErrHeightUnavailable
+		require.ErrorAs(t, err, &unavailable)
+		assert.Equal(t, int64(5), unavailable.Height)
+		assert.Equal(t, int64(100), unavailable.Latest)
+	})
+
+	t.Run("all nodes fail", func(t *testing.T) {
+		a := &stubNode{storeErr: errors.New("connection refused")}
+		b := &stubNode{storeErr: errors.New("timeout")}
+		c := newClient(a, b)
+		_, err := c.ContractStore(ctx, nil, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "connection refused")
+		assert.Contains(t, err.Error(), "timeout")
+	})
+
+	t.Run("avoid nodes which are behind", func(t *testing.T) {
+		a := &stubNode{height: 100}
+		b := &stubNode{height: 100 + MultiNodeMaxBlockLag + 1}
+		c := newClient(a, b)
+		require.NoError(t, c.Start(ctx))
+		t.Cleanup(func() { assert.NoError(t, c.Close()) })
+		require.Eventually(t, func() bool {
+			return c.bestNodes()[0] == c.nodes[1]
+		}, time.Second, 10*time.Millisecond)
+		_, err := c.ContractStore(ctx, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, a.calls)
+		assert.Equal(t, 1, b.calls)
+	})
+}
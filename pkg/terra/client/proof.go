@@ -0,0 +1,196 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/store/rootmulti"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/pkg/errors"
+	"github.com/tendermint/tendermint/crypto/merkle"
+	tmmath "github.com/tendermint/tendermint/libs/math"
+	tmcrypto "github.com/tendermint/tendermint/proto/tendermint/crypto"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	tmcore "github.com/tendermint/tendermint/types"
+	wasmtypes "github.com/terra-money/core/x/wasm/types"
+)
+
+// wasmRawStorePath is the ABCI query path for raw keys of the wasm module's store, which are served with merkle proofs.
+const wasmRawStorePath = "/store/" + wasmtypes.StoreKey + "/key"
+
+// RawStoreProof is a value from a WASM contract's raw state, along with a merkle proof of it against the
+// app hash of the header at Height+1.
+type RawStoreProof struct {
+	Key    []byte // key in the wasm module's store, including the contract prefix
+	Value  []byte // nil if the key is absent
+	Proof  *tmcrypto.ProofOps
+	Height int64
+}
+
+// ContractRawStoreWithProof reads the value stored under key by a WASM contract as of height, which must be positive,
+// along with a merkle proof. The value is not verified, see ProofVerifier.
+func (c *Client) ContractRawStoreWithProof(ctx context.Context, contractAddress sdk.AccAddress, key []byte, height int64) (*RawStoreProof, error) {
+	if height <= 0 {
+		return nil, fmt.Errorf("invalid height %d", height)
+	}
+	storeKey := append(wasmtypes.GetContractStoreKey(contractAddress), key...)
+	result, err := c.tmClient.ABCIQueryWithOptions(ctx, wasmRawStorePath, storeKey, rpcclient.ABCIQueryOptions{Height: height, Prove: true})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Response.IsOK() {
+		return nil, queryError(result.Response)
+	}
+	if result.Response.ProofOps == nil {
+		return nil, fmt.Errorf("no proof returned for height %d", height)
+	}
+	var value []byte
+	if len(result.Response.Value) > 0 {
+		value = result.Response.Value
+	}
+	return &RawStoreProof{
+		Key:    storeKey,
+		Value:  value,
+		Proof:  result.Response.ProofOps,
+		Height: result.Response.Height,
+	}, nil
+}
+
+// LightBlock returns the signed header and validator set at height, or the latest if height is 0.
+// The header is not verified, see ProofVerifier.
+func (c *Client) LightBlock(ctx context.Context, height int64) (*tmcore.LightBlock, error) {
+	return c.lightProvider.LightBlock(ctx, height)
+}
+
+// ProofTrustLevel is the fraction of the trusted voting power which must sign a header for it to be trusted.
+// Matches the tendermint light client default.
+var ProofTrustLevel = tmmath.Fraction{Numerator: 1, Denominator: 3}
+
+// ProofVerifier reads WASM contract state which is verified, like a light client, instead of trusting the node:
+// values are proven against the app hash of a header which is signed by both its own validators and by more than
+// ProofTrustLevel of a trusted validator set. It fails closed, so anything which cannot be verified is an error,
+// including once the validator set has changed too much since the trusted block.
+type ProofVerifier struct {
+	reader       Reader
+	chainID      string
+	trusted      *tmcore.ValidatorSet
+	proofRuntime *merkle.ProofRuntime
+
+	mu     sync.Mutex
+	header *tmcore.Header // last verified
+}
+
+// NewProofVerifier returns a ProofVerifier which trusts the validators of the block at trustedHeight, whose hash must
+// be trustedHash, as obtained from a trusted source like a block explorer or a node under our control.
+func NewProofVerifier(ctx context.Context, reader Reader, chainID string, trustedHeight int64, trustedHash []byte) (*ProofVerifier, error) {
+	if trustedHeight <= 0 {
+		return nil, fmt.Errorf("invalid trusted height %d", trustedHeight)
+	}
+	lb, err := reader.LightBlock(ctx, trustedHeight)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch trusted block %d", trustedHeight)
+	}
+	if err = lb.ValidateBasic(chainID); err != nil {
+		return nil, errors.Wrapf(err, "invalid trusted block %d", trustedHeight)
+	}
+	if hash := lb.Hash(); !bytes.Equal(hash, trustedHash) {
+		return nil, fmt.Errorf("trusted block %d has hash %X, expected %X", trustedHeight, hash, trustedHash)
+	}
+	return &ProofVerifier{
+		reader:       reader,
+		chainID:      chainID,
+		trusted:      lb.ValidatorSet,
+		proofRuntime: rootmulti.DefaultProofRuntime(),
+	}, nil
+}
+
+// ChainID returns the chain which headers must be from.
+func (v *ProofVerifier) ChainID() string { return v.chainID }
+
+// ContractRawStore reads the value stored under key by a WASM contract as of height, or as of the latest
+// verifiable height if height is 0, which is returned along with the value. The value is nil if the key is proven
+// to be absent.
+func (v *ProofVerifier) ContractRawStore(ctx context.Context, contractAddress sdk.AccAddress, key []byte, height int64) ([]byte, int64, error) {
+	if height < 0 {
+		return nil, 0, fmt.Errorf("invalid height %d", height)
+	}
+	var header *tmcore.Header
+	if height > 0 {
+		header = v.cachedHeader(height + 1)
+	}
+	if header == nil {
+		var headerHeight int64 // latest
+		if height > 0 {
+			headerHeight = height + 1
+		}
+		lb, err := v.reader.LightBlock(ctx, headerHeight)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed to fetch header")
+		}
+		if err = v.VerifyLightBlock(lb); err != nil {
+			return nil, 0, err
+		}
+		header = lb.Header
+		v.mu.Lock()
+		v.header = header
+		v.mu.Unlock()
+	}
+	// The app hash of a header commits to the state after the previous block.
+	height = header.Height - 1
+	p, err := v.reader.ContractRawStoreWithProof(ctx, contractAddress, key, height)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err = v.VerifyRawStore(p, header); err != nil {
+		return nil, 0, err
+	}
+	return p.Value, height, nil
+}
+
+func (v *ProofVerifier) cachedHeader(height int64) *tmcore.Header {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.header != nil && v.header.Height == height {
+		return v.header
+	}
+	return nil
+}
+
+// VerifyLightBlock returns an error unless lb is a header from our chain, committed by its validators, and signed by
+// enough of the trusted validators.
+func (v *ProofVerifier) VerifyLightBlock(lb *tmcore.LightBlock) error {
+	if lb == nil || lb.SignedHeader == nil || lb.ValidatorSet == nil {
+		return errors.New("incomplete light block")
+	}
+	if err := lb.ValidateBasic(v.chainID); err != nil {
+		return errors.Wrapf(err, "invalid light block %d", lb.Height)
+	}
+	if err := v.trusted.VerifyCommitLightTrusting(v.chainID, lb.Commit, ProofTrustLevel); err != nil {
+		return errors.Wrapf(err, "header %d not signed by trusted validators", lb.Height)
+	}
+	if err := lb.ValidatorSet.VerifyCommitLight(v.chainID, lb.Commit.BlockID, lb.Height, lb.Commit); err != nil {
+		return errors.Wrapf(err, "header %d not committed", lb.Height)
+	}
+	return nil
+}
+
+// VerifyRawStore returns an error unless p proves its value (or absence) against the app hash of header,
+// which must already be verified.
+func (v *ProofVerifier) VerifyRawStore(p *RawStoreProof, header *tmcore.Header) error {
+	if p.Height+1 != header.Height {
+		return fmt.Errorf("proof for height %d cannot be verified by header %d", p.Height, header.Height)
+	}
+	keyPath := merkle.KeyPath{}.
+		AppendKey([]byte(wasmtypes.StoreKey), merkle.KeyEncodingURL).
+		AppendKey(p.Key, merkle.KeyEncodingHex).
+		String()
+	var err error
+	if p.Value == nil {
+		err = v.proofRuntime.VerifyAbsence(p.Proof, header.AppHash, keyPath)
+	} else {
+		err = v.proofRuntime.VerifyValue(p.Proof, header.AppHash, keyPath, p.Value)
+	}
+	return errors.Wrapf(err, "invalid proof for height %d", p.Height)
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/store/rootmulti"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
+	tmcore "github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+	dbm "github.com/tendermint/tm-db"
+	wasmtypes "github.com/terra-money/core/x/wasm/types"
+)
+
+const proofChainID = "proof-test"
+
+// proofNode is a Reader which serves light blocks and proofs from an in-memory store.
+type proofNode struct {
+	ReaderWriter
+	store  *rootmulti.Store
+	blocks map[int64]*tmcore.LightBlock
+	latest int64
+	tamper func(*RawStoreProof)
+}
+
+func (n *proofNode) LightBlock(_ context.Context, height int64) (*tmcore.LightBlock, error) {
+	if height == 0 {
+		height = n.latest
+	}
+	return n.blocks[height], nil
+}
+
+func (n *proofNode) ContractRawStoreWithProof(_ context.Context, addr sdk.AccAddress, key []byte, height int64) (*RawStoreProof, error) {
+	storeKey := append(wasmtypes.GetContractStoreKey(addr), key...)
+	resp := n.store.Query(abci.RequestQuery{Path: "/" + wasmtypes.StoreKey + "/key", Data: storeKey, Height: height, Prove: true})
+	if !resp.IsOK() {
+		return nil, queryError(resp)
+	}
+	p := &RawStoreProof{Key: storeKey, Value: resp.Value, Proof: resp.ProofOps, Height: resp.Height}
+	if len(p.Value) == 0 {
+		p.Value = nil
+	}
+	if n.tamper != nil {
+		n.tamper(p)
+	}
+	return p, nil
+}
+
+func newLightBlock(t *testing.T, height int64, appHash []byte, vals *tmcore.ValidatorSet, privs []tmcore.PrivValidator) *tmcore.LightBlock {
+	header := &tmcore.Header{
+		Version:            tmversion.Consensus{Block: version.BlockProtocol},
+		ChainID:            proofChainID,
+		Height:             height,
+		Time:               time.Now(),
+		AppHash:            appHash,
+		ValidatorsHash:     vals.Hash(),
+		NextValidatorsHash: vals.Hash(),
+		ProposerAddress:    vals.Proposer.Address,
+	}
+	blockID := tmcore.BlockID{Hash: header.Hash(), PartSetHeader: tmcore.PartSetHeader{Total: 1, Hash: tmrand.Bytes(32)}}
+	voteSet := tmcore.NewVoteSet(proofChainID, height, 0, tmproto.PrecommitType, vals)
+	commit, err := tmcore.MakeCommit(blockID, height, 0, voteSet, privs, time.Now())
+	require.NoError(t, err)
+	return &tmcore.LightBlock{SignedHeader: &tmcore.SignedHeader{Header: header, Commit: commit}, ValidatorSet: vals}
+}
+
+func TestProofVerifier(t *testing.T) {
+	ctx := context.Background()
+	contract := sdk.AccAddress(tmrand.Bytes(20))
+	key, value := []byte("config"), []byte(`{"epoch":1}`)
+
+	storeKey := sdk.NewKVStoreKey(wasmtypes.StoreKey)
+	store := rootmulti.NewStore(dbm.NewMemDB())
+	store.MountStoreWithDB(storeKey, sdk.StoreTypeIAVL, nil)
+	require.NoError(t, store.LoadLatestVersion())
+	store.GetKVStore(storeKey).Set(append(wasmtypes.GetContractStoreKey(contract), key...), value)
+	commitID := store.Commit()
+	require.Equal(t, int64(1), commitID.Version)
+
+	vals, privs := tmcore.RandValidatorSet(4, 10)
+	trusted := newLightBlock(t, 1, nil, vals, privs)
+	newNode := func() *proofNode {
+		return &proofNode{store: store, latest: 2, blocks: map[int64]*tmcore.LightBlock{
+			1: trusted,
+			2: newLightBlock(t, 2, commitID.Hash, vals, privs),
+		}}
+	}
+
+	t.Run("verified", func(t *testing.T) {
+		v, err := NewProofVerifier(ctx, newNode(), proofChainID, 1, trusted.Hash())
+		require.NoError(t, err)
+		got, height, err := v.ContractRawStore(ctx, contract, key, 0)
+		require.NoError(t, err)
+		assert.Equal(t, value, got)
+		assert.Equal(t, int64(1), height)
+
+		got, _, err = v.ContractRawStore(ctx, contract, key, height)
+		require.NoError(t, err)
+		assert.Equal(t, value, got)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		v, err := NewProofVerifier(ctx, newNode(), proofChainID, 1, trusted.Hash())
+		require.NoError(t, err)
+		got, _, err := v.ContractRawStore(ctx, contract, []byte("missing"), 0)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("untrusted block hash", func(t *testing.T) {
+		_, err := NewProofVerifier(ctx, newNode(), proofChainID, 1, tmrand.Bytes(32))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "trusted block 1 has hash")
+	})
+
+	t.Run("tampered value", func(t *testing.T) {
+		node := newNode()
+		v, err := NewProofVerifier(ctx, node, proofChainID, 1, trusted.Hash())
+		require.NoError(t, err)
+		node.tamper = func(p *RawStoreProof) { p.Value = []byte(`{"epoch":2}`) }
+		_, _, err = v.ContractRawStore(ctx, contract, key, 0)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid proof")
+	})
+
+	t.Run("tampered absence", func(t *testing.T) {
+		node := newNode()
+		v, err := NewProofVerifier(ctx, node, proofChainID, 1, trusted.Hash())
+		require.NoError(t, err)
+		node.tamper = func(p *RawStoreProof) { p.Value = nil }
+		_, _, err = v.ContractRawStore(ctx, contract, key, 0)
+		require.Error(t, err)
+	})
+
+	t.Run("untrusted validators", func(t *testing.T) {
+		node := newNode()
+		v, err := NewProofVerifier(ctx, node, proofChainID, 1, trusted.Hash())
+		require.NoError(t, err)
+		otherVals, otherPrivs := tmcore.RandValidatorSet(4, 10)
+		node.blocks[2] = newLightBlock(t, 2, commitID.Hash, otherVals, otherPrivs)
+		_, _, err = v.ContractRawStore(ctx, contract, key, 0)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not signed by trusted validators")
+	})
+
+	t.Run("wrong app hash", func(t *testing.T) {
+		node := newNode()
+		v, err := NewProofVerifier(ctx, node, proofChainID, 1, trusted.Hash())
+		require.NoError(t, err)
+		node.blocks[2] = newLightBlock(t, 2, tmrand.Bytes(32), vals, privs)
+		_, _, err = v.ContractRawStore(ctx, contract, key, 0)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid proof")
+	})
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/pkg/errors"
+)
+
+// AccountReader reads the account number and sequence number of an account.
+type AccountReader interface {
+	Account(ctx context.Context, address sdk.AccAddress) (uint64, uint64, error)
+}
+
+// SequenceManager tracks the next sequence number of each account locally, so that multiple txs
+// from the same account may be in flight at once, including within a single block.
+// The chain is only read on first use, and after a failure leaves a gap in the reserved sequences.
+// It is safe for concurrent use.
+type SequenceManager struct {
+	ar AccountReader
+
+	mu       sync.Mutex
+	accounts map[string]*account
+}
+
+type account struct {
+	mu     sync.Mutex // held while syncing from chain
+	synced bool
+	number uint64
+	next   uint64
+}
+
+// NewSequenceManager returns a SequenceManager which syncs accounts from ar.
+func NewSequenceManager(ar AccountReader) *SequenceManager {
+	return &SequenceManager{ar: ar, accounts: make(map[string]*account)}
+}
+
+func (sm *SequenceManager) account(address sdk.AccAddress) *account {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	a, ok := sm.accounts[address.String()]
+	if !ok {
+		a = &account{}
+		sm.accounts[address.String()] = a
+	}
+	return a
+}
+
+// Next reserves the next sequence number for address, and returns it along with the account number.
+// The caller must report back via Failed if no tx with the sequence number is accepted into the mempool.
+func (sm *SequenceManager) Next(ctx context.Context, address sdk.AccAddress) (accountNum uint64, sequence uint64, err error) {
+	a := sm.account(address)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.synced {
+		a.number, a.next, err = sm.ar.Account(ctx, address)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "failed to sync sequence for %s", address)
+		}
+		a.synced = true
+	}
+	sequence = a.next
+	a.next++
+	return a.number, sequence, nil
+}
+
+// Failed releases sequence, which was reserved for address by Next, after err prevented a tx using it from
+// being accepted into the mempool. On a sequence mismatch, the expected sequence number is adopted.
+// Otherwise the sequence number is reused if it was the last one reserved, or else the account
+// is resynced from chain on next use, since later sequence numbers are no longer valid.
+func (sm *SequenceManager) Failed(address sdk.AccAddress, sequence uint64, err error) {
+	a := sm.account(address)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.synced {
+		return
+	}
+	var seqErr *ErrSequenceMismatch
+	switch {
+	case errors.As(err, &seqErr) && seqErr.Expected > 0:
+		a.next = seqErr.Expected
+	case a.next == sequence+1:
+		a.next = sequence
+	case a.next > sequence+1:
+		a.synced = false
+	}
+}
+
+// Reset forgets the sequence number for address, so that it is resynced from chain on next use.
+// For example, after a tx times out without being included.
+func (sm *SequenceManager) Reset(address sdk.AccAddress) {
+	a := sm.account(address)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.synced = false
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type accountReader struct {
+	sequence uint64
+	reads    int64
+}
+
+func (a *accountReader) Account(context.Context, sdk.AccAddress) (uint64, uint64, error) {
+	atomic.AddInt64(&a.reads, 1)
+	return 3, atomic.LoadUint64(&a.sequence), nil
+}
+
+func TestSequenceManager(t *testing.T) {
+	ctx := context.Background()
+	addr := sdk.AccAddress("addr1_______________")
+	ar := &accountReader{sequence: 7}
+	sm := NewSequenceManager(ar)
+	next := func(expSeq uint64) {
+		t.Helper()
+		num, seq, err := sm.Next(ctx, addr)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), num)
+		assert.Equal(t, expSeq, seq)
+	}
+
+	next(7)
+	next(8)
+	next(9)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&ar.reads))
+
+	// the last sequence is reused
+	sm.Failed(addr, 9, errors.New("broadcast failed"))
+	next(9)
+
+	// a gap forces a resync
+	sm.Failed(addr, 8, errors.New("broadcast failed"))
+	atomic.StoreUint64(&ar.sequence, 9)
+	next(9)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&ar.reads))
+
+	// mismatches adopt the expected sequence
+	sm.Failed(addr, 9, &ErrSequenceMismatch{ABCIError: &ABCIError{}, Expected: 12, Got: 9})
+	next(12)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&ar.reads))
+
+	sm.Reset(addr)
+	atomic.StoreUint64(&ar.sequence, 13)
+	next(13)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&ar.reads))
+
+	// concurrent reservations are unique
+	other := sdk.AccAddress("addr2_______________")
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, seq, err := sm.Next(ctx, other)
+			assert.NoError(t, err)
+			mu.Lock()
+			defer mu.Unlock()
+			assert.False(t, seen[seq], "duplicate sequence %d", seq)
+			seen[seq] = true
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, 50)
+	assert.Equal(t, int64(4), atomic.LoadInt64(&ar.reads))
+}
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/terra.go/key"
+)
+
+// Signer signs txs on behalf of an account, without necessarily exposing its private key.
+type Signer interface {
+	// Address returns the address of the account.
+	Address() sdk.AccAddress
+	// PubKey returns the public key of the account.
+	PubKey() cryptotypes.PubKey
+	// Sign returns the signature of signBytes, as from cryptotypes.PrivKey.Sign.
+	Sign(ctx context.Context, signBytes []byte) ([]byte, error)
+}
+
+var (
+	_ Signer = (*PrivKeySigner)(nil)
+	_ Signer = (*KeyringSigner)(nil)
+	_ Signer = (*RemoteSigner)(nil)
+)
+
+// PrivKeySigner is a Signer with an in-memory private key.
+type PrivKeySigner struct {
+	key key.PrivKey
+}
+
+// NewPrivKeySigner returns a Signer for privKey.
+func NewPrivKeySigner(privKey key.PrivKey) *PrivKeySigner {
+	return &PrivKeySigner{key: privKey}
+}
+
+func (s *PrivKeySigner) Address() sdk.AccAddress {
+	return sdk.AccAddress(s.key.PubKey().Address())
+}
+
+func (s *PrivKeySigner) PubKey() cryptotypes.PubKey {
+	return s.key.PubKey()
+}
+
+func (s *PrivKeySigner) Sign(_ context.Context, signBytes []byte) ([]byte, error) {
+	return s.key.Sign(signBytes)
+}
+
+// KeyringSigner is a Signer for a key in a cosmos keyring, e.g. an encrypted file keystore
+// from keyring.New with keyring.BackendFile.
+type KeyringSigner struct {
+	kr     keyring.Keyring
+	uid    string
+	pubKey cryptotypes.PubKey
+}
+
+// NewKeyringSigner returns a Signer for the key named uid in kr.
+func NewKeyringSigner(kr keyring.Keyring, uid string) (*KeyringSigner, error) {
+	info, err := kr.Key(uid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get key %s", uid)
+	}
+	return &KeyringSigner{kr: kr, uid: uid, pubKey: info.GetPubKey()}, nil
+}
+
+func (s *KeyringSigner) Address() sdk.AccAddress {
+	return sdk.AccAddress(s.pubKey.Address())
+}
+
+func (s *KeyringSigner) PubKey() cryptotypes.PubKey {
+	return s.pubKey
+}
+
+func (s *KeyringSigner) Sign(_ context.Context, signBytes []byte) ([]byte, error) {
+	sig, _, err := s.kr.Sign(s.uid, signBytes)
+	return sig, err
+}
+
+// RemoteSigner is a Signer backed by an external signing service, which holds secp256k1 keys and speaks
+// a simple JSON over HTTP protocol:
+//
+//	GET  <url>/pubkey?address=<bech32>                             -> {"pub_key": "<base64 compressed pub key>"}
+//	POST <url>/sign {"address": "<bech32>", "sign_bytes": "<base64>"} -> {"signature": "<base64>"}
+//
+// Signatures must be as from secp256k1.PrivKey.Sign, i.e. 64 byte R||S over the sha256 of the sign bytes.
+// Errors are indicated by a non-200 status, with the message in the body.
+type RemoteSigner struct {
+	url    url.URL
+	client *http.Client
+	pubKey *secp256k1.PubKey
+}
+
+type remotePubKeyResponse struct {
+	PubKey []byte `json:"pub_key"`
+}
+
+type remoteSignRequest struct {
+	Address   string `json:"address"`
+	SignBytes []byte `json:"sign_bytes"`
+}
+
+type remoteSignResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+// NewRemoteSigner returns a Signer for address, via the signing service at u.
+// The public key is fetched once, and must match address.
+func NewRemoteSigner(ctx context.Context, u url.URL, address sdk.AccAddress, client *http.Client) (*RemoteSigner, error) {
+	s := &RemoteSigner{url: u, client: client}
+	pubURL := s.endpoint("pubkey")
+	pubURL.RawQuery = url.Values{"address": []string{address.String()}}.Encode()
+	var resp remotePubKeyResponse
+	if err := s.do(ctx, http.MethodGet, pubURL, nil, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to get public key")
+	}
+	if len(resp.PubKey) != secp256k1.PubKeySize {
+		return nil, fmt.Errorf("invalid public key length %d", len(resp.PubKey))
+	}
+	s.pubKey = &secp256k1.PubKey{Key: resp.PubKey}
+	if got := sdk.AccAddress(s.pubKey.Address()); !got.Equals(address) {
+		return nil, fmt.Errorf("public key is for %s, not %s", got, address)
+	}
+	return s, nil
+}
+
+func (s *RemoteSigner) Address() sdk.AccAddress {
+	return sdk.AccAddress(s.pubKey.Address())
+}
+
+func (s *RemoteSigner) PubKey() cryptotypes.PubKey {
+	return s.pubKey
+}
+
+// Sign requests a signature from the signing service, and verifies it before returning.
+func (s *RemoteSigner) Sign(ctx context.Context, signBytes []byte) ([]byte, error) {
+	body, err := json.Marshal(remoteSignRequest{Address: s.Address().String(), SignBytes: signBytes})
+	if err != nil {
+		return nil, err
+	}
+	var resp remoteSignResponse
+	if err = s.do(ctx, http.MethodPost, s.endpoint("sign"), body, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to sign")
+	}
+	if !s.pubKey.VerifySignature(signBytes, resp.Signature) {
+		return nil, errors.New("invalid signature from remote signer")
+	}
+	return resp.Signature, nil
+}
+
+func (s *RemoteSigner) endpoint(p string) url.URL {
+	u := s.url
+	u.Path = path.Join(u.Path, p)
+	return u
+}
+
+func (s *RemoteSigner) do(ctx context.Context, method string, u url.URL, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(b))
+	}
+	return json.Unmarshal(b, out)
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	wasmtypes "github.com/terra-money/core/x/wasm/types"
+
+	"github.com/smartcontractkit/terra.go/tx"
+)
+
+// newSignerServer returns a stand-in for a remote signing service, backed by signers.
+func newSignerServer(t *testing.T, signers ...Signer) *httptest.Server {
+	bySender := make(map[string]Signer)
+	for _, s := range signers {
+		bySender[s.Address().String()] = s
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pubkey", func(w http.ResponseWriter, r *http.Request) {
+		s, ok := bySender[r.URL.Query().Get("address")]
+		if !ok {
+			http.Error(w, "unknown address", http.StatusNotFound)
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(remotePubKeyResponse{PubKey: s.PubKey().Bytes()}))
+	})
+	mux.HandleFunc("/sign", func(w http.ResponseWriter, r *http.Request) {
+		var req remoteSignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s, ok := bySender[req.Address]
+		if !ok {
+			http.Error(w, "unknown address", http.StatusNotFound)
+			return
+		}
+		sig, err := s.Sign(r.Context(), req.SignBytes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(remoteSignResponse{Signature: sig}))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSigners(t *testing.T) {
+	ctx := context.Background()
+	privKey := secp256k1.GenPrivKey()
+	inMemory := NewPrivKeySigner(privKey)
+
+	kr := keyring.NewInMemory()
+	info, _, err := kr.NewMnemonic("transmitter", keyring.English, sdk.FullFundraiserPath, keyring.DefaultBIP39Passphrase, hd.Secp256k1)
+	require.NoError(t, err)
+	fromKeyring, err := NewKeyringSigner(kr, "transmitter")
+	require.NoError(t, err)
+	assert.Equal(t, info.GetAddress(), fromKeyring.Address())
+	_, err = NewKeyringSigner(kr, "missing")
+	require.Error(t, err)
+
+	srv := newSignerServer(t, inMemory)
+	srvURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	remote, err := NewRemoteSigner(ctx, *srvURL, inMemory.Address(), srv.Client())
+	require.NoError(t, err)
+	assert.Equal(t, inMemory.Address(), remote.Address())
+	_, err = NewRemoteSigner(ctx, *srvURL, fromKeyring.Address(), srv.Client())
+	require.ErrorContains(t, err, "unknown address")
+
+	for _, tt := range []struct {
+		name   string
+		signer Signer
+	}{
+		{"in memory", inMemory},
+		{"keyring", fromKeyring},
+		{"remote", remote},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := []byte("sign me")
+			sig, err := tt.signer.Sign(ctx, msg)
+			require.NoError(t, err)
+			assert.True(t, tt.signer.PubKey().VerifySignature(msg, sig))
+			assert.Equal(t, sdk.AccAddress(tt.signer.PubKey().Address()), tt.signer.Address())
+		})
+	}
+
+	t.Run("remote invalid signature", func(t *testing.T) {
+		// A service which signs with the wrong key.
+		other := NewPrivKeySigner(secp256k1.GenPrivKey())
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/pubkey" {
+				require.NoError(t, json.NewEncoder(w).Encode(remotePubKeyResponse{PubKey: inMemory.PubKey().Bytes()}))
+				return
+			}
+			var req remoteSignRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			sig, err := other.Sign(r.Context(), req.SignBytes)
+			require.NoError(t, err)
+			require.NoError(t, json.NewEncoder(w).Encode(remoteSignResponse{Signature: sig}))
+		}))
+		t.Cleanup(srv.Close)
+		u, err := url.Parse(srv.URL)
+		require.NoError(t, err)
+		bad, err := NewRemoteSigner(ctx, *u, inMemory.Address(), srv.Client())
+		require.NoError(t, err)
+		_, err = bad.Sign(ctx, []byte("sign me"))
+		require.ErrorContains(t, err, "invalid signature")
+	})
+}
+
+func TestClient_CreateAndSign(t *testing.T) {
+	ctx := context.Background()
+	privKey := secp256k1.GenPrivKey()
+	signer := NewPrivKeySigner(privKey)
+	c := &Client{chainID: "test"}
+	msgs := []sdk.Msg{wasmtypes.NewMsgExecuteContract(signer.Address(), sdk.AccAddress("contract"), []byte(`{}`), sdk.Coins{})}
+	gasPrice := sdk.NewDecCoinFromDec("uluna", sdk.MustNewDecFromStr("0.01"))
+
+	txBytes, err := c.CreateAndSign(ctx, msgs, 3, 7, 1000, 1.5, gasPrice, signer, nil, 10)
+	require.NoError(t, err)
+
+	decoded, err := encodingConfig.TxConfig.TxDecoder()(txBytes)
+	require.NoError(t, err)
+	sigTx, ok := decoded.(authsigning.SigVerifiableTx)
+	require.True(t, ok)
+	sigs, err := sigTx.GetSignaturesV2()
+	require.NoError(t, err)
+	require.Len(t, sigs, 1)
+	assert.Equal(t, uint64(7), sigs[0].Sequence)
+	assert.True(t, signer.PubKey().Equals(sigs[0].PubKey))
+	signBytes, err := encodingConfig.TxConfig.SignModeHandler().GetSignBytes(signing.SignMode_SIGN_MODE_DIRECT, authsigning.SignerData{
+		ChainID:       "test",
+		AccountNumber: 3,
+		Sequence:      7,
+	}, decoded)
+	require.NoError(t, err)
+	assert.True(t, signer.PubKey().VerifySignature(signBytes, sigs[0].Data.(*signing.SingleSignatureData).Signature))
+
+	// Identical to signing with the key directly, since signatures are deterministic.
+	txbuilder := tx.NewTxBuilder(encodingConfig.TxConfig)
+	require.NoError(t, txbuilder.SetMsgs(msgs...))
+	txbuilder.SetGasLimit(1500)
+	txbuilder.SetFeeAmount(sdk.NewCoins(sdk.NewInt64Coin("uluna", 15)))
+	txbuilder.SetTimeoutHeight(10)
+	require.NoError(t, txbuilder.Sign(tx.SignModeDirect, tx.SignerData{AccountNumber: 3, ChainID: "test", Sequence: 7}, privKey, true))
+	exp, err := txbuilder.GetTxBytes()
+	require.NoError(t, err)
+	assert.Equal(t, exp, txBytes)
+}
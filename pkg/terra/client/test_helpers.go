@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
@@ -149,10 +150,11 @@ func DeployTestContract(t *testing.T, tendermintURL string, deployAccount, owner
 	out, err := exec.Command("terrad", "tx", "wasm", "store", wasmTestContractPath, "--node", tendermintURL,
 		"--from", deployAccount.Name, "--gas", "auto", "--fees", "100000uluna", "--chain-id", "42", "--broadcast-mode", "block", "--home", testdir, "--keyring-backend", "test", "--keyring-dir", testdir, "--yes").CombinedOutput()
 	require.NoError(t, err, string(out))
-	an, sn, err2 := tc.Account(ownerAccount.Address)
+	ctx := context.Background()
+	an, sn, err2 := tc.Account(ctx, ownerAccount.Address)
 	require.NoError(t, err2)
-	r, err3 := tc.SignAndBroadcast([]msg.Msg{
-		msg.NewMsgInstantiateContract(ownerAccount.Address, nil, 1, []byte(`{"count":0}`), nil)}, an, sn, minGasPrice, ownerAccount.PrivateKey, txtypes.BroadcastMode_BROADCAST_MODE_BLOCK)
+	r, err3 := tc.SignAndBroadcast(ctx, []msg.Msg{
+		msg.NewMsgInstantiateContract(ownerAccount.Address, nil, 1, []byte(`{"count":0}`), nil)}, an, sn, minGasPrice, NewPrivKeySigner(ownerAccount.PrivateKey), nil, txtypes.BroadcastMode_BROADCAST_MODE_BLOCK)
 	require.NoError(t, err3)
 	return GetContractAddr(t, tc, r.TxResponse.TxHash)
 }
@@ -160,8 +162,9 @@ func DeployTestContract(t *testing.T, tendermintURL string, deployAccount, owner
 func GetContractAddr(t *testing.T, tc *Client, deploymentHash string) sdk.AccAddress {
 	var deploymentTx *txtypes.GetTxResponse
 	var err error
+	ctx := context.Background()
 	for try := 0; try < 5; try++ {
-		deploymentTx, err = tc.Tx(deploymentHash)
+		deploymentTx, err = tc.Tx(ctx, deploymentHash)
 		if err != nil {
 			time.Sleep(1 * time.Second)
 			continue
@@ -1,6 +1,8 @@
 package terra
 
 import (
+	"encoding/hex"
+	"fmt"
 	"net/url"
 	"sync"
 	"time"
@@ -10,6 +12,8 @@ import (
 	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	"gopkg.in/guregu/null.v4"
 
 	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
 )
@@ -17,6 +21,9 @@ import (
 // Global terra defaults.
 var defaultConfigSet = configSet{
 	BlockRate: 6 * time.Second,
+	// Each attempt expires after about 30s, and is then resubmitted with a higher gas price.
+	// The mempool has no replace-by-fee, so the same sequence cannot be resubmitted until the previous attempt expires.
+	BlocksUntilGasBump: 5,
 	// ~6s per block, so ~3m until we give up on the tx getting confirmed
 	// Anecdotally it appears anything more than 4 blocks would be an extremely long wait,
 	// In practice during the UST depegging and subsequent extreme congestion, we saw
@@ -24,12 +31,20 @@ var defaultConfigSet = configSet{
 	BlocksUntilTxTimeout:  30,
 	ConfirmPollPeriod:     time.Second,
 	FallbackGasPriceULuna: sdk.MustNewDecFromStr("0.015"),
-	// This is high since we simulate before signing the transaction.
-	// There's a chicken and egg problem: need to sign to simulate accurately
-	// but you need to specify a gas limit when signing.
-	// TODO: Determine how much gas a signature adds and then
-	// add that directly so we can be more accurate.
+	FeeDenoms:             []string{"uluna"},
+	// ~2m of blocks, for client.BlockFeeHistoryGasPriceEstimator.
+	FeeHistoryBlocks: 20,
+	// The median price paid is usually enough to be included promptly, with gas bumping as needed.
+	FeeHistoryPercentile: 50,
+	// 20% per bump compounds to ~2.5x over the 5 resubmissions before BlocksUntilTxTimeout.
+	GasBumpPercent: 20,
+	// Only applies until the gas used by a contract's txs has been observed, after which
+	// estimates are corrected by a learned factor instead. See client.GasEstimator.
 	GasLimitMultiplier: client.DefaultGasLimitMultiplier,
+	// Gas prices normally move gradually, and GasBumpPercent compounds to less than this over a tx's lifetime.
+	GasPriceSpikeRatio: 3,
+	// Caps gas bumping, to bound spending during congestion.
+	MaxGasPriceULuna: sdk.MustNewDecFromStr("1"),
 	// The max gas limit per block is 1_000_000_000
 	// https://github.com/terra-money/core/blob/d6037b9a12c8bf6b09fe861c8ad93456aac5eebb/app/legacy/migrate.go#L69.
 	// The max msg size is 10KB https://github.com/terra-money/core/blob/d6037b9a12c8bf6b09fe861c8ad93456aac5eebb/x/wasm/types/params.go#L15.
@@ -39,22 +54,52 @@ var defaultConfigSet = configSet{
 	// To be conservative and since the number of messages we'd
 	// have in a batch on average roughly corresponds to the number of terra ocr jobs we're running (do not expect more than 100),
 	// we can set a max msgs per batch of 100.
-	MaxMsgsPerBatch:     100,
-	OCR2CachePollPeriod: 4 * time.Second,
-	OCR2CacheTTL:        time.Minute,
-	TxMsgTimeout:        10 * time.Minute,
+	MaxMsgsPerBatch: 100,
+	// Values restored from a ContractCacheStore on start remain usable this long past OCR2CacheTTL,
+	// so that OCR keeps running through a restart during an RPC outage.
+	OCR2CacheGracePeriod: 5 * time.Minute,
+	OCR2CachePollPeriod:  4 * time.Second,
+	OCR2CacheTTL:         time.Minute,
+	// Verified reads are opt-in, since the trusted block must be kept recent enough that its validators still sign.
+	TrustedBlockHash:   nil,
+	TrustedBlockHeight: 0,
+	// Confirmed and Errored msgs are kept this long after their last update, for inspection, then pruned.
+	TxMsgRetention: 24 * time.Hour,
+	TxMsgTimeout:   10 * time.Minute,
 }
 
 type Config interface {
+	// AuthzGranter is the account on whose behalf transmitters execute, via authz grants. Nil if unset.
+	AuthzGranter() sdk.AccAddress
 	BlockRate() time.Duration
+	BlocksUntilGasBump() int64
 	BlocksUntilTxTimeout() int64
 	ConfirmPollPeriod() time.Duration
 	FallbackGasPriceULuna() sdk.Dec
 	FCDURL() url.URL
+	// FeeDenoms are the denoms which fees may be paid in, in order of preference.
+	FeeDenoms() []string
+	// FeeGranter is the account which pays tx fees, via feegrant allowances. Nil if unset.
+	FeeGranter() sdk.AccAddress
+	FeeHistoryBlocks() int64
+	FeeHistoryPercentile() int64
+	GasBumpPercent() int64
 	GasLimitMultiplier() float64
+	// GasPriceSpikeRatio is how many times above or below the trailing average a gas price must be to be rejected,
+	// pausing non-urgent msgs. See client.GuardedGasPriceEstimator.
+	GasPriceSpikeRatio() float64
+	MaxGasPriceULuna() sdk.Dec
 	MaxMsgsPerBatch() int64
+	// OCR2CacheGracePeriod is how long past OCR2CacheTTL a ContractCache may use stale values restored on start.
+	OCR2CacheGracePeriod() time.Duration
 	OCR2CachePollPeriod() time.Duration
 	OCR2CacheTTL() time.Duration
+	// TrustedBlockHash and TrustedBlockHeight identify a block whose validators are trusted to sign headers,
+	// which enables verified contract reads. Nil and 0 if unset. See client.ProofVerifier.
+	TrustedBlockHash() []byte
+	TrustedBlockHeight() int64
+	// TxMsgRetention is how long msgs in a terminal state are kept after their last update.
+	TxMsgRetention() time.Duration
 	TxMsgTimeout() time.Duration
 
 	// Update sets new chain config values.
@@ -63,14 +108,25 @@ type Config interface {
 
 type configSet struct {
 	BlockRate             time.Duration
+	BlocksUntilGasBump    int64
 	BlocksUntilTxTimeout  int64
 	ConfirmPollPeriod     time.Duration
 	FallbackGasPriceULuna sdk.Dec
 	FCDURL                url.URL
+	FeeDenoms             []string
+	FeeHistoryBlocks      int64
+	FeeHistoryPercentile  int64
+	GasBumpPercent        int64
 	GasLimitMultiplier    float64
+	GasPriceSpikeRatio    float64
+	MaxGasPriceULuna      sdk.Dec
 	MaxMsgsPerBatch       int64
+	OCR2CacheGracePeriod  time.Duration
 	OCR2CachePollPeriod   time.Duration
 	OCR2CacheTTL          time.Duration
+	TrustedBlockHash      []byte
+	TrustedBlockHeight    int64
+	TxMsgRetention        time.Duration
 	TxMsgTimeout          time.Duration
 }
 
@@ -98,6 +154,13 @@ func (c *config) Update(dbcfg db.ChainCfg) {
 	c.chainMu.Unlock()
 }
 
+func (c *config) AuthzGranter() sdk.AccAddress {
+	c.chainMu.RLock()
+	ch := c.chain.AuthzGranter
+	c.chainMu.RUnlock()
+	return c.address("AuthzGranter", ch)
+}
+
 func (c *config) BlockRate() time.Duration {
 	c.chainMu.RLock()
 	ch := c.chain.BlockRate
@@ -108,6 +171,16 @@ func (c *config) BlockRate() time.Duration {
 	return c.defaults.BlockRate
 }
 
+func (c *config) BlocksUntilGasBump() int64 {
+	c.chainMu.RLock()
+	ch := c.chain.BlocksUntilGasBump
+	c.chainMu.RUnlock()
+	if ch.Valid {
+		return ch.Int64
+	}
+	return c.defaults.BlocksUntilGasBump
+}
+
 func (c *config) BlocksUntilTxTimeout() int64 {
 	c.chainMu.RLock()
 	ch := c.chain.BlocksUntilTxTimeout
@@ -158,6 +231,58 @@ func (c *config) FCDURL() url.URL {
 	return c.defaults.FCDURL
 }
 
+func (c *config) FeeDenoms() []string {
+	c.chainMu.RLock()
+	ch := c.chain.FeeDenoms
+	c.chainMu.RUnlock()
+	if ch.Valid {
+		str := ch.String
+		denoms, err := db.ParseFeeDenoms(str)
+		if err == nil {
+			return denoms
+		}
+		c.lggr.Warnf(invalidFallbackMsg, "FeeDenoms", str, c.defaults.FeeDenoms, err)
+	}
+	return c.defaults.FeeDenoms
+}
+
+func (c *config) FeeGranter() sdk.AccAddress {
+	c.chainMu.RLock()
+	ch := c.chain.FeeGranter
+	c.chainMu.RUnlock()
+	return c.address("FeeGranter", ch)
+}
+
+func (c *config) FeeHistoryBlocks() int64 {
+	c.chainMu.RLock()
+	ch := c.chain.FeeHistoryBlocks
+	c.chainMu.RUnlock()
+	if ch.Valid {
+		return ch.Int64
+	}
+	return c.defaults.FeeHistoryBlocks
+}
+
+func (c *config) FeeHistoryPercentile() int64 {
+	c.chainMu.RLock()
+	ch := c.chain.FeeHistoryPercentile
+	c.chainMu.RUnlock()
+	if ch.Valid {
+		return ch.Int64
+	}
+	return c.defaults.FeeHistoryPercentile
+}
+
+func (c *config) GasBumpPercent() int64 {
+	c.chainMu.RLock()
+	ch := c.chain.GasBumpPercent
+	c.chainMu.RUnlock()
+	if ch.Valid {
+		return ch.Int64
+	}
+	return c.defaults.GasBumpPercent
+}
+
 func (c *config) GasLimitMultiplier() float64 {
 	c.chainMu.RLock()
 	ch := c.chain.GasLimitMultiplier
@@ -168,6 +293,31 @@ func (c *config) GasLimitMultiplier() float64 {
 	return c.defaults.GasLimitMultiplier
 }
 
+func (c *config) GasPriceSpikeRatio() float64 {
+	c.chainMu.RLock()
+	ch := c.chain.GasPriceSpikeRatio
+	c.chainMu.RUnlock()
+	if ch.Valid {
+		return ch.Float64
+	}
+	return c.defaults.GasPriceSpikeRatio
+}
+
+func (c *config) MaxGasPriceULuna() sdk.Dec {
+	c.chainMu.RLock()
+	ch := c.chain.MaxGasPriceULuna
+	c.chainMu.RUnlock()
+	if ch.Valid {
+		str := ch.String
+		dec, err := sdk.NewDecFromStr(str)
+		if err == nil {
+			return dec
+		}
+		c.lggr.Warnf(invalidFallbackMsg, "MaxGasPriceULuna", str, c.defaults.MaxGasPriceULuna, err)
+	}
+	return c.defaults.MaxGasPriceULuna
+}
+
 func (c *config) MaxMsgsPerBatch() int64 {
 	c.chainMu.RLock()
 	ch := c.chain.MaxMsgsPerBatch
@@ -178,6 +328,16 @@ func (c *config) MaxMsgsPerBatch() int64 {
 	return c.defaults.MaxMsgsPerBatch
 }
 
+func (c *config) OCR2CacheGracePeriod() time.Duration {
+	c.chainMu.RLock()
+	ch := c.chain.OCR2CacheGracePeriod
+	c.chainMu.RUnlock()
+	if ch != nil {
+		return ch.Duration()
+	}
+	return c.defaults.OCR2CacheGracePeriod
+}
+
 func (c *config) OCR2CachePollPeriod() time.Duration {
 	c.chainMu.RLock()
 	ch := c.chain.OCR2CachePollPeriod
@@ -198,6 +358,44 @@ func (c *config) OCR2CacheTTL() time.Duration {
 	return c.defaults.OCR2CacheTTL
 }
 
+func (c *config) TrustedBlockHash() []byte {
+	c.chainMu.RLock()
+	ch := c.chain.TrustedBlockHash
+	c.chainMu.RUnlock()
+	if ch.Valid && ch.String != "" {
+		str := ch.String
+		hash, err := hex.DecodeString(str)
+		if err == nil && len(hash) != tmhash.Size {
+			err = fmt.Errorf("expected %d bytes, got %d", tmhash.Size, len(hash))
+		}
+		if err == nil {
+			return hash
+		}
+		c.lggr.Warnf(invalidFallbackMsg, "TrustedBlockHash", str, "", err)
+	}
+	return c.defaults.TrustedBlockHash
+}
+
+func (c *config) TrustedBlockHeight() int64 {
+	c.chainMu.RLock()
+	ch := c.chain.TrustedBlockHeight
+	c.chainMu.RUnlock()
+	if ch.Valid {
+		return ch.Int64
+	}
+	return c.defaults.TrustedBlockHeight
+}
+
+func (c *config) TxMsgRetention() time.Duration {
+	c.chainMu.RLock()
+	ch := c.chain.TxMsgRetention
+	c.chainMu.RUnlock()
+	if ch != nil {
+		return ch.Duration()
+	}
+	return c.defaults.TxMsgRetention
+}
+
 func (c *config) TxMsgTimeout() time.Duration {
 	c.chainMu.RLock()
 	ch := c.chain.TxMsgTimeout
@@ -208,4 +406,17 @@ func (c *config) TxMsgTimeout() time.Duration {
 	return c.defaults.TxMsgTimeout
 }
 
+// address parses an optional bech32 address, which is nil if unset or invalid.
+func (c *config) address(name string, ch null.String) sdk.AccAddress {
+	if !ch.Valid || ch.String == "" {
+		return nil
+	}
+	addr, err := sdk.AccAddressFromBech32(ch.String)
+	if err != nil {
+		c.lggr.Warnf(invalidFallbackMsg, name, ch.String, "", err)
+		return nil
+	}
+	return addr
+}
+
 const invalidFallbackMsg = `Invalid value provided for %s, "%s" - falling back to default "%s": %v`
@@ -14,15 +14,28 @@ import (
 )
 
 type Chain struct {
+	AuthzGranter          *string
 	BlockRate             *utils.Duration
+	BlocksUntilGasBump    *int64
 	BlocksUntilTxTimeout  *int64
 	ConfirmPollPeriod     *utils.Duration
 	FallbackGasPriceULuna *decimal.Decimal
 	FCDURL                *utils.URL
+	FeeDenoms             []string
+	FeeGranter            *string
+	FeeHistoryBlocks      *int64
+	FeeHistoryPercentile  *int64
+	GasBumpPercent        *int64
 	GasLimitMultiplier    *decimal.Decimal
+	GasPriceSpikeRatio    *decimal.Decimal
+	MaxGasPriceULuna      *decimal.Decimal
 	MaxMsgsPerBatch       *int64
+	OCR2CacheGracePeriod  *utils.Duration
 	OCR2CachePollPeriod   *utils.Duration
 	OCR2CacheTTL          *utils.Duration
+	TrustedBlockHash      *string
+	TrustedBlockHeight    *int64
+	TxMsgRetention        *utils.Duration
 	TxMsgTimeout          *utils.Duration
 }
 
@@ -30,9 +43,15 @@ func (c *Chain) SetFromDB(cfg *db.ChainCfg) error {
 	if cfg == nil {
 		return nil
 	}
+	if cfg.AuthzGranter.Valid {
+		c.AuthzGranter = &cfg.AuthzGranter.String
+	}
 	if cfg.BlockRate != nil {
 		c.BlockRate = utils.MustNewDuration(cfg.BlockRate.Duration())
 	}
+	if cfg.BlocksUntilGasBump.Valid {
+		c.BlocksUntilGasBump = &cfg.BlocksUntilGasBump.Int64
+	}
 	if cfg.BlocksUntilTxTimeout.Valid {
 		c.BlocksUntilTxTimeout = &cfg.BlocksUntilTxTimeout.Int64
 	}
@@ -55,19 +74,63 @@ func (c *Chain) SetFromDB(cfg *db.ChainCfg) error {
 		}
 		c.FCDURL = (*utils.URL)(d)
 	}
+	if cfg.FeeDenoms.Valid {
+		s := cfg.FeeDenoms.String
+		denoms, err := db.ParseFeeDenoms(s)
+		if err != nil {
+			return errors.Wrapf(err, "invalid FeeDenoms: %s", s)
+		}
+		c.FeeDenoms = denoms
+	}
+	if cfg.FeeGranter.Valid {
+		c.FeeGranter = &cfg.FeeGranter.String
+	}
+	if cfg.FeeHistoryBlocks.Valid {
+		c.FeeHistoryBlocks = &cfg.FeeHistoryBlocks.Int64
+	}
+	if cfg.FeeHistoryPercentile.Valid {
+		c.FeeHistoryPercentile = &cfg.FeeHistoryPercentile.Int64
+	}
+	if cfg.GasBumpPercent.Valid {
+		c.GasBumpPercent = &cfg.GasBumpPercent.Int64
+	}
 	if cfg.GasLimitMultiplier.Valid {
 		d := decimal.NewFromFloat(cfg.GasLimitMultiplier.Float64)
 		c.GasLimitMultiplier = &d
 	}
+	if cfg.GasPriceSpikeRatio.Valid {
+		d := decimal.NewFromFloat(cfg.GasPriceSpikeRatio.Float64)
+		c.GasPriceSpikeRatio = &d
+	}
+	if cfg.MaxGasPriceULuna.Valid {
+		s := cfg.MaxGasPriceULuna.String
+		d, err := decimal.NewFromString(s)
+		if err != nil {
+			return errors.Wrapf(err, "invalid decimal MaxGasPriceULuna: %s", s)
+		}
+		c.MaxGasPriceULuna = &d
+	}
 	if cfg.MaxMsgsPerBatch.Valid {
 		c.MaxMsgsPerBatch = &cfg.MaxMsgsPerBatch.Int64
 	}
+	if cfg.OCR2CacheGracePeriod != nil {
+		c.OCR2CacheGracePeriod = utils.MustNewDuration(cfg.OCR2CacheGracePeriod.Duration())
+	}
 	if cfg.OCR2CachePollPeriod != nil {
 		c.OCR2CachePollPeriod = utils.MustNewDuration(cfg.OCR2CachePollPeriod.Duration())
 	}
 	if cfg.OCR2CacheTTL != nil {
 		c.OCR2CacheTTL = utils.MustNewDuration(cfg.OCR2CacheTTL.Duration())
 	}
+	if cfg.TrustedBlockHash.Valid {
+		c.TrustedBlockHash = &cfg.TrustedBlockHash.String
+	}
+	if cfg.TrustedBlockHeight.Valid {
+		c.TrustedBlockHeight = &cfg.TrustedBlockHeight.Int64
+	}
+	if cfg.TxMsgRetention != nil {
+		c.TxMsgRetention = utils.MustNewDuration(cfg.TxMsgRetention.Duration())
+	}
 	if cfg.TxMsgTimeout != nil {
 		c.TxMsgTimeout = utils.MustNewDuration(cfg.TxMsgTimeout.Duration())
 	}
@@ -18,6 +18,8 @@ import (
 func TestChain_SetFromDB(t *testing.T) {
 	gasPriceULuna := decimal.RequireFromString("0.015")
 	gasLimitMultiplier := decimal.RequireFromString("1.5")
+	gasPriceSpikeRatio := decimal.RequireFromString("3")
+	maxGasPriceULuna := decimal.RequireFromString("1")
 	for _, tt := range []struct {
 		name  string
 		dbCfg *db.ChainCfg
@@ -26,26 +28,52 @@ func TestChain_SetFromDB(t *testing.T) {
 		{"nil", nil, Chain{}},
 		{"empty", &db.ChainCfg{}, Chain{}},
 		{"full", &db.ChainCfg{
+			AuthzGranter:          null.StringFrom("terra1rfazrm4r657r0u00uq50g8hehxewqq32zzhf9p"),
 			BlockRate:             utils.MustNewDuration(6 * time.Second),
+			BlocksUntilGasBump:    null.IntFrom(5),
 			BlocksUntilTxTimeout:  null.IntFrom(30),
 			ConfirmPollPeriod:     utils.MustNewDuration(time.Second),
 			FallbackGasPriceULuna: null.StringFrom("0.015"),
 			FCDURL:                null.StringFrom("http://fake.test"),
+			FeeDenoms:             null.StringFrom("uluna,uusd"),
+			FeeGranter:            null.StringFrom("terra1tfx3q08q780u9uu4qlw0drn375uktfka7kgh93"),
+			FeeHistoryBlocks:      null.IntFrom(20),
+			FeeHistoryPercentile:  null.IntFrom(50),
+			GasBumpPercent:        null.IntFrom(20),
 			GasLimitMultiplier:    null.FloatFrom(1.5),
+			GasPriceSpikeRatio:    null.FloatFrom(3),
+			MaxGasPriceULuna:      null.StringFrom("1"),
 			MaxMsgsPerBatch:       null.IntFrom(100),
+			OCR2CacheGracePeriod:  utils.MustNewDuration(5 * time.Minute),
 			OCR2CachePollPeriod:   utils.MustNewDuration(4 * time.Second),
 			OCR2CacheTTL:          utils.MustNewDuration(time.Minute),
+			TrustedBlockHash:      null.StringFrom("6A1DAF9A9E2BE5D2FA4BA1BB3A8FD0E7B9E3F6CC8D1C4F8E0F5B2A7C3D9E1F04"),
+			TrustedBlockHeight:    null.IntFrom(100),
+			TxMsgRetention:        utils.MustNewDuration(24 * time.Hour),
 			TxMsgTimeout:          utils.MustNewDuration(10 * time.Minute),
 		}, Chain{
+			AuthzGranter:          ptr("terra1rfazrm4r657r0u00uq50g8hehxewqq32zzhf9p"),
 			BlockRate:             utils.MustNewDuration(6 * time.Second),
+			BlocksUntilGasBump:    ptr[int64](5),
 			BlocksUntilTxTimeout:  ptr[int64](30),
 			ConfirmPollPeriod:     utils.MustNewDuration(time.Second),
 			FallbackGasPriceULuna: &gasPriceULuna,
 			FCDURL:                utils.MustParseURL("http://fake.test"),
+			FeeDenoms:             []string{"uluna", "uusd"},
+			FeeGranter:            ptr("terra1tfx3q08q780u9uu4qlw0drn375uktfka7kgh93"),
+			FeeHistoryBlocks:      ptr[int64](20),
+			FeeHistoryPercentile:  ptr[int64](50),
+			GasBumpPercent:        ptr[int64](20),
 			GasLimitMultiplier:    &gasLimitMultiplier,
+			GasPriceSpikeRatio:    &gasPriceSpikeRatio,
+			MaxGasPriceULuna:      &maxGasPriceULuna,
 			MaxMsgsPerBatch:       ptr[int64](100),
+			OCR2CacheGracePeriod:  utils.MustNewDuration(5 * time.Minute),
 			OCR2CachePollPeriod:   utils.MustNewDuration(4 * time.Second),
 			OCR2CacheTTL:          utils.MustNewDuration(time.Minute),
+			TrustedBlockHash:      ptr("6A1DAF9A9E2BE5D2FA4BA1BB3A8FD0E7B9E3F6CC8D1C4F8E0F5B2A7C3D9E1F04"),
+			TrustedBlockHeight:    ptr[int64](100),
+			TxMsgRetention:        utils.MustNewDuration(24 * time.Hour),
 			TxMsgTimeout:          utils.MustNewDuration(10 * time.Minute),
 		}},
 	} {
@@ -55,6 +83,13 @@ func TestChain_SetFromDB(t *testing.T) {
 			assert.Equal(t, tt.exp, c)
 		})
 	}
+
+	t.Run("fee denoms", func(t *testing.T) {
+		var c Chain
+		require.NoError(t, c.SetFromDB(&db.ChainCfg{FeeDenoms: null.StringFrom("uluna, uusd")}))
+		assert.Equal(t, []string{"uluna", "uusd"}, c.FeeDenoms)
+		require.ErrorContains(t, c.SetFromDB(&db.ChainCfg{FeeDenoms: null.StringFrom("uluna,")}), "invalid FeeDenoms")
+	})
 }
 
 func TestNode_SetFromDB(t *testing.T) {
@@ -1,6 +1,7 @@
 package terra
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -20,38 +21,84 @@ func TestConfig(t *testing.T) {
 
 	lggr, logs := logger.TestObserved(t, zap.WarnLevel)
 	cfg := NewConfig(db.ChainCfg{}, lggr)
+	assert.Nil(t, cfg.AuthzGranter())
 	assert.Equal(t, def.BlockRate, cfg.BlockRate())
+	assert.Equal(t, def.BlocksUntilGasBump, cfg.BlocksUntilGasBump())
 	assert.Equal(t, def.BlocksUntilTxTimeout, cfg.BlocksUntilTxTimeout())
 	assert.Equal(t, def.ConfirmPollPeriod, cfg.ConfirmPollPeriod())
 	assert.Equal(t, def.FallbackGasPriceULuna, cfg.FallbackGasPriceULuna())
 	assert.Equal(t, def.FCDURL, cfg.FCDURL())
+	assert.Equal(t, def.FeeDenoms, cfg.FeeDenoms())
+	assert.Nil(t, cfg.FeeGranter())
+	assert.Equal(t, def.FeeHistoryBlocks, cfg.FeeHistoryBlocks())
+	assert.Equal(t, def.FeeHistoryPercentile, cfg.FeeHistoryPercentile())
+	assert.Equal(t, def.GasBumpPercent, cfg.GasBumpPercent())
 	assert.Equal(t, def.GasLimitMultiplier, cfg.GasLimitMultiplier())
+	assert.Equal(t, def.GasPriceSpikeRatio, cfg.GasPriceSpikeRatio())
+	assert.Equal(t, def.MaxGasPriceULuna, cfg.MaxGasPriceULuna())
 	assert.Equal(t, def.MaxMsgsPerBatch, cfg.MaxMsgsPerBatch())
+	assert.Equal(t, def.OCR2CacheGracePeriod, cfg.OCR2CacheGracePeriod())
+	assert.Nil(t, cfg.TrustedBlockHash())
+	assert.Equal(t, def.TrustedBlockHeight, cfg.TrustedBlockHeight())
+	assert.Equal(t, def.TxMsgRetention, cfg.TxMsgRetention())
 
 	minute, err := utils.NewDuration(time.Minute)
 	require.NoError(t, err)
+	granter := sdk.AccAddress("granter_____________")
 	updated := db.ChainCfg{
+		AuthzGranter:          null.StringFrom(granter.String()),
 		BlockRate:             &minute,
 		BlocksUntilTxTimeout:  null.IntFrom(1000),
 		FallbackGasPriceULuna: null.StringFrom("5.6"),
 		FCDURL:                null.StringFrom("http://example.com/fcd"),
+		FeeDenoms:             null.StringFrom("uusd, uluna"),
+		FeeGranter:            null.StringFrom(granter.String()),
+		FeeHistoryBlocks:      null.IntFrom(10),
+		GasBumpPercent:        null.IntFrom(50),
+		GasPriceSpikeRatio:    null.FloatFrom(2),
+		MaxGasPriceULuna:      null.StringFrom("0.5"),
+		OCR2CacheGracePeriod:  &minute,
+		TrustedBlockHash:      null.StringFrom("6A1DAF9A9E2BE5D2FA4BA1BB3A8FD0E7B9E3F6CC8D1C4F8E0F5B2A7C3D9E1F04"),
+		TrustedBlockHeight:    null.IntFrom(100),
+		TxMsgRetention:        &minute,
 	}
 	cfg.Update(updated)
+	assert.Equal(t, granter, cfg.AuthzGranter())
 	assert.Equal(t, updated.BlocksUntilTxTimeout.Int64, cfg.BlocksUntilTxTimeout())
 	assert.Equal(t, updated.BlockRate.Duration(), cfg.BlockRate())
 	assert.Equal(t, def.ConfirmPollPeriod, cfg.ConfirmPollPeriod())
 	assert.Equal(t, sdk.MustNewDecFromStr(updated.FallbackGasPriceULuna.String), cfg.FallbackGasPriceULuna())
 	fcdURL := cfg.FCDURL()
 	assert.Equal(t, updated.FCDURL.String, fcdURL.String())
+	assert.Equal(t, []string{"uusd", "uluna"}, cfg.FeeDenoms())
+	assert.Equal(t, granter, cfg.FeeGranter())
+	assert.Equal(t, updated.FeeHistoryBlocks.Int64, cfg.FeeHistoryBlocks())
+	assert.Equal(t, def.FeeHistoryPercentile, cfg.FeeHistoryPercentile())
+	assert.Equal(t, updated.GasBumpPercent.Int64, cfg.GasBumpPercent())
 	assert.Equal(t, def.GasLimitMultiplier, cfg.GasLimitMultiplier())
+	assert.Equal(t, updated.GasPriceSpikeRatio.Float64, cfg.GasPriceSpikeRatio())
+	assert.Equal(t, sdk.MustNewDecFromStr(updated.MaxGasPriceULuna.String), cfg.MaxGasPriceULuna())
 	assert.Equal(t, def.MaxMsgsPerBatch, cfg.MaxMsgsPerBatch())
+	assert.Equal(t, updated.OCR2CacheGracePeriod.Duration(), cfg.OCR2CacheGracePeriod())
+	assert.Equal(t, updated.TrustedBlockHash.String, fmt.Sprintf("%X", cfg.TrustedBlockHash()))
+	assert.Equal(t, updated.TrustedBlockHeight.Int64, cfg.TrustedBlockHeight())
+	assert.Equal(t, updated.TxMsgRetention.Duration(), cfg.TxMsgRetention())
 
 	updated = db.ChainCfg{
 		FallbackGasPriceULuna: null.StringFrom("not-a-number"),
+		FeeDenoms:             null.StringFrom("uluna,"),
+		FeeGranter:            null.StringFrom("not-an-address"),
+		TrustedBlockHash:      null.StringFrom("abcd"),
 	}
 	cfg.Update(updated)
 	assert.Equal(t, def.FallbackGasPriceULuna, cfg.FallbackGasPriceULuna())
-	if all := logs.All(); assert.Len(t, all, 1) {
+	assert.Equal(t, def.FeeDenoms, cfg.FeeDenoms())
+	assert.Nil(t, cfg.FeeGranter())
+	assert.Nil(t, cfg.TrustedBlockHash())
+	if all := logs.All(); assert.Len(t, all, 4) {
 		assert.Contains(t, all[0].Message, `Invalid value provided for FallbackGasPriceULuna, "not-a-number"`)
+		assert.Contains(t, all[1].Message, `Invalid value provided for FeeDenoms, "uluna,"`)
+		assert.Contains(t, all[2].Message, `Invalid value provided for FeeGranter, "not-an-address"`)
+		assert.Contains(t, all[3].Message, `Invalid value provided for TrustedBlockHash, "abcd"`)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"reflect"
 	"sync"
 	"time"
 
@@ -13,21 +14,44 @@ import (
 	"github.com/smartcontractkit/chainlink-relay/pkg/utils"
 	"github.com/smartcontractkit/libocr/offchainreporting2/reportingplugin/median"
 	"github.com/smartcontractkit/libocr/offchainreporting2/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
 )
 
 var _ median.MedianContract = (*ContractCache)(nil)
 
+// subscriptionMaxMissedBlocks is the number of block periods without a new block header after which
+// the event subscription is considered dead and re-established.
+const subscriptionMaxMissedBlocks = 10
+
 type ContractCache struct {
-	cfg    Config
-	reader *OCR2Reader
-	lggr   logger.Logger
+	cfg         Config
+	reader      *OCR2Reader
+	coordinator *PollCoordinator // nil unless batched
+	lggr        logger.Logger
+
+	// store is nil unless persisted, see withStore.
+	store   ContractCacheStore
+	chainID string
+	// persisted is the state last saved to store, and when. Only accessed by poll, and Close after it returns.
+	persisted   *db.ContractState
+	persistedAt time.Time
+
+	stop chan struct{}
+	wg   sync.WaitGroup
 
-	stop, done chan struct{}
+	// notifies are signalled when the config changes, one for each ContractTracker.
+	notifyMu sync.Mutex
+	notifies map[chan struct{}]struct{}
 
 	configMu    sync.RWMutex
 	configTS    time.Time
 	configBlock uint64
 	config      types.ContractConfig
+	// configRestored is true while config is from store, rather than read since Start.
+	configRestored bool
 
 	transMu         sync.RWMutex
 	transTS         time.Time
@@ -36,19 +60,48 @@ type ContractCache struct {
 	round           uint8
 	latestAnswer    *big.Int
 	latestTimestamp time.Time
+	// transRestored is true while the transmission details are from store, rather than read since Start.
+	transRestored bool
+
+	rrMu       sync.RWMutex
+	rrTS       time.Time
+	rrLookback time.Duration // from the latest call to LatestRoundRequested
+	rrDigest   types.ConfigDigest
+	rrEpoch    uint32
+	rrRound    uint8
 }
 
 func NewContractCache(cfg Config, reader *OCR2Reader, lggr logger.Logger) *ContractCache {
 	return &ContractCache{
-		cfg:    cfg,
-		reader: reader,
-		lggr:   lggr,
-		stop:   make(chan struct{}),
-		done:   make(chan struct{}),
+		cfg:      cfg,
+		reader:   reader,
+		lggr:     lggr,
+		stop:     make(chan struct{}),
+		notifies: make(map[chan struct{}]struct{}),
 	}
 }
 
+// NewBatchedContractCache returns a ContractCache whose config details and transmissions are polled by coordinator,
+// in batches with other contracts, instead of separately.
+func NewBatchedContractCache(cfg Config, reader *OCR2Reader, coordinator *PollCoordinator, lggr logger.Logger) *ContractCache {
+	cc := NewContractCache(cfg, reader, lggr)
+	cc.coordinator = coordinator
+	return cc
+}
+
+// withStore makes cc restore its state from store on Start, and persist its state to store as it is updated.
+func (cc *ContractCache) withStore(chainID string, store ContractCacheStore) *ContractCache {
+	cc.chainID = chainID
+	cc.store = store
+	return cc
+}
+
 func (cc *ContractCache) Start() error {
+	if cc.store != nil {
+		if err := cc.restore(); err != nil {
+			cc.lggr.Warnf("failed to restore persisted contract state: %v", err)
+		}
+	}
 	// We synchronously update the config on start so that
 	// when OCR starts there is config available (if possible).
 	// Avoids confusing "contract has not been configured" OCR errors.
@@ -57,22 +110,156 @@ func (cc *ContractCache) Start() error {
 	if err := cc.updateConfig(ctx); err != nil {
 		cc.lggr.Warnf("failed to populate initial config: %v", err)
 	}
+	if cc.coordinator != nil {
+		cc.coordinator.register(cc)
+	}
+	cc.wg.Add(2)
 	go cc.poll()
+	go cc.subscribe()
 	return nil
 }
 
 func (cc *ContractCache) Close() error {
 	close(cc.stop)
-	select {
-	case <-time.After(time.Second):
-		// can't rely on clients to cancel
-	case <-cc.done:
+	if cc.coordinator != nil {
+		cc.coordinator.unregister(cc)
+	}
+	cc.wg.Wait()
+	if cc.store != nil {
+		if err := cc.persist(true); err != nil {
+			cc.lggr.Errorf("Failed to persist contract state: %v", err)
+		}
+	}
+	return nil
+}
+
+// restore populates the cache from the state in store, if any. Restored values are stale but usable, for up to
+// OCR2CacheGracePeriod past OCR2CacheTTL since they were read from the chain, until they are replaced by fresh ones.
+func (cc *ContractCache) restore() error {
+	state, err := cc.store.LoadContractState(cc.chainID, cc.reader.address.String())
+	if err != nil || state == nil {
+		return err
+	}
+	if !state.ConfigUpdatedAt.IsZero() {
+		cc.configMu.Lock()
+		cc.configTS = state.ConfigUpdatedAt
+		cc.configBlock = state.ConfigBlock
+		cc.config = contractConfigFromState(state)
+		cc.configRestored = true
+		cc.configMu.Unlock()
 	}
+	if !state.TransmissionUpdatedAt.IsZero() {
+		cc.transMu.Lock()
+		cc.transTS = state.TransmissionUpdatedAt
+		cc.digest = state.TransmissionDigest
+		cc.epoch = state.Epoch
+		cc.round = state.Round
+		cc.latestAnswer = state.LatestAnswer
+		cc.latestTimestamp = state.LatestTimestamp
+		cc.transRestored = true
+		cc.transMu.Unlock()
+	}
+	cc.persisted, cc.persistedAt = state, time.Now()
+	cc.lggr.Infof("restored persisted contract state. [config block %v, config read at %v, transmission read at %v]",
+		state.ConfigBlock, state.ConfigUpdatedAt, state.TransmissionUpdatedAt)
 	return nil
 }
 
+// persist saves the cache's state to store if it has changed, or if force is true or the saved state is older
+// than OCR2CacheTTL, so that restored times stay recent.
+func (cc *ContractCache) persist(force bool) error {
+	state := db.ContractState{ChainID: cc.chainID, ContractAddress: cc.reader.address.String()}
+	cc.configMu.RLock()
+	if !cc.configRestored {
+		state.ConfigUpdatedAt = cc.configTS
+		setContractConfigState(&state, cc.configBlock, cc.config)
+	}
+	cc.configMu.RUnlock()
+	cc.transMu.RLock()
+	if !cc.transRestored {
+		state.TransmissionUpdatedAt = cc.transTS
+		state.TransmissionDigest = cc.digest
+		state.Epoch = cc.epoch
+		state.Round = cc.round
+		state.LatestAnswer = cc.latestAnswer
+		state.LatestTimestamp = cc.latestTimestamp
+	}
+	cc.transMu.RUnlock()
+	if state.ConfigUpdatedAt.IsZero() && state.TransmissionUpdatedAt.IsZero() {
+		return nil // nothing fresh, so keep any restored state as is
+	}
+	if cc.persisted != nil {
+		// keep restored parts which haven't been refreshed yet
+		if state.ConfigUpdatedAt.IsZero() {
+			setContractConfigState(&state, cc.persisted.ConfigBlock, contractConfigFromState(cc.persisted))
+			state.ConfigUpdatedAt = cc.persisted.ConfigUpdatedAt
+		}
+		if state.TransmissionUpdatedAt.IsZero() {
+			state.TransmissionUpdatedAt = cc.persisted.TransmissionUpdatedAt
+			state.TransmissionDigest = cc.persisted.TransmissionDigest
+			state.Epoch = cc.persisted.Epoch
+			state.Round = cc.persisted.Round
+			state.LatestAnswer = cc.persisted.LatestAnswer
+			state.LatestTimestamp = cc.persisted.LatestTimestamp
+		}
+		if !force && time.Since(cc.persistedAt) < cc.cfg.OCR2CacheTTL() && sameContractState(*cc.persisted, state) {
+			return nil
+		}
+	}
+	if err := cc.store.SaveContractState(state); err != nil {
+		return err
+	}
+	cc.persisted, cc.persistedAt = &state, time.Now()
+	return nil
+}
+
+func setContractConfigState(state *db.ContractState, changedInBlock uint64, config types.ContractConfig) {
+	state.ConfigBlock = changedInBlock
+	state.ConfigDigest = config.ConfigDigest
+	state.ConfigCount = config.ConfigCount
+	state.Signers = make([][]byte, len(config.Signers))
+	for i, s := range config.Signers {
+		state.Signers[i] = s
+	}
+	state.Transmitters = make([]string, len(config.Transmitters))
+	for i, t := range config.Transmitters {
+		state.Transmitters[i] = string(t)
+	}
+	state.F = config.F
+	state.OnchainConfig = config.OnchainConfig
+	state.OffchainConfigVersion = config.OffchainConfigVersion
+	state.OffchainConfig = config.OffchainConfig
+}
+
+func contractConfigFromState(state *db.ContractState) types.ContractConfig {
+	config := types.ContractConfig{
+		ConfigDigest:          state.ConfigDigest,
+		ConfigCount:           state.ConfigCount,
+		Signers:               make([]types.OnchainPublicKey, len(state.Signers)),
+		Transmitters:          make([]types.Account, len(state.Transmitters)),
+		F:                     state.F,
+		OnchainConfig:         state.OnchainConfig,
+		OffchainConfigVersion: state.OffchainConfigVersion,
+		OffchainConfig:        state.OffchainConfig,
+	}
+	for i, s := range state.Signers {
+		config.Signers[i] = s
+	}
+	for i, t := range state.Transmitters {
+		config.Transmitters[i] = types.Account(t)
+	}
+	return config
+}
+
+// sameContractState returns true if a and b differ by at most their UpdatedAt times.
+func sameContractState(a, b db.ContractState) bool {
+	a.ConfigUpdatedAt, b.ConfigUpdatedAt = time.Time{}, time.Time{}
+	a.TransmissionUpdatedAt, b.TransmissionUpdatedAt = time.Time{}, time.Time{}
+	return reflect.DeepEqual(a, b)
+}
+
 func (cc *ContractCache) poll() {
-	defer close(cc.done)
+	defer cc.wg.Done()
 	tick := time.After(0)
 	for {
 		select {
@@ -80,14 +267,21 @@ func (cc *ContractCache) poll() {
 			return
 		case <-tick:
 			ctx, cancel := utils.ContextFromChan(cc.stop)
-			if err := cc.updateConfig(ctx); err != nil {
-				cc.lggr.Errorf("Failed to update config: %v", err)
+			if cc.coordinator == nil { // otherwise see updateFromBatch
+				if err := cc.updateConfig(ctx); err != nil {
+					cc.lggr.Errorf("Failed to update config: %v", err)
+				}
+				if err := cc.updateTransmission(ctx); err != nil {
+					cc.lggr.Errorf("Failed to update transmission: %v", err)
+				}
 			}
-			if ctx.Err() != nil { // b/c client doesn't use ctx
-				return
+			if err := cc.updateRoundRequested(ctx); err != nil {
+				cc.lggr.Errorf("Failed to update round requested: %v", err)
 			}
-			if err := cc.updateTransmission(ctx); err != nil {
-				cc.lggr.Errorf("Failed to update transmission: %v", err)
+			if cc.store != nil {
+				if err := cc.persist(false); err != nil {
+					cc.lggr.Errorf("Failed to persist contract state: %v", err)
+				}
 			}
 			cancel()
 			tick = time.After(utils.WithJitter(cc.cfg.OCR2CachePollPeriod()))
@@ -95,19 +289,61 @@ func (cc *ContractCache) poll() {
 	}
 }
 
+// updateFromBatch is like updateConfig and updateTransmission, except from the results of their queries,
+// as batched by a PollCoordinator.
+func (cc *ContractCache) updateFromBatch(ctx context.Context, configDetails, transmissionDetails client.ContractQueryResult) {
+	if err := cc.updateConfigFromResult(ctx, configDetails); err != nil {
+		cc.lggr.Errorf("Failed to update config: %v", err)
+	}
+	if err := cc.updateTransmissionFromResult(ctx, transmissionDetails); err != nil {
+		cc.lggr.Errorf("Failed to update transmission: %v", err)
+	}
+}
+
+func (cc *ContractCache) updateConfigFromResult(ctx context.Context, res client.ContractQueryResult) error {
+	if res.Err != nil {
+		return errors.Wrap(res.Err, "fetch latest config details")
+	}
+	changedInBlock, configDigest, err := parseConfigDetails(res.Result)
+	if err != nil {
+		return errors.Wrap(err, "parse latest config details")
+	}
+	return cc.updateConfigDetails(ctx, changedInBlock, configDigest)
+}
+
+func (cc *ContractCache) updateTransmissionFromResult(ctx context.Context, res client.ContractQueryResult) error {
+	if isTransmissionNotFound(res.Err) {
+		// the reader handles contracts without transmissions
+		return cc.updateTransmission(ctx)
+	}
+	if res.Err != nil {
+		return errors.Wrap(res.Err, "fetch latest transmission")
+	}
+	digest, epoch, round, latestAnswer, latestTimestamp, err := parseTransmissionDetails(res.Result)
+	if err != nil {
+		return errors.Wrap(err, "parse latest transmission")
+	}
+	cc.setTransmission(digest, epoch, round, latestAnswer, latestTimestamp)
+	return nil
+}
+
 func (cc *ContractCache) updateConfig(ctx context.Context) error {
 	changedInBlock, configDigest, err := cc.reader.LatestConfigDetails(ctx)
 	if err != nil {
 		return errors.Wrap(err, "fetch latest config details")
 	}
-	if err = ctx.Err(); err != nil { // b/c client doesn't use ctx
-		return err
-	}
+	return cc.updateConfigDetails(ctx, changedInBlock, configDigest)
+}
+
+// updateConfigDetails refreshes the cached config if it matches changedInBlock and configDigest,
+// and otherwise fetches the latest config.
+func (cc *ContractCache) updateConfigDetails(ctx context.Context, changedInBlock uint64, configDigest types.ConfigDigest) error {
 	now := time.Now()
 	cc.configMu.Lock()
 	same := cc.configBlock == changedInBlock && cc.config.ConfigDigest == configDigest
 	if same {
 		cc.configTS = now // refresh TTL
+		cc.configRestored = false
 	}
 	cc.configMu.Unlock()
 	if same {
@@ -117,15 +353,125 @@ func (cc *ContractCache) updateConfig(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrapf(err, "fetch latest config, block %d", changedInBlock)
 	}
-	now = time.Now()
+	if cc.reader.Verified() && contractConfig.ConfigDigest != configDigest {
+		return fmt.Errorf("config from block %d has digest %s, but the contract's is %s", changedInBlock, contractConfig.ConfigDigest, configDigest)
+	}
+	cc.setConfig(changedInBlock, contractConfig)
+	return nil
+}
+
+// addNotify returns a new channel which is signalled when the config changes, until it is removed by removeNotify.
+func (cc *ContractCache) addNotify() chan struct{} {
+	ch := make(chan struct{}, 1)
+	cc.notifyMu.Lock()
+	defer cc.notifyMu.Unlock()
+	cc.notifies[ch] = struct{}{}
+	return ch
+}
+
+func (cc *ContractCache) removeNotify(ch chan struct{}) {
+	cc.notifyMu.Lock()
+	defer cc.notifyMu.Unlock()
+	delete(cc.notifies, ch)
+}
+
+// setConfig caches contractConfig, and signals each Notify if it differs from the cached config.
+func (cc *ContractCache) setConfig(changedInBlock uint64, contractConfig types.ContractConfig) {
+	now := time.Now()
 	cc.configMu.Lock()
+	if changedInBlock < cc.configBlock && !cc.configRestored {
+		// stale, e.g. from an event racing the poller
+		cc.configMu.Unlock()
+		return
+	}
+	changed := cc.configBlock != changedInBlock || cc.config.ConfigDigest != contractConfig.ConfigDigest
 	cc.configTS = now
 	cc.configBlock = changedInBlock
 	cc.config = contractConfig
+	cc.configRestored = false
 	cc.configMu.Unlock()
+	if !changed {
+		return
+	}
 	cc.lggr.Infof("updated config. [config %v, config block %v]",
 		contractConfig, changedInBlock)
-	return nil
+	cc.notifyMu.Lock()
+	defer cc.notifyMu.Unlock()
+	for ch := range cc.notifies {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// subscribe listens for contract events, and re-subscribes whenever the subscription fails.
+// Polling continues regardless, so updates are only delayed while the subscription is down.
+func (cc *ContractCache) subscribe() {
+	defer cc.wg.Done()
+	ctx, cancel := utils.ContextFromChan(cc.stop)
+	defer cancel()
+	for {
+		if err := cc.listen(ctx); err != nil {
+			cc.lggr.Warnf("Event subscription failed, falling back to polling: %v", err)
+		}
+		select {
+		case <-cc.stop:
+			return
+		case <-time.After(utils.WithJitter(cc.cfg.OCR2CachePollPeriod())):
+		}
+	}
+}
+
+// listen updates the cache from contract events until ctx is done, or no new blocks are received
+// for subscriptionMaxMissedBlocks block periods.
+func (cc *ContractCache) listen(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := cc.reader.SubscribeEvents(ctx)
+	if err != nil {
+		return errors.Wrap(err, "subscribe to events")
+	}
+	cc.lggr.Debugf("subscribed to contract events")
+	timeout := subscriptionMaxMissedBlocks * cc.cfg.BlockRate()
+	liveness := time.NewTimer(timeout)
+	defer liveness.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-liveness.C:
+			return fmt.Errorf("no new blocks received in %s", timeout)
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if !liveness.Stop() {
+				<-liveness.C
+			}
+			liveness.Reset(timeout)
+			tx, ok := ev.Data.(tmtypes.EventDataTx)
+			if !ok {
+				continue // block header
+			}
+			changedInBlock, contractConfig, found, err := cc.reader.ConfigFromTx(tx)
+			if err != nil {
+				cc.lggr.Errorf("Failed to parse set_config event: %v", err)
+			} else if found && cc.reader.Verified() {
+				// events can't be verified, so fetch the verified digest instead
+				if err := cc.updateConfig(ctx); err != nil {
+					cc.lggr.Errorf("Failed to update config: %v", err)
+				}
+			} else if found {
+				cc.setConfig(changedInBlock, contractConfig)
+			}
+			if cc.reader.HasTransmission(tx) {
+				if err := cc.updateTransmission(ctx); err != nil {
+					cc.lggr.Errorf("Failed to update transmission: %v", err)
+				}
+			}
+		}
+	}
 }
 
 func (cc *ContractCache) updateTransmission(ctx context.Context) error {
@@ -133,24 +479,64 @@ func (cc *ContractCache) updateTransmission(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "fetch latest transmission")
 	}
+	cc.setTransmission(digest, epoch, round, latestAnswer, latestTimestamp)
+	return nil
+}
+
+// setTransmission caches the transmission details, unless they are older than the cached ones for the same config.
+func (cc *ContractCache) setTransmission(digest types.ConfigDigest, epoch uint32, round uint8, latestAnswer *big.Int, latestTimestamp time.Time) {
 	now := time.Now()
 	cc.transMu.Lock()
+	if digest == cc.digest && !cc.transRestored && (epoch < cc.epoch || epoch == cc.epoch && round < cc.round) {
+		// stale, e.g. from a poll racing an event
+		cc.transMu.Unlock()
+		return
+	}
 	cc.transTS = now
 	cc.digest = digest
 	cc.epoch = epoch
 	cc.round = round
 	cc.latestAnswer = latestAnswer
 	cc.latestTimestamp = latestTimestamp
+	cc.transRestored = false
 	cc.transMu.Unlock()
 	cc.lggr.Infof("updated transmission details. [epoch %v, round %v, answer %v, ts %v]",
 		epoch, round, latestAnswer, latestTimestamp)
+}
+
+// updateRoundRequested is a no-op until the lookback is known from a call to LatestRoundRequested.
+func (cc *ContractCache) updateRoundRequested(ctx context.Context) error {
+	cc.rrMu.RLock()
+	lookback := cc.rrLookback
+	cc.rrMu.RUnlock()
+	if lookback == 0 {
+		return nil
+	}
+	digest, epoch, round, err := cc.reader.LatestRoundRequested(ctx, lookback)
+	if err != nil {
+		return errors.Wrap(err, "fetch latest round requested")
+	}
+	now := time.Now()
+	cc.rrMu.Lock()
+	cc.rrTS = now
+	cc.rrDigest = digest
+	cc.rrEpoch = epoch
+	cc.rrRound = round
+	cc.rrMu.Unlock()
+	cc.lggr.Debugf("updated round requested. [epoch %v, round %v]", epoch, round)
 	return nil
 }
 
-func (cc *ContractCache) checkTS(ts time.Time) error {
+// checkTS returns an error if a value cached at ts has expired. Restored values expire OCR2CacheGracePeriod later.
+func (cc *ContractCache) checkTS(ts time.Time, restored bool) error {
 	if ts.IsZero() {
 		return errors.New("contract cache not yet initialized")
-	} else if since := time.Since(ts); since > cc.cfg.OCR2CacheTTL() {
+	}
+	ttl := cc.cfg.OCR2CacheTTL()
+	if restored {
+		ttl += cc.cfg.OCR2CacheGracePeriod()
+	}
+	if since := time.Since(ts); since > ttl {
 		return fmt.Errorf("contract cache expired: value cached %s ago", since)
 	}
 	return nil
@@ -161,8 +547,9 @@ func (cc *ContractCache) LatestConfigDetails(ctx context.Context) (changedInBloc
 	ts := cc.configTS
 	changedInBlock = cc.configBlock
 	configDigest = cc.config.ConfigDigest
+	restored := cc.configRestored
 	cc.configMu.RUnlock()
-	err = cc.checkTS(ts)
+	err = cc.checkTS(ts, restored)
 	return
 }
 
@@ -171,8 +558,9 @@ func (cc *ContractCache) LatestConfig(ctx context.Context, changedInBlock uint64
 	ts := cc.configTS
 	contractConfig = cc.config
 	cachedBlock := cc.configBlock
+	restored := cc.configRestored
 	cc.configMu.RUnlock()
-	err = cc.checkTS(ts)
+	err = cc.checkTS(ts, restored)
 	if err == nil && cachedBlock != changedInBlock {
 		err = fmt.Errorf("failed to get config from %d: latest config in cache is from %d", changedInBlock, cachedBlock)
 	}
@@ -194,8 +582,9 @@ func (cc *ContractCache) LatestTransmissionDetails(ctx context.Context) (
 	round = cc.round
 	latestAnswer = cc.latestAnswer
 	latestTimestamp = cc.latestTimestamp
+	restored := cc.transRestored
 	cc.transMu.RUnlock()
-	err = cc.checkTS(ts)
+	err = cc.checkTS(ts, restored)
 	return
 }
 
@@ -216,6 +605,22 @@ func (cc *ContractCache) LatestRoundRequested(ctx context.Context, lookback time
 	round uint8,
 	err error,
 ) {
-	// Not supporting this feature initially, rounds are frequent enough.
-	return types.ConfigDigest{}, 0, 0, nil
+	cc.rrMu.Lock()
+	cc.rrLookback = lookback
+	ts := cc.rrTS
+	cc.rrMu.Unlock()
+	if ts.IsZero() {
+		// The poller can't fetch until the first call provides the lookback, so fetch synchronously once.
+		if err = cc.updateRoundRequested(ctx); err != nil {
+			return
+		}
+	}
+	cc.rrMu.RLock()
+	ts = cc.rrTS
+	configDigest = cc.rrDigest
+	epoch = cc.rrEpoch
+	round = cc.rrRound
+	cc.rrMu.RUnlock()
+	err = cc.checkTS(ts, false)
+	return
 }
@@ -0,0 +1,85 @@
+package terra
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+// ContractCacheStore persists the state of ContractCaches, so that they can warm-start from it while the chain
+// cannot be read.
+type ContractCacheStore interface {
+	// LoadContractState returns the state saved for contract, or nil if there is none.
+	LoadContractState(chainID, contractAddress string) (*db.ContractState, error)
+	// SaveContractState replaces any state saved for the same contract.
+	SaveContractState(state db.ContractState) error
+}
+
+var _ ContractCacheStore = (*FileContractCacheStore)(nil)
+
+// FileContractCacheStore is a ContractCacheStore which saves the state of each contract to a JSON file in a directory.
+type FileContractCacheStore struct {
+	dir string
+}
+
+// NewFileContractCacheStore returns a FileContractCacheStore which saves to dir, creating it if necessary.
+func NewFileContractCacheStore(dir string) (*FileContractCacheStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create contract cache dir %s", dir)
+	}
+	return &FileContractCacheStore{dir: dir}, nil
+}
+
+func (s *FileContractCacheStore) path(chainID, contractAddress string) string {
+	return filepath.Join(s.dir, url.PathEscape(chainID)+"_"+url.PathEscape(contractAddress)+".json")
+}
+
+func (s *FileContractCacheStore) LoadContractState(chainID, contractAddress string) (*db.ContractState, error) {
+	b, err := os.ReadFile(s.path(chainID, contractAddress))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var state db.ContractState
+	if err = json.Unmarshal(b, &state); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse contract state for %s", contractAddress)
+	}
+	if state.ChainID != chainID || state.ContractAddress != contractAddress {
+		return nil, fmt.Errorf("contract state for %s is from %s on %s", contractAddress, state.ContractAddress, state.ChainID)
+	}
+	return &state, nil
+}
+
+// SaveContractState writes to a temporary file first, so that a crash never leaves a partial file.
+func (s *FileContractCacheStore) SaveContractState(state db.ContractState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	path := s.path(state.ChainID, state.ContractAddress)
+	f, err := os.CreateTemp(s.dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
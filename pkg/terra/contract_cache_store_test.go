@@ -0,0 +1,145 @@
+package terra
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	cosmosSDK "github.com/cosmos/cosmos-sdk/types"
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/smartcontractkit/chainlink-relay/pkg/utils"
+	"github.com/smartcontractkit/libocr/offchainreporting2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client/mocks"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+func TestFileContractCacheStore(t *testing.T) {
+	store, err := NewFileContractCacheStore(t.TempDir())
+	require.NoError(t, err)
+
+	got, err := store.LoadContractState("chain", "contract")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	state := db.ContractState{
+		ChainID:               "chain",
+		ContractAddress:       "contract",
+		ConfigBlock:           10,
+		ConfigDigest:          [32]byte{1, 2, 3},
+		ConfigCount:           2,
+		Signers:               [][]byte{{4, 5}},
+		Transmitters:          []string{"transmitter"},
+		F:                     1,
+		OnchainConfig:         []byte{6},
+		OffchainConfigVersion: 7,
+		OffchainConfig:        []byte{8},
+		ConfigUpdatedAt:       time.Unix(100, 0).UTC(),
+		TransmissionDigest:    [32]byte{1, 2, 3},
+		Epoch:                 4,
+		Round:                 5,
+		LatestAnswer:          big.NewInt(42),
+		LatestTimestamp:       time.Unix(90, 0).UTC(),
+		TransmissionUpdatedAt: time.Unix(101, 0).UTC(),
+	}
+	require.NoError(t, store.SaveContractState(state))
+	got, err = store.LoadContractState("chain", "contract")
+	require.NoError(t, err)
+	assert.Equal(t, &state, got)
+
+	got, err = store.LoadContractState("other-chain", "contract")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestContractCache_WarmStart(t *testing.T) {
+	ctx := context.Background()
+	lggr := logger.Test(t)
+	cfg := NewConfig(db.ChainCfg{
+		OCR2CacheTTL:         utils.MustNewDuration(time.Minute),
+		OCR2CacheGracePeriod: utils.MustNewDuration(5 * time.Minute),
+	}, lggr)
+	contract := cosmosSDK.AccAddress("contract")
+	store, err := NewFileContractCacheStore(t.TempDir())
+	require.NoError(t, err)
+	newCache := func() *ContractCache {
+		reader := NewOCR2Reader(contract, mocks.NewReaderWriter(t), cfg, lggr)
+		return NewContractCache(cfg, reader, lggr).withStore("chain", store)
+	}
+
+	// Persist fresh values.
+	digest := types.ConfigDigest{1, 2, 3}
+	cc := newCache()
+	config := types.ContractConfig{
+		ConfigDigest:  digest,
+		ConfigCount:   1,
+		Signers:       []types.OnchainPublicKey{{4, 5}},
+		Transmitters:  []types.Account{"transmitter"},
+		F:             1,
+		OnchainConfig: []byte{6},
+	}
+	cc.setConfig(10, config)
+	require.NoError(t, cc.persist(false))
+	cc.setTransmission(digest, 4, 5, big.NewInt(42), time.Unix(90, 0))
+	require.NoError(t, cc.persist(false))
+
+	restored := func(age time.Duration) *ContractCache {
+		state, err := store.LoadContractState("chain", contract.String())
+		require.NoError(t, err)
+		require.NotNil(t, state)
+		state.ConfigUpdatedAt = time.Now().Add(-age)
+		state.TransmissionUpdatedAt = time.Now().Add(-age)
+		require.NoError(t, store.SaveContractState(*state))
+		cc := newCache()
+		require.NoError(t, cc.restore())
+		return cc
+	}
+
+	t.Run("within grace period", func(t *testing.T) {
+		cc := restored(3 * time.Minute)
+		changedInBlock, configDigest, err := cc.LatestConfigDetails(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10), changedInBlock)
+		assert.Equal(t, digest, configDigest)
+		got, err := cc.LatestConfig(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, config, got)
+		_, epoch, round, answer, _, err := cc.LatestTransmissionDetails(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(4), epoch)
+		assert.Equal(t, uint8(5), round)
+		assert.Equal(t, big.NewInt(42), answer)
+
+		// fresh values are not granted the grace period
+		cc.setConfig(11, types.ContractConfig{ConfigDigest: digest, ConfigCount: 2})
+		cc.configMu.Lock()
+		cc.configTS = time.Now().Add(-3 * time.Minute)
+		cc.configMu.Unlock()
+		_, _, err = cc.LatestConfigDetails(ctx)
+		assert.ErrorContains(t, err, "contract cache expired")
+	})
+
+	t.Run("past grace period", func(t *testing.T) {
+		cc := restored(7 * time.Minute)
+		_, _, err := cc.LatestConfigDetails(ctx)
+		assert.ErrorContains(t, err, "contract cache expired")
+		_, _, _, _, _, err = cc.LatestTransmissionDetails(ctx)
+		assert.ErrorContains(t, err, "contract cache expired")
+	})
+
+	t.Run("restored values are not re-persisted as fresh", func(t *testing.T) {
+		cc := restored(3 * time.Minute)
+		before, err := store.LoadContractState("chain", contract.String())
+		require.NoError(t, err)
+		cc.setTransmission(digest, 4, 6, big.NewInt(43), time.Unix(95, 0))
+		require.NoError(t, cc.persist(false))
+		after, err := store.LoadContractState("chain", contract.String())
+		require.NoError(t, err)
+		assert.Equal(t, before.ConfigUpdatedAt, after.ConfigUpdatedAt)
+		assert.Equal(t, uint8(6), after.Round)
+		assert.True(t, after.TransmissionUpdatedAt.After(before.TransmissionUpdatedAt))
+	})
+}
@@ -0,0 +1,50 @@
+package terra
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	cosmosSDK "github.com/cosmos/cosmos-sdk/types"
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client/mocks"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+func TestContractCache_setTransmission(t *testing.T) {
+	ctx := context.Background()
+	lggr := logger.Test(t)
+	cfg := NewConfig(db.ChainCfg{}, lggr)
+	cc := NewContractCache(cfg, NewOCR2Reader(cosmosSDK.AccAddress("contract"), mocks.NewReaderWriter(t), cfg, lggr), lggr)
+	digest := mustStringToConfigDigest(t, "test config digest 32 chars long")
+	requireLatest := func(epoch uint32, round uint8, answer int64) {
+		t.Helper()
+		gotDigest, gotEpoch, gotRound, gotAnswer, _, err := cc.LatestTransmissionDetails(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, digest, gotDigest)
+		assert.Equal(t, epoch, gotEpoch)
+		assert.Equal(t, round, gotRound)
+		assert.Equal(t, big.NewInt(answer), gotAnswer)
+	}
+
+	cc.setTransmission(digest, 4, 5, big.NewInt(42), time.Unix(90, 0))
+	requireLatest(4, 5, 42)
+	// stale, e.g. from a poll racing an event
+	cc.setTransmission(digest, 4, 4, big.NewInt(41), time.Unix(80, 0))
+	cc.setTransmission(digest, 3, 9, big.NewInt(40), time.Unix(70, 0))
+	requireLatest(4, 5, 42)
+	cc.setTransmission(digest, 5, 1, big.NewInt(43), time.Unix(100, 0))
+	requireLatest(5, 1, 43)
+
+	// A new config starts again from epoch 0.
+	newDigest := mustStringToConfigDigest(t, "next config digest 32 chars long")
+	cc.setTransmission(newDigest, 0, 1, big.NewInt(44), time.Unix(110, 0))
+	gotDigest, epoch, _, _, _, err := cc.LatestTransmissionDetails(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, newDigest, gotDigest)
+	assert.Zero(t, epoch)
+}
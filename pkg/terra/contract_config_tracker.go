@@ -13,23 +13,32 @@ var _ types.ContractConfigTracker = (*ContractTracker)(nil)
 type ContractTracker struct {
 	*ContractCache
 	chainReader client.Reader
+	notify      chan struct{}
 }
 
+// NewContractTracker returns a ContractTracker with its own Notify channel, which should be released when the
+// tracker is no longer used.
 func NewContractTracker(chainReader client.Reader, contract *ContractCache) *ContractTracker {
 	return &ContractTracker{
 		ContractCache: contract,
 		chainReader:   chainReader,
+		notify:        contract.addNotify(),
 	}
 }
 
-// Unused, libocr will use polling
+// Notify signals when a new config is detected, either by polling or from a set_config event.
 func (ct *ContractTracker) Notify() <-chan struct{} {
-	return nil
+	return ct.notify
+}
+
+// release stops signalling Notify.
+func (ct *ContractTracker) release() {
+	ct.ContractCache.removeNotify(ct.notify)
 }
 
 // LatestBlockHeight returns the height of the most recent block in the chain.
 func (ct *ContractTracker) LatestBlockHeight(ctx context.Context) (blockHeight uint64, err error) {
-	b, err := ct.chainReader.LatestBlock()
+	b, err := ct.chainReader.LatestBlock(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,44 @@
+package terra
+
+import (
+	"testing"
+
+	cosmosSDK "github.com/cosmos/cosmos-sdk/types"
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/smartcontractkit/libocr/offchainreporting2/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client/mocks"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+func TestContractTracker_Notify(t *testing.T) {
+	lggr := logger.Test(t)
+	cfg := NewConfig(db.ChainCfg{}, lggr)
+	chainReader := mocks.NewReaderWriter(t)
+	cc := NewContractCache(cfg, NewOCR2Reader(cosmosSDK.AccAddress("contract"), chainReader, cfg, lggr), lggr)
+
+	// e.g. bootstrap and oracle jobs for the same contract
+	a, b := NewContractTracker(chainReader, cc), NewContractTracker(chainReader, cc)
+	notified := func(ct *ContractTracker) bool {
+		select {
+		case <-ct.Notify():
+			return true
+		default:
+			return false
+		}
+	}
+
+	cc.setConfig(10, types.ContractConfig{ConfigDigest: mustStringToConfigDigest(t, "test config digest 32 chars long")})
+	assert.True(t, notified(a))
+	assert.True(t, notified(b))
+
+	b.release()
+	cc.setConfig(11, types.ContractConfig{ConfigDigest: mustStringToConfigDigest(t, "next config digest 32 chars long")})
+	assert.True(t, notified(a))
+	assert.False(t, notified(b))
+
+	// unchanged
+	cc.setConfig(11, types.ContractConfig{ConfigDigest: mustStringToConfigDigest(t, "next config digest 32 chars long")})
+	assert.False(t, notified(a))
+}
@@ -8,11 +8,15 @@ import (
 	"math/big"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	cosmosSDK "github.com/cosmos/cosmos-sdk/types"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
 
 	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/smartcontractkit/libocr/offchainreporting2/reportingplugin/median"
 	"github.com/smartcontractkit/libocr/offchainreporting2/types"
 
 	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
@@ -21,37 +25,161 @@ import (
 type OCR2Reader struct {
 	address     cosmosSDK.AccAddress
 	chainReader client.Reader
+	verifier    *client.ProofVerifier // nil unless verified
+	cfg         Config
 	lggr        logger.Logger
+
+	metadataMu      sync.Mutex
+	metadata        *ContractMetadata // nil until fetched
+	metadataErr     error             // from the last failed fetch
+	metadataRetryAt time.Time         // before which metadataErr is returned instead of fetching again
 }
 
-func NewOCR2Reader(addess cosmosSDK.AccAddress, chainReader client.Reader, lggr logger.Logger) *OCR2Reader {
+// metadataRetryPeriod is how long a failure to fetch the contract's metadata is cached for, so that
+// each transmission does not repeat the queries while they are failing.
+const metadataRetryPeriod = time.Minute
+
+func NewOCR2Reader(addess cosmosSDK.AccAddress, chainReader client.Reader, cfg Config, lggr logger.Logger) *OCR2Reader {
 	return &OCR2Reader{
 		address:     addess,
 		chainReader: chainReader,
+		cfg:         cfg,
 		lggr:        lggr,
 	}
 }
 
+// NewVerifiedOCR2Reader returns an OCR2Reader which reads the config digest, epoch and transmissions from the
+// contract's raw state, as verified by verifier, instead of trusting the node's query results.
+// The config itself is read from set_config events, which cannot be proven, so it is only accepted if it hashes
+// to the digest from the event. Callers must check that digest against LatestConfigDetails.
+func NewVerifiedOCR2Reader(addess cosmosSDK.AccAddress, chainReader client.Reader, verifier *client.ProofVerifier, cfg Config, lggr logger.Logger) *OCR2Reader {
+	r := NewOCR2Reader(addess, chainReader, cfg, lggr)
+	r.verifier = verifier
+	return r
+}
+
+// Verified returns true if contract state is verified. See NewVerifiedOCR2Reader.
+func (r *OCR2Reader) Verified() bool {
+	return r.verifier != nil
+}
+
+// verifiedConfig reads the contract's stored config as of height, or the latest verifiable height if height is 0,
+// which is returned along with it.
+func (r *OCR2Reader) verifiedConfig(ctx context.Context, height int64) (config RawConfig, _ int64, err error) {
+	b, height, err := r.verifier.ContractRawStore(ctx, r.address, rawConfigKey, height)
+	if err != nil {
+		return
+	}
+	if b == nil {
+		err = fmt.Errorf("no config stored by contract %s at height %d", r.address, height)
+		return
+	}
+	err = json.Unmarshal(b, &config)
+	return config, height, err
+}
+
+// contractStore queries the contract's state as of height, or the latest state if height is 0.
+func (r *OCR2Reader) contractStore(ctx context.Context, queryMsg []byte, height int64) ([]byte, error) {
+	if height == 0 {
+		return r.chainReader.ContractStore(ctx, r.address, queryMsg)
+	}
+	return r.chainReader.ContractStoreAtHeight(ctx, r.address, queryMsg, height)
+}
+
 func (r *OCR2Reader) LatestConfigDetails(ctx context.Context) (changedInBlock uint64, configDigest types.ConfigDigest, err error) {
-	resp, err := r.chainReader.ContractStore(
-		r.address,
-		[]byte(`"latest_config_details"`),
-	)
+	return r.latestConfigDetails(ctx, 0)
+}
+
+func (r *OCR2Reader) latestConfigDetails(ctx context.Context, height int64) (changedInBlock uint64, configDigest types.ConfigDigest, err error) {
+	if r.verifier != nil {
+		var config RawConfig
+		config, _, err = r.verifiedConfig(ctx, height)
+		return config.LatestConfigBlockNumber, config.LatestConfigDigest, err
+	}
+	resp, err := r.contractStore(ctx, queryLatestConfigDetails, height)
 	if err != nil {
 		return
 	}
+	return parseConfigDetails(resp)
+}
+
+// Metadata returns the contract's metadata, which is fetched on the first successful call and cached thereafter.
+// Failures are cached for metadataRetryPeriod.
+// The answer bounds are decoded from the onchain config of the latest config, so the contract must be configured.
+// Unlike other state, the decimals and description are never verified.
+func (r *OCR2Reader) Metadata(ctx context.Context) (ContractMetadata, error) {
+	r.metadataMu.Lock()
+	defer r.metadataMu.Unlock()
+	if r.metadata != nil {
+		return *r.metadata, nil
+	}
+	if r.metadataErr != nil && time.Now().Before(r.metadataRetryAt) {
+		return ContractMetadata{}, r.metadataErr
+	}
+	metadata, err := r.fetchMetadata(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.metadataErr, r.metadataRetryAt = err, time.Now().Add(metadataRetryPeriod)
+		}
+		return ContractMetadata{}, err
+	}
+	r.metadata, r.metadataErr = &metadata, nil
+	return metadata, nil
+}
+
+func (r *OCR2Reader) fetchMetadata(ctx context.Context) (ContractMetadata, error) {
+	var metadata ContractMetadata
+	resp, err := r.contractStore(ctx, []byte(`"decimals"`), 0)
+	if err != nil {
+		return ContractMetadata{}, fmt.Errorf("fetch decimals: %w", err)
+	}
+	if err = json.Unmarshal(resp, &metadata.Decimals); err != nil {
+		return ContractMetadata{}, fmt.Errorf("parse decimals: %w", err)
+	}
+	resp, err = r.contractStore(ctx, []byte(`"description"`), 0)
+	if err != nil {
+		return ContractMetadata{}, fmt.Errorf("fetch description: %w", err)
+	}
+	if err = json.Unmarshal(resp, &metadata.Description); err != nil {
+		return ContractMetadata{}, fmt.Errorf("parse description: %w", err)
+	}
+	changedInBlock, _, err := r.LatestConfigDetails(ctx)
+	if err != nil {
+		return ContractMetadata{}, fmt.Errorf("fetch latest config details: %w", err)
+	}
+	if changedInBlock == 0 {
+		return ContractMetadata{}, fmt.Errorf("contract %s has not been configured", r.address)
+	}
+	config, err := r.LatestConfig(ctx, changedInBlock)
+	if err != nil {
+		return ContractMetadata{}, fmt.Errorf("fetch latest config, block %d: %w", changedInBlock, err)
+	}
+	onchainConfig, err := median.DecodeOnchainConfig(config.OnchainConfig)
+	if err != nil {
+		return ContractMetadata{}, fmt.Errorf("decode onchain config: %w", err)
+	}
+	metadata.MinAnswer, metadata.MaxAnswer = onchainConfig.Min, onchainConfig.Max
+	return metadata, nil
+}
+
+// Queries which are polled by ContractCache.
+var (
+	queryLatestConfigDetails       = []byte(`"latest_config_details"`)
+	queryLatestTransmissionDetails = []byte(`"latest_transmission_details"`)
+)
+
+// parseConfigDetails parses the response to a latest_config_details query.
+func parseConfigDetails(resp []byte) (changedInBlock uint64, configDigest types.ConfigDigest, err error) {
 	var config ConfigDetails
 	if err = json.Unmarshal(resp, &config); err != nil {
 		return
 	}
-	changedInBlock = config.BlockNumber
-	configDigest = config.ConfigDigest
-	return
+	return config.BlockNumber, config.ConfigDigest, nil
 }
 
 func (r *OCR2Reader) LatestConfig(ctx context.Context, changedInBlock uint64) (types.ContractConfig, error) {
 	query := []string{fmt.Sprintf("tx.height=%d", changedInBlock), fmt.Sprintf("wasm-set_config.contract_address='%s'", r.address)}
-	res, err := r.chainReader.TxsEvents(query, nil)
+	res, err := r.chainReader.TxsEvents(ctx, query, nil)
 	if err != nil {
 		return types.ContractConfig{}, err
 	}
@@ -72,12 +200,91 @@ func (r *OCR2Reader) LatestConfig(ctx context.Context, changedInBlock uint64) (t
 			if len(unknown) > 0 {
 				r.lggr.Warnf("wasm-set_config event contained unrecognized attributes: %v", unknown)
 			}
+			if err == nil && r.verifier != nil {
+				err = r.checkConfigDigest(cc)
+			}
 			return cc, err
 		}
 	}
 	return types.ContractConfig{}, fmt.Errorf("No set_config event found for tx %s", res.TxResponses[0].TxHash)
 }
 
+// LatestConfigAtHeight returns the latest config as of height, and the block in which it was set.
+// Returns client.ErrHeightUnavailable if no node has the state at height.
+func (r *OCR2Reader) LatestConfigAtHeight(ctx context.Context, height int64) (changedInBlock uint64, config types.ContractConfig, err error) {
+	if height <= 0 {
+		err = fmt.Errorf("invalid height %d", height)
+		return
+	}
+	changedInBlock, _, err = r.latestConfigDetails(ctx, height)
+	if err != nil {
+		return
+	}
+	if changedInBlock == 0 {
+		return // never configured
+	}
+	config, err = r.LatestConfig(ctx, changedInBlock)
+	return
+}
+
+// checkConfigDigest returns an error unless config hashes to its own digest.
+func (r *OCR2Reader) checkConfigDigest(config types.ContractConfig) error {
+	digest, err := NewOffchainConfigDigester(r.verifier.ChainID(), r.address).ConfigDigest(config)
+	if err != nil {
+		return err
+	}
+	if digest != config.ConfigDigest {
+		return fmt.Errorf("config has digest %s, but hashes to %s", config.ConfigDigest, digest)
+	}
+	return nil
+}
+
+// SubscribeEvents subscribes to the contract's set_config and new_transmission events, as well as to new block
+// headers, which serve as a heartbeat for the subscription.
+func (r *OCR2Reader) SubscribeEvents(ctx context.Context) (<-chan ctypes.ResultEvent, error) {
+	return r.chainReader.SubscribeEvents(ctx,
+		fmt.Sprintf("tm.event='Tx' AND wasm-set_config.contract_address='%s'", r.address),
+		fmt.Sprintf("tm.event='Tx' AND wasm-new_transmission.contract_address='%s'", r.address),
+		"tm.event='NewBlockHeader'",
+	)
+}
+
+// contractEvents returns the attributes of each event of type typ which was emitted by the contract in tx.
+func (r *OCR2Reader) contractEvents(tx tmtypes.EventDataTx, typ string) (events [][]cosmosSDK.Attribute) {
+	for _, event := range tx.Result.Events {
+		if event.Type != typ {
+			continue
+		}
+		attrs := make([]cosmosSDK.Attribute, len(event.Attributes))
+		for i, attr := range event.Attributes {
+			attrs[i] = cosmosSDK.NewAttribute(string(attr.Key), string(attr.Value))
+		}
+		if hasContractAddress(attrs, r.address) {
+			events = append(events, attrs)
+		}
+	}
+	return
+}
+
+// ConfigFromTx returns the config from the last set_config event emitted by the contract in tx.
+// found is false if there is no such event.
+func (r *OCR2Reader) ConfigFromTx(tx tmtypes.EventDataTx) (changedInBlock uint64, config types.ContractConfig, found bool, err error) {
+	events := r.contractEvents(tx, "wasm-set_config")
+	if len(events) == 0 {
+		return
+	}
+	config, unknown, err := parseAttributes(events[len(events)-1])
+	if len(unknown) > 0 {
+		r.lggr.Warnf("wasm-set_config event contained unrecognized attributes: %v", unknown)
+	}
+	return uint64(tx.Height), config, true, err
+}
+
+// HasTransmission returns true if the contract emitted a new_transmission event in tx.
+func (r *OCR2Reader) HasTransmission(tx tmtypes.EventDataTx) bool {
+	return len(r.contractEvents(tx, "wasm-new_transmission")) > 0
+}
+
 // parseAttributes returns a ContractConfig parsed from attrs.
 // An error will be returned if any of the 8 required attributes are not present, or if any duplicates are found for
 // unique attributes.
@@ -226,14 +433,41 @@ func (r *OCR2Reader) LatestTransmissionDetails(ctx context.Context) (
 	latestTimestamp time.Time,
 	err error,
 ) {
-	resp, err := r.chainReader.ContractStore(r.address, []byte(`"latest_transmission_details"`))
+	return r.latestTransmissionDetails(ctx, 0)
+}
+
+// LatestTransmissionDetailsAtHeight is like LatestTransmissionDetails, but as of height.
+// Returns client.ErrHeightUnavailable if no node has the state at height.
+func (r *OCR2Reader) LatestTransmissionDetailsAtHeight(ctx context.Context, height int64) (
+	configDigest types.ConfigDigest,
+	epoch uint32,
+	round uint8,
+	latestAnswer *big.Int,
+	latestTimestamp time.Time,
+	err error,
+) {
+	if height <= 0 {
+		return types.ConfigDigest{}, 0, 0, big.NewInt(0), time.Now(), fmt.Errorf("invalid height %d", height)
+	}
+	return r.latestTransmissionDetails(ctx, height)
+}
+
+func (r *OCR2Reader) latestTransmissionDetails(ctx context.Context, height int64) (
+	configDigest types.ConfigDigest,
+	epoch uint32,
+	round uint8,
+	latestAnswer *big.Int,
+	latestTimestamp time.Time,
+	err error,
+) {
+	if r.verifier != nil {
+		return r.verifiedTransmissionDetails(ctx, height)
+	}
+	resp, err := r.contractStore(ctx, queryLatestTransmissionDetails, height)
 	if err != nil {
-		// Handle the 500 error that occurs when there has not been a submission
-		// "rpc error: code = Unknown desc = ocr2::state::Transmission not found: contract query failed: unknown request"
-		// which is thrown if this map lookup fails https://github.com/smartcontractkit/chainlink-terra/blob/main/contracts/ocr2/src/contract.rs#L759
-		if strings.Contains(fmt.Sprint(err), "ocr2::state::Transmission not found") {
+		if isTransmissionNotFound(err) {
 			r.lggr.Infof("No transmissions found when fetching `latest_transmission_details` attempting with `latest_config_digest_and_epoch`")
-			digest, epoch, err2 := r.LatestConfigDigestAndEpoch(ctx)
+			digest, epoch, err2 := r.latestConfigDigestAndEpoch(ctx, height)
 
 			// In the case that there have been no transmissions, we expect the epoch to be zero.
 			// We return just the contract digest here and set the rest of the
@@ -250,7 +484,26 @@ func (r *OCR2Reader) LatestTransmissionDetails(ctx context.Context) (
 		// default response if there actually is an error
 		return types.ConfigDigest{}, 0, 0, big.NewInt(0), time.Now(), err
 	}
+	return parseTransmissionDetails(resp)
+}
 
+// isTransmissionNotFound returns true if err is from a latest_transmission_details query before any transmissions.
+func isTransmissionNotFound(err error) bool {
+	// Handle the 500 error that occurs when there has not been a submission
+	// "rpc error: code = Unknown desc = ocr2::state::Transmission not found: contract query failed: unknown request"
+	// which is thrown if this map lookup fails https://github.com/smartcontractkit/chainlink-terra/blob/main/contracts/ocr2/src/contract.rs#L759
+	return strings.Contains(fmt.Sprint(err), "ocr2::state::Transmission not found")
+}
+
+// parseTransmissionDetails parses the response to a latest_transmission_details query.
+func parseTransmissionDetails(resp []byte) (
+	configDigest types.ConfigDigest,
+	epoch uint32,
+	round uint8,
+	latestAnswer *big.Int,
+	latestTimestamp time.Time,
+	err error,
+) {
 	// unmarshal
 	var details LatestTransmissionDetails
 	if err := json.Unmarshal(resp, &details); err != nil {
@@ -266,68 +519,158 @@ func (r *OCR2Reader) LatestTransmissionDetails(ctx context.Context) (
 	return details.LatestConfigDigest, details.Epoch, details.Round, ans, time.Unix(details.LatestTimestamp, 0), nil
 }
 
-// LatestRoundRequested fetches the latest round requested by filtering event logs
-//func (cc *OCR2Reader) LatestRoundRequested(ctx context.Context, lookback time.Duration) (
-//	configDigest types.ConfigDigest,
-//	epoch uint32,
-//	round uint8,
-//	err error,
-//) {
-//	// calculate start block
-//	latestBlock, blkErr := cc.chainReader.LatestBlock()
-//	if blkErr != nil {
-//		err = blkErr
-//		return
-//	}
-//	blockNum := uint64(latestBlock.Block.Header.Height) - uint64(lookback/cc.cfg.BlockRate())
-//	res, err := cc.chainReader.TxsEvents([]string{fmt.Sprintf("tx.height>=%d", blockNum+1), fmt.Sprintf("wasm-new_round.contract_address='%s'", cc.address.String())}, nil)
-//	if err != nil {
-//		return
-//	}
-//	if len(res.TxResponses) == 0 {
-//		return
-//	}
-//	if len(res.TxResponses[0].Logs) == 0 {
-//		err = fmt.Errorf("No logs found for tx %s", res.TxResponses[0].TxHash)
-//		return
-//	}
-//	// First tx is the latest.
-//	if len(res.TxResponses[0].Logs[0].Events) == 0 {
-//		err = fmt.Errorf("No events found for tx %s", res.TxResponses[0].TxHash)
-//		return
-//	}
-//
-//	for _, event := range res.TxResponses[0].Logs[0].Events {
-//		if event.Type == "wasm-new_round" {
-//			// TODO: confirm event parameters
-//			// https://github.com/smartcontractkit/chainlink-terra/issues/22
-//			for _, attr := range event.Attributes {
-//				key, value := string(attr.Key), string(attr.Value)
-//				switch key {
-//				case "latest_config_digest":
-//					// parse byte array encoded as hex string
-//					if err := HexToConfigDigest(value, &configDigest); err != nil {
-//						return configDigest, epoch, round, err
-//					}
-//				case "epoch":
-//					epochU64, err := strconv.ParseUint(value, 10, 32)
-//					if err != nil {
-//						return configDigest, epoch, round, err
-//					}
-//					epoch = uint32(epochU64)
-//				case "round":
-//					roundU64, err := strconv.ParseUint(value, 10, 8)
-//					if err != nil {
-//						return configDigest, epoch, round, err
-//					}
-//					round = uint8(roundU64)
-//				}
-//			}
-//			return // exit once all parameters are processed
-//		}
-//	}
-//	return
-//}
+func (r *OCR2Reader) verifiedTransmissionDetails(ctx context.Context, height int64) (
+	configDigest types.ConfigDigest,
+	epoch uint32,
+	round uint8,
+	latestAnswer *big.Int,
+	latestTimestamp time.Time,
+	err error,
+) {
+	config, height, err := r.verifiedConfig(ctx, height)
+	if err != nil {
+		return types.ConfigDigest{}, 0, 0, big.NewInt(0), time.Now(), err
+	}
+	if config.LatestAggregatorRoundID == 0 {
+		// No transmissions yet, so like LatestTransmissionDetails we return only the digest and epoch.
+		return config.LatestConfigDigest, config.Epoch, 0, big.NewInt(0), time.Unix(0, 0), nil
+	}
+	b, _, err := r.verifier.ContractRawStore(ctx, r.address, rawTransmissionKey(config.LatestAggregatorRoundID), height)
+	if err != nil {
+		return types.ConfigDigest{}, 0, 0, big.NewInt(0), time.Now(), err
+	}
+	if b == nil {
+		return types.ConfigDigest{}, 0, 0, big.NewInt(0), time.Now(),
+			fmt.Errorf("no transmission stored for round %d at height %d", config.LatestAggregatorRoundID, height)
+	}
+	var transmission RawTransmission
+	if err := json.Unmarshal(b, &transmission); err != nil {
+		return types.ConfigDigest{}, 0, 0, big.NewInt(0), time.Now(), err
+	}
+	ans := new(big.Int)
+	if _, success := ans.SetString(transmission.Answer, 10); !success {
+		return types.ConfigDigest{}, 0, 0, big.NewInt(0), time.Now(), fmt.Errorf("Could not create *big.Int from %s", transmission.Answer)
+	}
+	return config.LatestConfigDigest, config.Epoch, config.Round, ans, time.Unix(transmission.TransmissionTimestamp, 0), nil
+}
+
+// LatestRoundRequested fetches the latest round requested by searching the round_requested events
+// emitted in blocks within lookback of the latest block.
+// Zero values are returned if no event is found.
+func (r *OCR2Reader) LatestRoundRequested(ctx context.Context, lookback time.Duration) (
+	configDigest types.ConfigDigest,
+	epoch uint32,
+	round uint8,
+	err error,
+) {
+	latestBlock, err := r.chainReader.LatestBlock(ctx)
+	if err != nil {
+		return
+	}
+	startBlock := latestBlock.Block.Header.Height - int64(lookback/r.cfg.BlockRate()) + 1
+	if startBlock < 1 {
+		startBlock = 1
+	}
+	query := []string{fmt.Sprintf("tx.height>=%d", startBlock), fmt.Sprintf("wasm-round_requested.contract_address='%s'", r.address)}
+	res, err := r.chainReader.TxsEvents(ctx, query, nil)
+	if err != nil {
+		return
+	}
+	if len(res.TxResponses) == 0 {
+		return
+	}
+	// Use the first tx, which is the most recent.
+	tx := res.TxResponses[0]
+	if len(tx.Logs) == 0 {
+		err = fmt.Errorf("No logs found for tx %s, query %v", tx.TxHash, query)
+		return
+	}
+	// A tx may request several rounds, so use the last matching event.
+	var found bool
+	for _, log := range tx.Logs {
+		for _, event := range log.Events {
+			if event.Type != "wasm-round_requested" || !hasContractAddress(event.Attributes, r.address) {
+				continue
+			}
+			configDigest, epoch, round, err = parseRoundRequestedAttributes(event.Attributes)
+			if err != nil {
+				return
+			}
+			found = true
+		}
+	}
+	if !found {
+		err = fmt.Errorf("No round_requested event found for tx %s, query %v", tx.TxHash, query)
+	}
+	return
+}
+
+func hasContractAddress(attrs []cosmosSDK.Attribute, address cosmosSDK.AccAddress) bool {
+	for _, attr := range attrs {
+		if attr.Key == "contract_address" && attr.Value == address.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRoundRequestedAttributes returns the config digest, epoch and round from the attributes of a round_requested event.
+// An error will be returned if any are missing or duplicated.
+func parseRoundRequestedAttributes(attrs []cosmosSDK.Attribute) (configDigest types.ConfigDigest, epoch uint32, round uint8, err error) {
+	const uniqueKeys = 3
+	known := make(map[string]struct{}, uniqueKeys)
+	first := func(key string) bool {
+		_, ok := known[key]
+		if ok {
+			return false
+		}
+		known[key] = struct{}{}
+		return true
+	}
+	for _, attr := range attrs {
+		key, value := attr.Key, attr.Value
+		switch key {
+		case "config_digest":
+			if !first(key) {
+				err = ErrAttrDupe(key)
+				return
+			}
+			// parse byte array encoded as hex string
+			if err = HexToConfigDigest(value, &configDigest); err != nil {
+				err = &ErrAttrInvalid{Err: err, Key: key}
+				return
+			}
+		case "epoch":
+			if !first(key) {
+				err = ErrAttrDupe(key)
+				return
+			}
+			var i uint64
+			i, err = strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				err = &ErrAttrInvalid{Err: err, Key: key}
+				return
+			}
+			epoch = uint32(i)
+		case "round":
+			if !first(key) {
+				err = ErrAttrDupe(key)
+				return
+			}
+			var i uint64
+			i, err = strconv.ParseUint(value, 10, 8)
+			if err != nil {
+				err = &ErrAttrInvalid{Err: err, Key: key}
+				return
+			}
+			round = uint8(i)
+		}
+	}
+	if len(known) != uniqueKeys {
+		err = fmt.Errorf("expected %d types of known keys, but found %d: %v", uniqueKeys, len(known), known)
+	}
+	return
+}
 
 // LatestConfigDigestAndEpoch fetches the latest details from address state
 func (r *OCR2Reader) LatestConfigDigestAndEpoch(ctx context.Context) (
@@ -335,9 +678,19 @@ func (r *OCR2Reader) LatestConfigDigestAndEpoch(ctx context.Context) (
 	epoch uint32,
 	err error,
 ) {
-	resp, err := r.chainReader.ContractStore(
-		r.address, []byte(`"latest_config_digest_and_epoch"`),
-	)
+	return r.latestConfigDigestAndEpoch(ctx, 0)
+}
+
+func (r *OCR2Reader) latestConfigDigestAndEpoch(ctx context.Context, height int64) (
+	configDigest types.ConfigDigest,
+	epoch uint32,
+	err error,
+) {
+	if r.verifier != nil {
+		config, _, err := r.verifiedConfig(ctx, height)
+		return config.LatestConfigDigest, config.Epoch, err
+	}
+	resp, err := r.contractStore(ctx, []byte(`"latest_config_digest_and_epoch"`), height)
 	if err != nil {
 		return types.ConfigDigest{}, 0, err
 	}
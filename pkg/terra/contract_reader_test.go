@@ -2,15 +2,42 @@ package terra
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	tmtypes "github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"github.com/cosmos/cosmos-sdk/store/prefix"
+	"github.com/cosmos/cosmos-sdk/store/rootmulti"
 	cosmosSDK "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/smartcontractkit/chainlink-relay/pkg/utils"
+	"github.com/smartcontractkit/libocr/offchainreporting2/reportingplugin/median"
 	"github.com/smartcontractkit/libocr/offchainreporting2/types"
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
+	tmcore "github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+	dbm "github.com/tendermint/tm-db"
+	wasmtypes "github.com/terra-money/core/x/wasm/types"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client/mocks"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
 )
 
 func Test_parseAttributes(t *testing.T) {
@@ -90,6 +117,364 @@ func Test_parseAttributes(t *testing.T) {
 	}
 }
 
+func Test_parseRoundRequestedAttributes(t *testing.T) {
+	valid := []cosmosSDK.Attribute{
+		{Key: "contract_address", Value: "terra1"},
+		{Key: "requester", Value: "terra2"},
+		{Key: "config_digest", Value: "7465737420636f6e66696720646967657374203332206368617273206c6f6e67"},
+		{Key: "round", Value: "3"},
+		{Key: "epoch", Value: "42"},
+	}
+	for _, tt := range []struct {
+		name      string
+		attrs     []cosmosSDK.Attribute
+		expErrIs  error
+		expErrStr string
+	}{
+		{name: "valid", attrs: valid},
+		{name: "missing", attrs: valid[:3], expErrStr: "expected 3 types of known keys"},
+		{name: "dupe", attrs: append(valid, cosmosSDK.Attribute{Key: "epoch", Value: "43"}), expErrIs: ErrAttrDupe("epoch")},
+		{name: "round-overflow", attrs: []cosmosSDK.Attribute{{Key: "round", Value: "256"}}, expErrIs: strconv.ErrRange},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			digest, epoch, round, err := parseRoundRequestedAttributes(tt.attrs)
+			if tt.expErrIs != nil {
+				require.ErrorIs(t, err, tt.expErrIs)
+				return
+			} else if tt.expErrStr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.expErrStr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, mustStringToConfigDigest(t, "test config digest 32 chars long"), digest)
+			assert.Equal(t, uint32(42), epoch)
+			assert.Equal(t, uint8(3), round)
+		})
+	}
+}
+
+func TestOCR2Reader_LatestRoundRequested(t *testing.T) {
+	ctx := context.Background()
+	lggr := logger.Test(t)
+	contract := cosmosSDK.AccAddress("contract")
+	other := cosmosSDK.AccAddress("other")
+	cfg := NewConfig(db.ChainCfg{BlockRate: utils.MustNewDuration(time.Second)}, lggr)
+	event := func(addr cosmosSDK.AccAddress, epoch string) cosmosSDK.StringEvent {
+		return cosmosSDK.StringEvent{Type: "wasm-round_requested", Attributes: []cosmosSDK.Attribute{
+			{Key: "contract_address", Value: addr.String()},
+			{Key: "config_digest", Value: "7465737420636f6e66696720646967657374203332206368617273206c6f6e67"},
+			{Key: "round", Value: "1"},
+			{Key: "epoch", Value: epoch},
+		}}
+	}
+
+	chainReader := mocks.NewReaderWriter(t)
+	chainReader.On("LatestBlock", mock.Anything).Return(&tmtypes.GetLatestBlockResponse{
+		Block: &tmproto.Block{Header: tmproto.Header{Height: 100}},
+	}, nil)
+	events := []string{"tx.height>=91", fmt.Sprintf("wasm-round_requested.contract_address='%s'", contract)}
+	chainReader.On("TxsEvents", mock.Anything, events, (*query.PageRequest)(nil)).Return(&txtypes.GetTxsEventResponse{
+		TxResponses: []*cosmosSDK.TxResponse{{Logs: cosmosSDK.ABCIMessageLogs{
+			{Events: cosmosSDK.StringEvents{event(contract, "5"), event(other, "7")}},
+			{Events: cosmosSDK.StringEvents{event(contract, "6")}},
+		}}},
+	}, nil).Once()
+	chainReader.On("TxsEvents", mock.Anything, events, (*query.PageRequest)(nil)).Return(&txtypes.GetTxsEventResponse{}, nil).Once()
+
+	reader := NewOCR2Reader(contract, chainReader, cfg, lggr)
+	digest, epoch, round, err := reader.LatestRoundRequested(ctx, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, mustStringToConfigDigest(t, "test config digest 32 chars long"), digest)
+	assert.Equal(t, uint32(6), epoch)
+	assert.Equal(t, uint8(1), round)
+
+	// no events is not an error
+	digest, epoch, round, err = reader.LatestRoundRequested(ctx, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, types.ConfigDigest{}, digest)
+	assert.Zero(t, epoch)
+	assert.Zero(t, round)
+}
+
+func TestOCR2Reader_AtHeight(t *testing.T) {
+	ctx := context.Background()
+	lggr := logger.Test(t)
+	contract := cosmosSDK.AccAddress("contract")
+	digest := mustStringToConfigDigest(t, "test config digest 32 chars long")
+	// The contract returns digests as byte arrays.
+	digestJSON := make([]int, len(digest))
+	for i := range digest {
+		digestJSON[i] = int(digest[i])
+	}
+	mustMarshal := func(v map[string]interface{}) []byte {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return b
+	}
+
+	chainReader := mocks.NewReaderWriter(t)
+	chainReader.On("ContractStoreAtHeight", mock.Anything, contract, []byte(`"latest_config_details"`), int64(100)).
+		Return(mustMarshal(map[string]interface{}{"block_number": 90, "config_digest": digestJSON}), nil).Once()
+	events := []string{"tx.height=90", fmt.Sprintf("wasm-set_config.contract_address='%s'", contract)}
+	chainReader.On("TxsEvents", mock.Anything, events, (*query.PageRequest)(nil)).Return(&txtypes.GetTxsEventResponse{
+		TxResponses: []*cosmosSDK.TxResponse{{Logs: cosmosSDK.ABCIMessageLogs{{Events: cosmosSDK.StringEvents{{
+			Type: "wasm-set_config", Attributes: []cosmosSDK.Attribute{
+				{Key: "config_count", Value: "3"},
+				{Key: "f", Value: "1"},
+				{Key: "latest_config_digest", Value: hex.EncodeToString(digest[:])},
+				{Key: "offchain_config", Value: "AwQ="},
+				{Key: "offchain_config_version", Value: "2"},
+				{Key: "onchain_config", Value: "AQI="},
+				{Key: "signers", Value: "0101010101010101010101010101010101010101010101010101010101010101"},
+				{Key: "transmitters", Value: "account1"},
+			}},
+		}}}}},
+	}, nil).Once()
+	chainReader.On("ContractStoreAtHeight", mock.Anything, contract, []byte(`"latest_transmission_details"`), int64(100)).
+		Return(mustMarshal(map[string]interface{}{
+			"latest_config_digest": digestJSON, "epoch": 4, "round": 2, "latest_answer": "42", "latest_timestamp": 1000,
+		}), nil).Once()
+	// Before any transmissions.
+	chainReader.On("ContractStoreAtHeight", mock.Anything, contract, []byte(`"latest_transmission_details"`), int64(95)).
+		Return(nil, errors.New("rpc error: code = Unknown desc = ocr2::state::Transmission not found: contract query failed: unknown request")).Once()
+	chainReader.On("ContractStoreAtHeight", mock.Anything, contract, []byte(`"latest_config_digest_and_epoch"`), int64(95)).
+		Return(mustMarshal(map[string]interface{}{"config_digest": digestJSON, "epoch": 0}), nil).Once()
+	pruned := &client.ErrHeightUnavailable{ABCIError: &client.ABCIError{Codespace: "sdk", Code: 18}, Height: 5, Latest: 100}
+	chainReader.On("ContractStoreAtHeight", mock.Anything, contract, []byte(`"latest_config_details"`), int64(5)).
+		Return(nil, pruned).Once()
+
+	reader := NewOCR2Reader(contract, chainReader, nil, lggr)
+	changedInBlock, config, err := reader.LatestConfigAtHeight(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(90), changedInBlock)
+	assert.Equal(t, uint64(3), config.ConfigCount)
+	assert.Equal(t, digest, config.ConfigDigest)
+
+	gotDigest, epoch, round, answer, timestamp, err := reader.LatestTransmissionDetailsAtHeight(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, digest, gotDigest)
+	assert.Equal(t, uint32(4), epoch)
+	assert.Equal(t, uint8(2), round)
+	assert.Equal(t, big.NewInt(42), answer)
+	assert.Equal(t, time.Unix(1000, 0), timestamp)
+
+	gotDigest, epoch, _, answer, _, err = reader.LatestTransmissionDetailsAtHeight(ctx, 95)
+	require.NoError(t, err)
+	assert.Equal(t, digest, gotDigest)
+	assert.Zero(t, epoch)
+	assert.Equal(t, big.NewInt(0), answer)
+
+	_, _, err = reader.LatestConfigAtHeight(ctx, 5)
+	var unavailable *client.ErrHeightUnavailable
+	require.ErrorAs(t, err, &unavailable)
+	assert.Equal(t, int64(5), unavailable.Height)
+
+	_, _, err = reader.LatestConfigAtHeight(ctx, 0)
+	require.Error(t, err)
+}
+
+func TestOCR2Reader_Metadata(t *testing.T) {
+	ctx := context.Background()
+	lggr := logger.Test(t)
+	contract := cosmosSDK.AccAddress("contract")
+	onchainConfig, err := median.OnchainConfig{Min: big.NewInt(-10), Max: big.NewInt(1_000_000)}.Encode()
+	require.NoError(t, err)
+
+	chainReader := mocks.NewReaderWriter(t)
+	chainReader.On("ContractStore", mock.Anything, contract, []byte(`"decimals"`)).Return([]byte(`8`), nil).Once()
+	chainReader.On("ContractStore", mock.Anything, contract, []byte(`"description"`)).Return([]byte(`"LUNA/USD"`), nil).Once()
+	chainReader.On("ContractStore", mock.Anything, contract, []byte(`"latest_config_details"`)).
+		Return([]byte(`{"block_number": 0, "config_digest": []}`), nil).Once()
+	reader := NewOCR2Reader(contract, chainReader, NewConfig(db.ChainCfg{}, lggr), lggr)
+	_, err = reader.Metadata(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has not been configured")
+	// The failure is cached, without querying again.
+	_, err = reader.Metadata(ctx)
+	require.ErrorContains(t, err, "has not been configured")
+	reader.metadataRetryAt = time.Now()
+
+	chainReader.On("ContractStore", mock.Anything, contract, []byte(`"decimals"`)).Return([]byte(`8`), nil).Once()
+	chainReader.On("ContractStore", mock.Anything, contract, []byte(`"description"`)).Return([]byte(`"LUNA/USD"`), nil).Once()
+	chainReader.On("ContractStore", mock.Anything, contract, []byte(`"latest_config_details"`)).
+		Return([]byte(`{"block_number": 90, "config_digest": []}`), nil).Once()
+	events := []string{"tx.height=90", fmt.Sprintf("wasm-set_config.contract_address='%s'", contract)}
+	chainReader.On("TxsEvents", mock.Anything, events, (*query.PageRequest)(nil)).Return(&txtypes.GetTxsEventResponse{
+		TxResponses: []*cosmosSDK.TxResponse{{Logs: cosmosSDK.ABCIMessageLogs{{Events: cosmosSDK.StringEvents{{
+			Type: "wasm-set_config", Attributes: []cosmosSDK.Attribute{
+				{Key: "config_count", Value: "1"},
+				{Key: "f", Value: "1"},
+				{Key: "latest_config_digest", Value: "7465737420636f6e66696720646967657374203332206368617273206c6f6e67"},
+				{Key: "offchain_config", Value: "AwQ="},
+				{Key: "offchain_config_version", Value: "2"},
+				{Key: "onchain_config", Value: base64.StdEncoding.EncodeToString(onchainConfig)},
+				{Key: "signers", Value: "0101010101010101010101010101010101010101010101010101010101010101"},
+				{Key: "transmitters", Value: "account1"},
+			}},
+		}}}}},
+	}, nil).Once()
+	expected := ContractMetadata{MinAnswer: big.NewInt(-10), MaxAnswer: big.NewInt(1_000_000), Decimals: 8, Description: "LUNA/USD"}
+	metadata, err := reader.Metadata(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, metadata)
+
+	// cached
+	metadata, err = reader.Metadata(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, metadata)
+}
+
+func TestOCR2Reader_Verified(t *testing.T) {
+	const chainID = "verified-test"
+	ctx := context.Background()
+	lggr := logger.Test(t)
+	contract := cosmosSDK.AccAddress("contract")
+	digest := mustStringToConfigDigest(t, "test config digest 32 chars long")
+	digestJSON := make([]int, len(digest))
+	for i := range digest {
+		digestJSON[i] = int(digest[i])
+	}
+	mustMarshal := func(v map[string]interface{}) []byte {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return b
+	}
+
+	// Commit the contract's state, which is then proven against the app hash of block 2.
+	storeKey := cosmosSDK.NewKVStoreKey(wasmtypes.StoreKey)
+	store := rootmulti.NewStore(dbm.NewMemDB())
+	store.MountStoreWithDB(storeKey, cosmosSDK.StoreTypeIAVL, nil)
+	require.NoError(t, store.LoadLatestVersion())
+	kv := prefix.NewStore(store.GetKVStore(storeKey), wasmtypes.GetContractStoreKey(contract))
+	kv.Set(rawConfigKey, mustMarshal(map[string]interface{}{
+		"latest_config_digest": digestJSON, "latest_config_block_number": 90, "latest_aggregator_round_id": 3,
+		"epoch": 4, "round": 2, "description": "ignored",
+	}))
+	kv.Set(rawTransmissionKey(3), mustMarshal(map[string]interface{}{
+		"answer": "-42", "observations_timestamp": 999, "transmission_timestamp": 1000,
+	}))
+	commitID := store.Commit()
+
+	vals, privs := tmcore.RandValidatorSet(4, 10)
+	lightBlock := func(height int64, appHash []byte) *tmcore.LightBlock {
+		header := &tmcore.Header{
+			Version:            tmversion.Consensus{Block: version.BlockProtocol},
+			ChainID:            chainID,
+			Height:             height,
+			Time:               time.Now(),
+			AppHash:            appHash,
+			ValidatorsHash:     vals.Hash(),
+			NextValidatorsHash: vals.Hash(),
+			ProposerAddress:    vals.Proposer.Address,
+		}
+		blockID := tmcore.BlockID{Hash: header.Hash(), PartSetHeader: tmcore.PartSetHeader{Total: 1, Hash: make([]byte, 32)}}
+		voteSet := tmcore.NewVoteSet(chainID, height, 0, tmproto.PrecommitType, vals)
+		commit, err := tmcore.MakeCommit(blockID, height, 0, voteSet, privs, time.Now())
+		require.NoError(t, err)
+		return &tmcore.LightBlock{SignedHeader: &tmcore.SignedHeader{Header: header, Commit: commit}, ValidatorSet: vals}
+	}
+	trusted := lightBlock(1, nil)
+
+	var tamper bool
+	chainReader := mocks.NewReaderWriter(t)
+	chainReader.On("LightBlock", mock.Anything, int64(1)).Return(trusted, nil)
+	chainReader.On("LightBlock", mock.Anything, int64(0)).Return(lightBlock(2, commitID.Hash), nil)
+	chainReader.On("ContractRawStoreWithProof", mock.Anything, contract, mock.Anything, int64(1)).Return(
+		func(_ context.Context, addr cosmosSDK.AccAddress, key []byte, height int64) *client.RawStoreProof {
+			storeKey := append(wasmtypes.GetContractStoreKey(addr), key...)
+			resp := store.Query(abci.RequestQuery{Path: "/" + wasmtypes.StoreKey + "/key", Data: storeKey, Height: height, Prove: true})
+			require.True(t, resp.IsOK(), resp.Log)
+			p := &client.RawStoreProof{Key: storeKey, Value: resp.Value, Proof: resp.ProofOps, Height: resp.Height}
+			if tamper {
+				p.Value = bytes.Replace(p.Value, []byte("-42"), []byte("-43"), 1)
+			}
+			return p
+		}, nil)
+
+	verifier, err := client.NewProofVerifier(ctx, chainReader, chainID, 1, trusted.Hash())
+	require.NoError(t, err)
+	reader := NewVerifiedOCR2Reader(contract, chainReader, verifier, nil, lggr)
+	assert.True(t, reader.Verified())
+
+	changedInBlock, gotDigest, err := reader.LatestConfigDetails(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(90), changedInBlock)
+	assert.Equal(t, digest, gotDigest)
+
+	gotDigest, epoch, err := reader.LatestConfigDigestAndEpoch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, digest, gotDigest)
+	assert.Equal(t, uint32(4), epoch)
+
+	gotDigest, epoch, round, answer, timestamp, err := reader.LatestTransmissionDetails(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, digest, gotDigest)
+	assert.Equal(t, uint32(4), epoch)
+	assert.Equal(t, uint8(2), round)
+	assert.Equal(t, big.NewInt(-42), answer)
+	assert.Equal(t, time.Unix(1000, 0), timestamp)
+
+	// A node which lies about the state is rejected.
+	tamper = true
+	_, _, _, _, _, err = reader.LatestTransmissionDetails(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid proof")
+}
+
+func TestOCR2Reader_ConfigFromTx(t *testing.T) {
+	lggr := logger.Test(t)
+	contract := cosmosSDK.AccAddress("contract")
+	other := cosmosSDK.AccAddress("other")
+	event := func(typ string, addr cosmosSDK.AccAddress, attrs ...cosmosSDK.Attribute) abci.Event {
+		e := abci.Event{Type: typ, Attributes: []abci.EventAttribute{{Key: []byte("contract_address"), Value: []byte(addr.String())}}}
+		for _, a := range attrs {
+			e.Attributes = append(e.Attributes, abci.EventAttribute{Key: []byte(a.Key), Value: []byte(a.Value)})
+		}
+		return e
+	}
+	configAttrs := func(count string) []cosmosSDK.Attribute {
+		return []cosmosSDK.Attribute{
+			{Key: "config_count", Value: count},
+			{Key: "f", Value: "1"},
+			{Key: "latest_config_digest", Value: "7465737420636f6e66696720646967657374203332206368617273206c6f6e67"},
+			{Key: "offchain_config", Value: "AwQ="},
+			{Key: "offchain_config_version", Value: "2"},
+			{Key: "onchain_config", Value: "AQI="},
+			{Key: "signers", Value: "0101010101010101010101010101010101010101010101010101010101010101"},
+			{Key: "transmitters", Value: "account1"},
+		}
+	}
+	tx := func(events ...abci.Event) tmcore.EventDataTx {
+		return tmcore.EventDataTx{TxResult: abci.TxResult{Height: 42, Result: abci.ResponseDeliverTx{Events: events}}}
+	}
+	reader := NewOCR2Reader(contract, nil, nil, lggr)
+
+	block, config, found, err := reader.ConfigFromTx(tx(
+		event("wasm-set_config", contract, configAttrs("1")...),
+		event("wasm-set_config", other, configAttrs("7")...),
+	))
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, uint64(42), block)
+	assert.Equal(t, uint64(1), config.ConfigCount)
+	assert.Equal(t, mustStringToConfigDigest(t, "test config digest 32 chars long"), config.ConfigDigest)
+	assert.False(t, reader.HasTransmission(tx(event("wasm-set_config", contract, configAttrs("1")...))))
+
+	_, _, found, err = reader.ConfigFromTx(tx(
+		event("wasm-set_config", other, configAttrs("1")...),
+		event("wasm-new_transmission", contract),
+	))
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.True(t, reader.HasTransmission(tx(event("wasm-new_transmission", contract))))
+	assert.False(t, reader.HasTransmission(tx(event("wasm-new_transmission", other))))
+
+	_, _, found, err = reader.ConfigFromTx(tx(event("wasm-set_config", contract, configAttrs("1.5")...)))
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+	assert.True(t, found)
+}
+
 func mustStringToConfigDigest(t *testing.T, s string) types.ConfigDigest {
 	d, err := types.BytesToConfigDigest([]byte(s))
 	require.NoError(t, err)
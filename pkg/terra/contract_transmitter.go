@@ -1,12 +1,20 @@
 package terra
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"encoding/binary"
 	"encoding/json"
+	"fmt"
 
 	cosmosSDK "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/authz"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
 	terraSDK "github.com/terra-money/core/x/wasm/types"
+	"golang.org/x/crypto/blake2s"
 
 	"github.com/smartcontractkit/libocr/offchainreporting2/chains/evmutil"
 	"github.com/smartcontractkit/libocr/offchainreporting2/types"
@@ -14,8 +22,21 @@ import (
 
 var _ types.ContractTransmitter = (*ContractTransmitter)(nil)
 
+// Reasons for skipping reports, as labels of promSkippedReports.
+const (
+	skipReasonSuperseded   = "superseded"
+	skipReasonConfigDigest = "config_digest"
+	skipReasonOutOfRange   = "out_of_range"
+)
+
+var promSkippedReports = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "terra_transmitter_skipped_reports",
+	Help: "The number of reports which were not transmitted because they were stale, or their median was outside the contract's answer range.",
+}, []string{"contract_address", "reason"})
+
 type ContractTransmitter struct {
 	*OCR2Reader
+	cache       *ContractCache // optional, for skipping stale reports
 	msgEnqueuer MsgEnqueuer
 	lggr        logger.Logger
 	jobID       string
@@ -24,8 +45,10 @@ type ContractTransmitter struct {
 	cfg         Config
 }
 
+// NewContractTransmitter returns a ContractTransmitter. If cache is not nil, reports which it shows to be stale are skipped.
 func NewContractTransmitter(
 	reader *OCR2Reader,
+	cache *ContractCache,
 	jobID string,
 	contract cosmosSDK.AccAddress,
 	sender cosmosSDK.AccAddress,
@@ -35,6 +58,7 @@ func NewContractTransmitter(
 ) *ContractTransmitter {
 	return &ContractTransmitter{
 		OCR2Reader:  reader,
+		cache:       cache,
 		jobID:       jobID,
 		contract:    contract,
 		msgEnqueuer: msgEnqueuer,
@@ -44,19 +68,38 @@ func NewContractTransmitter(
 	}
 }
 
-// Transmit signs and sends the report
+// Transmit signs and sends the report, unless it is stale, its median is out of the contract's range,
+// or its signatures would be rejected by the contract.
+// If an AuthzGranter is configured, the transmission is executed on its behalf via an authz MsgExec signed by the sender.
+// Transmissions are not urgent: while gas prices are paused they are held, and any which expire are superseded by
+// the reports of later rounds.
 func (ct *ContractTransmitter) Transmit(
 	ctx context.Context,
 	reportCtx types.ReportContext,
 	report types.Report,
 	sigs []types.AttributedOnchainSignature,
 ) error {
+	if reason := ct.staleReason(ctx, reportCtx); reason != "" {
+		promSkippedReports.WithLabelValues(ct.contract.String(), reason).Inc()
+		return nil
+	}
+	if err := ct.checkSignatures(ctx, reportCtx, report, sigs); err != nil {
+		return err
+	}
+	if outOfRange, err := ct.outOfRange(ctx, reportCtx, report); err != nil {
+		return err
+	} else if outOfRange {
+		promSkippedReports.WithLabelValues(ct.contract.String(), skipReasonOutOfRange).Inc()
+		return nil
+	}
 	ct.lggr.Infof("[%s] Sending TX to %s", ct.jobID, ct.contract.String())
-	msgStruct := TransmitMsg{}
-	reportContext := evmutil.RawReportContext(reportCtx)
-	for _, r := range reportContext {
-		msgStruct.Transmit.ReportContext = append(msgStruct.Transmit.ReportContext, r[:]...)
+	if parsed, err := (ReportCodec{}).DecodeReport(report); err != nil {
+		ct.lggr.Warnf("[%s] Undecodable report for epoch %d round %d: %v", ct.jobID, reportCtx.Epoch, reportCtx.Round, err)
+	} else {
+		ct.lggr.Debugf("[%s] Report for epoch %d round %d: %s", ct.jobID, reportCtx.Epoch, reportCtx.Round, parsed)
 	}
+	msgStruct := TransmitMsg{}
+	msgStruct.Transmit.ReportContext = rawReportContext(reportCtx)
 	msgStruct.Transmit.Report = []byte(report)
 	for _, sig := range sigs {
 		msgStruct.Transmit.Signatures = append(msgStruct.Transmit.Signatures, sig.Signature)
@@ -65,11 +108,187 @@ func (ct *ContractTransmitter) Transmit(
 	if err != nil {
 		return err
 	}
-	m := terraSDK.NewMsgExecuteContract(ct.sender, ct.contract, msgBytes, cosmosSDK.Coins{})
+	var m cosmosSDK.Msg
+	if granter := ct.cfg.AuthzGranter(); granter != nil {
+		exec := authz.NewMsgExec(ct.sender, []cosmosSDK.Msg{
+			terraSDK.NewMsgExecuteContract(granter, ct.contract, msgBytes, cosmosSDK.Coins{}),
+		})
+		m = &exec
+	} else {
+		m = terraSDK.NewMsgExecuteContract(ct.sender, ct.contract, msgBytes, cosmosSDK.Coins{})
+	}
 	_, err = ct.msgEnqueuer.Enqueue(ct.contract.String(), m)
 	return err
 }
 
+// staleReason returns why the report for reportCtx would be rejected by the contract, according to the cache,
+// or "" if it may be accepted. Reports are never skipped when the cache is unavailable.
+func (ct *ContractTransmitter) staleReason(ctx context.Context, reportCtx types.ReportContext) string {
+	if ct.cache == nil {
+		return ""
+	}
+	_, configDigest, err := ct.cache.LatestConfigDetails(ctx)
+	if err != nil {
+		ct.lggr.Debugf("[%s] Unable to check report for a stale config digest: %v", ct.jobID, err)
+		return ""
+	}
+	if reportCtx.ConfigDigest != configDigest {
+		ct.lggr.Debugf("[%s] Skipping report for config digest %s, since the contract's is %s",
+			ct.jobID, reportCtx.ConfigDigest, configDigest)
+		return skipReasonConfigDigest
+	}
+	digest, epoch, round, _, _, err := ct.cache.LatestTransmissionDetails(ctx)
+	if err != nil {
+		ct.lggr.Debugf("[%s] Unable to check report for a newer transmission: %v", ct.jobID, err)
+		return ""
+	}
+	// transmissions under an older config digest can't supersede the report
+	if digest == reportCtx.ConfigDigest && !(epochRound{epoch, round}).less(epochRound{reportCtx.Epoch, reportCtx.Round}) {
+		ct.lggr.Debugf("[%s] Skipping report for epoch %d round %d, since epoch %d round %d was already transmitted",
+			ct.jobID, reportCtx.Epoch, reportCtx.Round, epoch, round)
+		return skipReasonSuperseded
+	}
+	return ""
+}
+
+// outOfRange returns true if the report's median is outside of the contract's answer range, so that it would be
+// rejected. Reports are never skipped when the range is unavailable.
+func (ct *ContractTransmitter) outOfRange(ctx context.Context, reportCtx types.ReportContext, report types.Report) (bool, error) {
+	metadata, err := ct.Metadata(ctx)
+	if err != nil {
+		ct.lggr.Debugf("[%s] Unable to check report median against the answer range: %v", ct.jobID, err)
+		return false, nil
+	}
+	parsed, err := ReportCodec{}.DecodeReport(report)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode report for epoch %d round %d: %w", reportCtx.Epoch, reportCtx.Round, err)
+	}
+	answer := parsed.Median()
+	if answer.Cmp(metadata.MinAnswer) >= 0 && answer.Cmp(metadata.MaxAnswer) <= 0 {
+		return false, nil
+	}
+	ct.lggr.Warnf("[%s] Not transmitting report for epoch %d round %d to %s (%q): median %s is outside of the contract's answer range [%s, %s] (%d decimals). Check the feed's data sources.",
+		ct.jobID, reportCtx.Epoch, reportCtx.Round, ct.contract, metadata.Description, answer, metadata.MinAnswer, metadata.MaxAnswer, metadata.Decimals)
+	return true, nil
+}
+
+// checkSignatures returns an error unless sigs would be accepted by the contract, according to the cached config.
+// Signatures are not checked when the cache is unavailable.
+func (ct *ContractTransmitter) checkSignatures(ctx context.Context, reportCtx types.ReportContext, report types.Report, sigs []types.AttributedOnchainSignature) error {
+	if ct.cache == nil {
+		return nil
+	}
+	changedInBlock, _, err := ct.cache.LatestConfigDetails(ctx)
+	if err != nil {
+		ct.lggr.Debugf("[%s] Unable to check report signatures: %v", ct.jobID, err)
+		return nil
+	}
+	config, err := ct.cache.LatestConfig(ctx, changedInBlock)
+	if err != nil {
+		ct.lggr.Debugf("[%s] Unable to check report signatures: %v", ct.jobID, err)
+		return nil
+	}
+	if config.ConfigDigest != reportCtx.ConfigDigest {
+		return nil // see staleReason
+	}
+	return verifyReportSignatures(config, reportCtx, report, sigs)
+}
+
+// verifyReportSignatures returns an error unless sigs are exactly f+1 valid signatures of the report, by distinct
+// signers from config, as required by the contract. Each signature is the signer's ed25519 public key followed
+// by its signature of reportSigningHash.
+func verifyReportSignatures(config types.ContractConfig, reportCtx types.ReportContext, report types.Report, sigs []types.AttributedOnchainSignature) error {
+	if len(sigs) != int(config.F)+1 {
+		return fmt.Errorf("report for epoch %d round %d has %d signatures, but f+1 = %d are required",
+			reportCtx.Epoch, reportCtx.Round, len(sigs), int(config.F)+1)
+	}
+	hash := reportSigningHash(reportCtx, report)
+	for i, sig := range sigs {
+		if len(sig.Signature) != ed25519.PublicKeySize+ed25519.SignatureSize {
+			return fmt.Errorf("signature %d from oracle %d has length %d, expected %d",
+				i, sig.Signer, len(sig.Signature), ed25519.PublicKeySize+ed25519.SignatureSize)
+		}
+		pubKey, signature := sig.Signature[:ed25519.PublicKeySize], sig.Signature[ed25519.PublicKeySize:]
+		if !isSigner(config, pubKey) {
+			return fmt.Errorf("signature %d from oracle %d is by %x, which is not a signer of config %s",
+				i, sig.Signer, pubKey, config.ConfigDigest)
+		}
+		for j := 0; j < i; j++ {
+			if bytes.Equal(sigs[j].Signature[:ed25519.PublicKeySize], pubKey) {
+				return fmt.Errorf("signatures %d and %d are both by signer %x", j, i, pubKey)
+			}
+		}
+		if !ed25519.Verify(pubKey, hash, signature) {
+			return fmt.Errorf("signature %d from oracle %d by signer %x is invalid for the report for epoch %d round %d",
+				i, sig.Signer, pubKey, reportCtx.Epoch, reportCtx.Round)
+		}
+	}
+	return nil
+}
+
+func isSigner(config types.ContractConfig, pubKey []byte) bool {
+	for _, signer := range config.Signers {
+		if bytes.Equal(signer, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportSigningHash returns the hash of a report which is signed by each oracle, as computed by the contract:
+// blake2s-256 of the report's big-endian uint32 length, the report, and the raw report context.
+func reportSigningHash(reportCtx types.ReportContext, report types.Report) []byte {
+	h, _ := blake2s.New256(nil) // only errors for keys over 32 bytes
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(report)))
+	h.Write(length[:])
+	h.Write(report)
+	h.Write(rawReportContext(reportCtx))
+	return h.Sum(nil)
+}
+
+// rawReportContext returns the 96 byte report context, as expected by the contract.
+func rawReportContext(reportCtx types.ReportContext) []byte {
+	var raw []byte
+	for _, r := range evmutil.RawReportContext(reportCtx) {
+		raw = append(raw, r[:]...)
+	}
+	return raw
+}
+
+type epochRound struct {
+	epoch uint32
+	round uint8
+}
+
+func (er epochRound) less(other epochRound) bool {
+	return er.epoch < other.epoch || (er.epoch == other.epoch && er.round < other.round)
+}
+
+// TransmitReport returns the report transmitted by msg, if it is a transmission as sent by ContractTransmitter,
+// including via an authz MsgExec.
+func TransmitReport(msg cosmosSDK.Msg) (types.Report, bool) {
+	switch m := msg.(type) {
+	case *authz.MsgExec:
+		msgs, err := m.GetMessages()
+		if err != nil || len(msgs) != 1 {
+			return nil, false
+		}
+		return TransmitReport(msgs[0])
+	case *terraSDK.MsgExecuteContract:
+		var transmit TransmitMsg
+		if err := json.Unmarshal(m.ExecuteMsg, &transmit); err != nil || transmit.Transmit.Report == nil {
+			return nil, false
+		}
+		return transmit.Transmit.Report, true
+	}
+	return nil, false
+}
+
+// FromAccount returns the account which transmits onchain: the AuthzGranter if configured, otherwise the sender.
 func (ct *ContractTransmitter) FromAccount() types.Account {
+	if granter := ct.cfg.AuthzGranter(); granter != nil {
+		return types.Account(granter.String())
+	}
 	return types.Account(ct.sender.String())
 }
@@ -0,0 +1,279 @@
+package terra
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	cosmosSDK "github.com/cosmos/cosmos-sdk/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2/reportingplugin/median"
+	"github.com/smartcontractkit/libocr/offchainreporting2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	terraSDK "github.com/terra-money/core/x/wasm/types"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client/mocks"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+type fakeMsgEnqueuer struct {
+	msgs []cosmosSDK.Msg
+}
+
+func (f *fakeMsgEnqueuer) Enqueue(_ string, msg cosmosSDK.Msg) (int64, error) {
+	f.msgs = append(f.msgs, msg)
+	return int64(len(f.msgs)), nil
+}
+
+type testSigner struct {
+	pub  ed25519.PublicKey
+	priv ed25519.PrivateKey
+}
+
+func newTestSigners(t *testing.T, n int) []testSigner {
+	signers := make([]testSigner, n)
+	for i := range signers {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		signers[i] = testSigner{pub, priv}
+	}
+	return signers
+}
+
+func (s testSigner) sign(oracle int, reportCtx types.ReportContext, report types.Report) types.AttributedOnchainSignature {
+	sig := append([]byte{}, s.pub...)
+	sig = append(sig, ed25519.Sign(s.priv, reportSigningHash(reportCtx, report))...)
+	return types.AttributedOnchainSignature{Signature: sig, Signer: commontypes.OracleID(oracle)}
+}
+
+func newTestReport(t *testing.T, answer int64) types.Report {
+	report, err := ReportCodec{}.BuildReport([]median.ParsedAttributedObservation{
+		{Timestamp: 1, Value: big.NewInt(answer), JuelsPerFeeCoin: big.NewInt(1), Observer: 0},
+	})
+	require.NoError(t, err)
+	return report
+}
+
+// withAnswerRange sets the reader's cached metadata, so that it isn't fetched.
+func withAnswerRange(reader *OCR2Reader, min, max int64) *OCR2Reader {
+	reader.metadata = &ContractMetadata{MinAnswer: big.NewInt(min), MaxAnswer: big.NewInt(max)}
+	return reader
+}
+
+func signersConfig(digest types.ConfigDigest, f uint8, signers []testSigner) types.ContractConfig {
+	config := types.ContractConfig{ConfigDigest: digest, F: f}
+	for _, s := range signers {
+		config.Signers = append(config.Signers, types.OnchainPublicKey(s.pub))
+	}
+	return config
+}
+
+func TestContractTransmitter_SkipsStaleReports(t *testing.T) {
+	ctx := context.Background()
+	lggr := logger.Test(t)
+	cfg := NewConfig(db.ChainCfg{}, lggr)
+	contract := cosmosSDK.AccAddress("stale_reports_contract")
+	sender := cosmosSDK.AccAddress("sender")
+	oldDigest := mustStringToConfigDigest(t, "old config digest 32 chars long.")
+	digest := mustStringToConfigDigest(t, "test config digest 32 chars long")
+	newDigest := mustStringToConfigDigest(t, "new config digest 32 chars long.")
+
+	reader := withAnswerRange(NewOCR2Reader(contract, mocks.NewReaderWriter(t), cfg, lggr), 0, 100)
+	cache := NewContractCache(cfg, reader, lggr)
+	enqueuer := &fakeMsgEnqueuer{}
+	ct := NewContractTransmitter(reader, cache, "job", contract, sender, enqueuer, lggr, cfg)
+	signer := newTestSigners(t, 1)[0]
+	report := newTestReport(t, 42)
+	transmit := func(reportCtx types.ReportContext) error {
+		return ct.Transmit(ctx, reportCtx, report, []types.AttributedOnchainSignature{signer.sign(0, reportCtx, report)})
+	}
+	reportCtx := func(digest types.ConfigDigest, epoch uint32, round uint8) types.ReportContext {
+		return types.ReportContext{ReportTimestamp: types.ReportTimestamp{ConfigDigest: digest, Epoch: epoch, Round: round}}
+	}
+	skipped := func(reason string) float64 {
+		return testutil.ToFloat64(promSkippedReports.WithLabelValues(contract.String(), reason))
+	}
+
+	// Reports are transmitted while the cache is uninitialized.
+	require.NoError(t, transmit(reportCtx(digest, 1, 1)))
+	assert.Len(t, enqueuer.msgs, 1)
+
+	cache.setConfig(10, signersConfig(digest, 0, []testSigner{signer}))
+	cache.setTransmission(digest, 3, 2, big.NewInt(42), time.Now())
+
+	for _, tt := range []struct {
+		name     string
+		digest   types.ConfigDigest
+		epoch    uint32
+		round    uint8
+		expected string // skip reason, or empty if transmitted
+	}{
+		{"newer round", digest, 3, 3, ""},
+		{"newer epoch", digest, 4, 1, ""},
+		{"same round", digest, 3, 2, skipReasonSuperseded},
+		{"older round", digest, 3, 1, skipReasonSuperseded},
+		{"older epoch", digest, 2, 5, skipReasonSuperseded},
+		{"old config digest", oldDigest, 5, 1, skipReasonConfigDigest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := len(enqueuer.msgs)
+			before := skipped(tt.expected)
+			require.NoError(t, transmit(reportCtx(tt.digest, tt.epoch, tt.round)))
+			if tt.expected == "" {
+				assert.Len(t, enqueuer.msgs, msgs+1)
+				return
+			}
+			assert.Len(t, enqueuer.msgs, msgs)
+			assert.Equal(t, before+1, skipped(tt.expected))
+		})
+	}
+
+	t.Run("first transmission after a config change", func(t *testing.T) {
+		cache.setConfig(11, signersConfig(newDigest, 0, []testSigner{signer}))
+		msgs := len(enqueuer.msgs)
+		require.NoError(t, transmit(reportCtx(newDigest, 1, 1)))
+		assert.Len(t, enqueuer.msgs, msgs+1)
+	})
+}
+
+func TestContractTransmitter_VerifiesSignatures(t *testing.T) {
+	ctx := context.Background()
+	lggr := logger.Test(t)
+	cfg := NewConfig(db.ChainCfg{}, lggr)
+	contract := cosmosSDK.AccAddress("signatures_contract")
+	digest := mustStringToConfigDigest(t, "test config digest 32 chars long")
+
+	reader := withAnswerRange(NewOCR2Reader(contract, mocks.NewReaderWriter(t), cfg, lggr), 0, 100)
+	cache := NewContractCache(cfg, reader, lggr)
+	enqueuer := &fakeMsgEnqueuer{}
+	ct := NewContractTransmitter(reader, cache, "job", contract, cosmosSDK.AccAddress("sender"), enqueuer, lggr, cfg)
+
+	signers := newTestSigners(t, 4)
+	outsider := newTestSigners(t, 1)[0]
+	cache.setConfig(10, signersConfig(digest, 1, signers))
+	reportCtx := types.ReportContext{ReportTimestamp: types.ReportTimestamp{ConfigDigest: digest, Epoch: 1, Round: 1}}
+	report := newTestReport(t, 42)
+
+	for _, tt := range []struct {
+		name string
+		sigs func() []types.AttributedOnchainSignature
+		err  string
+	}{
+		{"valid", func() []types.AttributedOnchainSignature {
+			return []types.AttributedOnchainSignature{signers[0].sign(0, reportCtx, report), signers[2].sign(2, reportCtx, report)}
+		}, ""},
+		{"too few", func() []types.AttributedOnchainSignature {
+			return []types.AttributedOnchainSignature{signers[0].sign(0, reportCtx, report)}
+		}, "has 1 signatures, but f+1 = 2 are required"},
+		{"too many", func() []types.AttributedOnchainSignature {
+			return []types.AttributedOnchainSignature{
+				signers[0].sign(0, reportCtx, report), signers[1].sign(1, reportCtx, report), signers[2].sign(2, reportCtx, report),
+			}
+		}, "has 3 signatures, but f+1 = 2 are required"},
+		{"not a signer", func() []types.AttributedOnchainSignature {
+			return []types.AttributedOnchainSignature{signers[0].sign(0, reportCtx, report), outsider.sign(1, reportCtx, report)}
+		}, "which is not a signer of config"},
+		{"repeated signer", func() []types.AttributedOnchainSignature {
+			return []types.AttributedOnchainSignature{signers[1].sign(1, reportCtx, report), signers[1].sign(1, reportCtx, report)}
+		}, "signatures 0 and 1 are both by signer"},
+		{"wrong report", func() []types.AttributedOnchainSignature {
+			return []types.AttributedOnchainSignature{signers[0].sign(0, reportCtx, report), signers[1].sign(1, reportCtx, newTestReport(t, 43))}
+		}, "signature 1 from oracle 1 by signer"},
+		{"wrong length", func() []types.AttributedOnchainSignature {
+			sig := signers[1].sign(1, reportCtx, report)
+			sig.Signature = sig.Signature[:64]
+			return []types.AttributedOnchainSignature{signers[0].sign(0, reportCtx, report), sig}
+		}, "has length 64, expected 96"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := len(enqueuer.msgs)
+			err := ct.Transmit(ctx, reportCtx, report, tt.sigs())
+			if tt.err == "" {
+				require.NoError(t, err)
+				assert.Len(t, enqueuer.msgs, msgs+1)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+			assert.Len(t, enqueuer.msgs, msgs)
+		})
+	}
+}
+
+func TestContractTransmitter_AnswerRange(t *testing.T) {
+	ctx := context.Background()
+	lggr := logger.Test(t)
+	cfg := NewConfig(db.ChainCfg{}, lggr)
+	contract := cosmosSDK.AccAddress("answer_range_contract")
+
+	chainReader := mocks.NewReaderWriter(t)
+	reader := NewOCR2Reader(contract, chainReader, cfg, lggr)
+	enqueuer := &fakeMsgEnqueuer{}
+	ct := NewContractTransmitter(reader, nil, "job", contract, cosmosSDK.AccAddress("sender"), enqueuer, lggr, cfg)
+	reportCtx := types.ReportContext{ReportTimestamp: types.ReportTimestamp{Epoch: 1, Round: 1}}
+	skipped := func() float64 {
+		return testutil.ToFloat64(promSkippedReports.WithLabelValues(contract.String(), skipReasonOutOfRange))
+	}
+
+	// Reports are transmitted while the range is unavailable.
+	chainReader.On("ContractStore", mock.Anything, contract, mock.Anything).Return(nil, errors.New("node down")).Once()
+	require.NoError(t, ct.Transmit(ctx, reportCtx, newTestReport(t, 1000), nil))
+	assert.Len(t, enqueuer.msgs, 1)
+
+	withAnswerRange(reader, -10, 100)
+	for _, tt := range []struct {
+		answer      int64
+		transmitted bool
+	}{
+		{-11, false},
+		{-10, true},
+		{42, true},
+		{100, true},
+		{101, false},
+	} {
+		msgs, before := len(enqueuer.msgs), skipped()
+		require.NoError(t, ct.Transmit(ctx, reportCtx, newTestReport(t, tt.answer), nil))
+		if tt.transmitted {
+			assert.Len(t, enqueuer.msgs, msgs+1, "answer %d", tt.answer)
+		} else {
+			assert.Len(t, enqueuer.msgs, msgs, "answer %d", tt.answer)
+			assert.Equal(t, before+1, skipped(), "answer %d", tt.answer)
+		}
+	}
+
+	err := ct.Transmit(ctx, reportCtx, types.Report("malformed"), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decode report")
+}
+
+func TestTransmitReport(t *testing.T) {
+	lggr := logger.Test(t)
+	contract := cosmosSDK.AccAddress("transmit_report_contract")
+	report := newTestReport(t, 42)
+	reportCtx := types.ReportContext{ReportTimestamp: types.ReportTimestamp{Epoch: 1, Round: 1}}
+
+	for _, granter := range []string{"", cosmosSDK.AccAddress("granter").String()} {
+		cfg := NewConfig(db.ChainCfg{AuthzGranter: null.StringFrom(granter)}, lggr)
+		enqueuer := &fakeMsgEnqueuer{}
+		reader := NewOCR2Reader(contract, mocks.NewReaderWriter(t), cfg, lggr)
+		ct := NewContractTransmitter(withAnswerRange(reader, 0, 100), nil, "job", contract, cosmosSDK.AccAddress("sender"), enqueuer, lggr, cfg)
+		require.NoError(t, ct.Transmit(context.Background(), reportCtx, report, nil))
+		require.Len(t, enqueuer.msgs, 1)
+
+		got, ok := TransmitReport(enqueuer.msgs[0])
+		require.True(t, ok, "granter %q", granter)
+		assert.Equal(t, report, got)
+	}
+
+	_, ok := TransmitReport(terraSDK.NewMsgExecuteContract(cosmosSDK.AccAddress("sender"), contract, []byte(`{"withdraw_payment":{}}`), nil))
+	assert.False(t, ok)
+}
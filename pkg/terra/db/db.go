@@ -4,8 +4,12 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"math/big"
+	"strings"
 	"time"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.uber.org/multierr"
 	"gopkg.in/guregu/null.v4"
 
 	"github.com/smartcontractkit/chainlink-relay/pkg/utils"
@@ -29,15 +33,28 @@ type Node struct {
 }
 
 type ChainCfg struct {
+	AuthzGranter          null.String
 	BlockRate             *utils.Duration
+	BlocksUntilGasBump    null.Int
 	BlocksUntilTxTimeout  null.Int
 	ConfirmPollPeriod     *utils.Duration
 	FallbackGasPriceULuna null.String
 	FCDURL                null.String `db:"fcd_url"`
+	FeeDenoms             null.String // comma separated
+	FeeGranter            null.String
+	FeeHistoryBlocks      null.Int
+	FeeHistoryPercentile  null.Int
+	GasBumpPercent        null.Int
 	GasLimitMultiplier    null.Float
+	GasPriceSpikeRatio    null.Float
+	MaxGasPriceULuna      null.String
 	MaxMsgsPerBatch       null.Int
+	OCR2CacheGracePeriod  *utils.Duration
 	OCR2CachePollPeriod   *utils.Duration
 	OCR2CacheTTL          *utils.Duration
+	TrustedBlockHash      null.String // hex
+	TrustedBlockHeight    null.Int
+	TxMsgRetention        *utils.Duration
 	TxMsgTimeout          *utils.Duration
 }
 
@@ -54,8 +71,23 @@ func (c *ChainCfg) Value() (driver.Value, error) {
 	return json.Marshal(c)
 }
 
+// ParseFeeDenoms parses a comma separated list of fee denoms, as stored in ChainCfg.FeeDenoms.
+// Whitespace around each denom is ignored, and each must be a valid denom.
+func ParseFeeDenoms(s string) ([]string, error) {
+	denoms := strings.Split(s, ",")
+	var err error
+	for i := range denoms {
+		denoms[i] = strings.TrimSpace(denoms[i])
+		err = multierr.Append(err, sdk.ValidateDenom(denoms[i]))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return denoms, nil
+}
+
 // State represents the state of a given terra msg
-// Happy path: Unstarted->Broadcasted->Confirmed
+// Happy path: Unstarted->Started->Broadcasted->Confirmed
 type State string
 
 var (
@@ -66,6 +98,8 @@ var (
 	// Valid next states: Broadcasted, Errored (sim fails)
 	Started State = "started"
 	// Broadcasted means included in the mempool of a node.
+	// If gas bumping is enabled, each attempt expires after BlocksUntilGasBump blocks, and is then re-signed with a higher
+	// gas price and rebroadcast.
 	// Valid next states: Confirmed (found onchain), Errored (tx expired waiting for confirmation)
 	Broadcasted State = "broadcasted"
 	// Confirmed means we're able to retrieve the txhash of the tx which broadcasted the msg.
@@ -73,9 +107,8 @@ var (
 	Confirmed State = "confirmed"
 	// Errored means the msg:
 	//  - reverted in simulation
-	//  - the tx containing the message timed out waiting to be confirmed
+	//  - the tx containing the message timed out waiting to be confirmed, despite any gas bumping
 	//  - the msg was cancelled
-	// TODO: when we add gas bumping, we'll address that timeout case
 	// Valid next states, none, terminal state
 	Errored State = "errored"
 )
@@ -88,6 +121,33 @@ type Msg struct {
 	Type       string // cosmos-sdk/types.MsgTypeURL()
 	Raw        []byte // proto.Marshal()
 	TxHash     *string
+	TxHashes   []string // all attempted txs, including gas bumps, oldest first
+	Urgent     bool     // broadcast even while gas prices are paused
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
+
+// ContractState is the last known state of an OCR2 contract, as persisted by a ContractCache so that it can
+// warm-start. The UpdatedAt times are when each part was last read from the chain, and zero if never.
+type ContractState struct {
+	ChainID         string `db:"terra_chain_id"`
+	ContractAddress string
+
+	ConfigBlock           uint64
+	ConfigDigest          [32]byte
+	ConfigCount           uint64
+	Signers               [][]byte
+	Transmitters          []string
+	F                     uint8
+	OnchainConfig         []byte
+	OffchainConfigVersion uint64
+	OffchainConfig        []byte
+	ConfigUpdatedAt       time.Time
+
+	TransmissionDigest    [32]byte
+	Epoch                 uint32
+	Round                 uint8
+	LatestAnswer          *big.Int
+	LatestTimestamp       time.Time
+	TransmissionUpdatedAt time.Time
+}
@@ -0,0 +1,145 @@
+package terra
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/smartcontractkit/chainlink-relay/pkg/utils"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
+)
+
+// PollCoordinator polls the contracts of all registered ContractCaches which share a node. Each OCR2CachePollPeriod,
+// their latest_config_details and latest_transmission_details queries are sent together in one JSON-RPC batch
+// request, instead of two requests per contract, and the results are fanned back out to each cache.
+type PollCoordinator struct {
+	utils.StartStopOnce
+	reader client.Reader
+	cfg    Config
+	lggr   logger.Logger
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	cachesMu sync.Mutex
+	// caches tracks the in-flight updates of each registered cache, so unregister can wait for them.
+	caches map[*ContractCache]*sync.WaitGroup
+}
+
+func NewPollCoordinator(reader client.Reader, cfg Config, lggr logger.Logger) *PollCoordinator {
+	return &PollCoordinator{
+		reader: reader,
+		cfg:    cfg,
+		lggr:   lggr,
+		stop:   make(chan struct{}),
+		caches: make(map[*ContractCache]*sync.WaitGroup),
+	}
+}
+
+func (pc *PollCoordinator) Start() error {
+	return pc.StartOnce("PollCoordinator", func() error {
+		pc.wg.Add(1)
+		go pc.run()
+		return nil
+	})
+}
+
+func (pc *PollCoordinator) Close() error {
+	return pc.StopOnce("PollCoordinator", func() error {
+		close(pc.stop)
+		pc.wg.Wait()
+		return nil
+	})
+}
+
+func (pc *PollCoordinator) register(cc *ContractCache) {
+	pc.cachesMu.Lock()
+	defer pc.cachesMu.Unlock()
+	pc.caches[cc] = new(sync.WaitGroup)
+}
+
+// unregister stops polling for cc, and waits for any in-flight update of cc to return.
+// Updates are cancelled by cc.stop, so it should be closed first.
+func (pc *PollCoordinator) unregister(cc *ContractCache) {
+	pc.cachesMu.Lock()
+	inflight, ok := pc.caches[cc]
+	delete(pc.caches, cc)
+	pc.cachesMu.Unlock()
+	if ok {
+		inflight.Wait()
+	}
+}
+
+func (pc *PollCoordinator) run() {
+	defer pc.wg.Done()
+	tick := time.After(0)
+	for {
+		select {
+		case <-pc.stop:
+			return
+		case <-tick:
+			ctx, cancel := utils.ContextFromChan(pc.stop)
+			pc.poll(ctx)
+			cancel()
+			tick = time.After(utils.WithJitter(pc.cfg.OCR2CachePollPeriod()))
+		}
+	}
+}
+
+// poll sends the queries of all caches in one batch, and updates each cache from its results.
+func (pc *PollCoordinator) poll(ctx context.Context) {
+	pc.cachesMu.Lock()
+	caches := make([]*ContractCache, 0, len(pc.caches))
+	for cc := range pc.caches {
+		caches = append(caches, cc)
+	}
+	pc.cachesMu.Unlock()
+	if len(caches) == 0 {
+		return
+	}
+	queries := make([]client.ContractQuery, 0, 2*len(caches))
+	for _, cc := range caches {
+		queries = append(queries,
+			client.ContractQuery{ContractAddress: cc.reader.address, QueryMsg: queryLatestConfigDetails},
+			client.ContractQuery{ContractAddress: cc.reader.address, QueryMsg: queryLatestTransmissionDetails},
+		)
+	}
+	results, err := pc.reader.BatchContractStore(ctx, queries)
+	if err != nil {
+		pc.lggr.Errorf("Failed to poll %d contracts: %v", len(caches), err)
+		return
+	}
+	if len(results) != len(queries) {
+		pc.lggr.Errorf("Failed to poll %d contracts: expected %d results but got %d", len(caches), len(queries), len(results))
+		return
+	}
+	// Caches may need to make further requests, e.g. to fetch a new config, so update them concurrently.
+	var wg sync.WaitGroup
+	pc.cachesMu.Lock()
+	for i, cc := range caches {
+		inflight, ok := pc.caches[cc]
+		if !ok {
+			continue // unregistered during the batch request
+		}
+		inflight.Add(1)
+		wg.Add(1)
+		go func(cc *ContractCache, configDetails, transmissionDetails client.ContractQueryResult) {
+			defer wg.Done()
+			defer inflight.Done()
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			go func() {
+				select {
+				case <-cc.stop:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+			cc.updateFromBatch(ctx, configDetails, transmissionDetails)
+		}(cc, results[2*i], results[2*i+1])
+	}
+	pc.cachesMu.Unlock()
+	wg.Wait()
+}
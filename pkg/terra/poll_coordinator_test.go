@@ -0,0 +1,132 @@
+package terra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cosmosSDK "github.com/cosmos/cosmos-sdk/types"
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client/mocks"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+func TestPollCoordinator(t *testing.T) {
+	ctx := context.Background()
+	lggr := logger.Test(t)
+	cfg := NewConfig(db.ChainCfg{}, lggr)
+	digest := mustStringToConfigDigest(t, "test config digest 32 chars long")
+	digestJSON := make([]int, len(digest))
+	for i := range digest {
+		digestJSON[i] = int(digest[i])
+	}
+	mustMarshal := func(v map[string]interface{}) []byte {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return b
+	}
+	a, b := cosmosSDK.AccAddress("contract_a"), cosmosSDK.AccAddress("contract_b")
+
+	chainReader := mocks.NewReaderWriter(t)
+	pc := NewPollCoordinator(chainReader, cfg, lggr)
+	ccA := NewBatchedContractCache(cfg, NewOCR2Reader(a, chainReader, cfg, lggr), pc, lggr)
+	ccB := NewBatchedContractCache(cfg, NewOCR2Reader(b, chainReader, cfg, lggr), pc, lggr)
+	pc.register(ccA)
+	pc.register(ccB)
+
+	chainReader.On("BatchContractStore", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, queries []client.ContractQuery) []client.ContractQueryResult {
+			require.Len(t, queries, 4)
+			results := make([]client.ContractQueryResult, len(queries))
+			for i, q := range queries {
+				switch {
+				case string(q.QueryMsg) == `"latest_config_details"`:
+					// unconfigured
+					results[i].Result = mustMarshal(map[string]interface{}{"block_number": 0, "config_digest": make([]int, 32)})
+				case q.ContractAddress.Equals(a):
+					results[i].Result = mustMarshal(map[string]interface{}{
+						"latest_config_digest": digestJSON, "epoch": 4, "round": 2, "latest_answer": "42", "latest_timestamp": 1000,
+					})
+				default:
+					results[i].Err = errors.New("ocr2::state::Transmission not found: contract query failed")
+				}
+			}
+			return results
+		}, nil).Once()
+	// b has no transmissions, so falls back to the reader
+	chainReader.On("ContractStore", mock.Anything, b, []byte(`"latest_transmission_details"`)).
+		Return(nil, errors.New("ocr2::state::Transmission not found: contract query failed")).Once()
+	chainReader.On("ContractStore", mock.Anything, b, []byte(`"latest_config_digest_and_epoch"`)).
+		Return(mustMarshal(map[string]interface{}{"config_digest": digestJSON, "epoch": 0}), nil).Once()
+
+	pc.poll(ctx)
+
+	for _, cc := range []*ContractCache{ccA, ccB} {
+		changedInBlock, _, err := cc.LatestConfigDetails(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, changedInBlock)
+	}
+	gotDigest, epoch, round, answer, timestamp, err := ccA.LatestTransmissionDetails(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, digest, gotDigest)
+	assert.Equal(t, uint32(4), epoch)
+	assert.Equal(t, uint8(2), round)
+	assert.Equal(t, big.NewInt(42), answer)
+	assert.Equal(t, time.Unix(1000, 0), timestamp)
+
+	gotDigest, epoch, _, answer, _, err = ccB.LatestTransmissionDetails(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, digest, gotDigest)
+	assert.Zero(t, epoch)
+	assert.Equal(t, big.NewInt(0), answer)
+
+	// nothing to poll
+	pc.unregister(ccA)
+	pc.unregister(ccB)
+	pc.poll(ctx)
+}
+
+func TestPollCoordinator_unregisterWaits(t *testing.T) {
+	lggr := logger.Test(t)
+	cfg := NewConfig(db.ChainCfg{}, lggr)
+	a := cosmosSDK.AccAddress("contract_a")
+
+	chainReader := mocks.NewReaderWriter(t)
+	pc := NewPollCoordinator(chainReader, cfg, lggr)
+	cc := NewBatchedContractCache(cfg, NewOCR2Reader(a, chainReader, cfg, lggr), pc, lggr)
+	pc.register(cc)
+
+	chainReader.On("BatchContractStore", mock.Anything, mock.Anything).Return([]client.ContractQueryResult{
+		{Result: []byte(`{"block_number":7,"config_digest":[` + strings.Repeat("1,", 31) + `1]}`)},
+		{Err: errors.New("query failed")},
+	}, nil).Once()
+	// the new config is fetched until cc is closed
+	fetching := make(chan struct{})
+	var fetched atomic.Bool
+	chainReader.On("TxsEvents", mock.Anything, mock.Anything, mock.Anything).Return(nil, context.Canceled).Run(func(args mock.Arguments) {
+		close(fetching)
+		<-args.Get(0).(context.Context).Done()
+		fetched.Store(true)
+	}).Once()
+
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		pc.poll(context.Background())
+	}()
+	<-fetching
+	close(cc.stop)
+	pc.unregister(cc)
+	assert.True(t, fetched.Load())
+	<-polled
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/smartcontractkit/chainlink-relay/pkg/utils"
 	"github.com/smartcontractkit/libocr/offchainreporting2/reportingplugin/median"
 	"github.com/smartcontractkit/libocr/offchainreporting2/types"
+	"go.uber.org/multierr"
 )
 
 // ErrMsgUnsupported is returned when an unsupported type of message is encountered.
@@ -25,7 +26,8 @@ func (e *ErrMsgUnsupported) Error() string {
 }
 
 type MsgEnqueuer interface {
-	// Enqueue enqueues msg for broadcast and returns its id.
+	// Enqueue enqueues msg for broadcast and returns its id. Msgs are not urgent unless enqueued with EnqueueUrgent,
+	// so they may be held while gas prices are paused, until they expire after TxMsgTimeout.
 	// Returns ErrMsgUnsupported for unsupported message types.
 	Enqueue(contractID string, msg cosmosSDK.Msg) (int64, error)
 }
@@ -40,10 +42,26 @@ type TxManager interface {
 	GasPrice() (cosmosSDK.DecCoin, error)
 }
 
+// UrgentTxManager is a TxManager which can broadcast urgent msgs even while gas prices are paused.
+// See client.PausableGasPricesEstimator.
+type UrgentTxManager interface {
+	TxManager
+	// EnqueueUrgent is like Enqueue, but msg is broadcast even while gas prices are paused.
+	EnqueueUrgent(contractID string, msg cosmosSDK.Msg) (int64, error)
+}
+
+// EnqueueUrgent enqueues msg with txm.EnqueueUrgent if txm is an UrgentTxManager, or txm.Enqueue otherwise.
+func EnqueueUrgent(txm TxManager, contractID string, msg cosmosSDK.Msg) (int64, error) {
+	if u, ok := txm.(UrgentTxManager); ok {
+		return u.EnqueueUrgent(contractID, msg)
+	}
+	return txm.Enqueue(contractID, msg)
+}
+
 // CL Core OCR2 job spec RelayConfig member for Terra
 type RelayConfig struct {
 	ChainID  string `json:"chainID"`  // required
-	NodeName string `json:"nodeName"` // optional, defaults to a random node with ChainID
+	NodeName string `json:"nodeName"` // optional, defaults to all nodes with ChainID if the Chain is a NodesChain, see client.MultiNodeClient
 }
 
 var _ relaytypes.Relayer = &Relayer{}
@@ -53,16 +71,32 @@ type Relayer struct {
 	chainSet ChainSet
 	ctx      context.Context
 	cancel   func()
+
+	chainReaders     *shared[chainReaderKey, *sharedChainReader]
+	pollCoordinators *shared[chainReaderKey, *sharedPollCoordinator]
+	contractCaches   *shared[contractCacheKey, *sharedContractCache]
+	cacheStore       ContractCacheStore // optional
 }
 
 // Note: constructed in core
 func NewRelayer(lggr logger.Logger, chainSet ChainSet) *Relayer {
+	return NewRelayerWithCacheStore(lggr, chainSet, nil)
+}
+
+// NewRelayerWithCacheStore returns a Relayer whose contract caches are persisted to cacheStore, so that they can
+// warm-start after a restart while the chain cannot be read. See Config.OCR2CacheGracePeriod.
+func NewRelayerWithCacheStore(lggr logger.Logger, chainSet ChainSet, cacheStore ContractCacheStore) *Relayer {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Relayer{
-		lggr:     lggr,
-		chainSet: chainSet,
-		ctx:      ctx,
-		cancel:   cancel,
+		lggr:       lggr,
+		chainSet:   chainSet,
+		ctx:        ctx,
+		cancel:     cancel,
+		cacheStore: cacheStore,
+
+		chainReaders:     newShared[chainReaderKey, *sharedChainReader](),
+		pollCoordinators: newShared[chainReaderKey, *sharedPollCoordinator](),
+		contractCaches:   newShared[contractCacheKey, *sharedContractCache](),
 	}
 }
 
@@ -89,7 +123,7 @@ func (r *Relayer) Healthy() error {
 }
 
 func (r *Relayer) NewConfigProvider(args relaytypes.RelayArgs) (relaytypes.ConfigProvider, error) {
-	configProvider, err := newConfigProvider(r.ctx, r.lggr, r.chainSet, args)
+	configProvider, err := r.newConfigProvider(args)
 	if err != nil {
 		// Never return (*configProvider)(nil)
 		return nil, err
@@ -98,13 +132,13 @@ func (r *Relayer) NewConfigProvider(args relaytypes.RelayArgs) (relaytypes.Confi
 }
 
 func (r *Relayer) NewMedianProvider(rargs relaytypes.RelayArgs, pargs relaytypes.PluginArgs) (relaytypes.MedianProvider, error) {
-	configProvider, err := newConfigProvider(r.ctx, r.lggr, r.chainSet, rargs)
+	configProvider, err := r.newConfigProvider(rargs)
 	if err != nil {
 		return nil, err
 	}
 	senderAddr, err := cosmosSDK.AccAddressFromBech32(pargs.TransmitterID)
 	if err != nil {
-		return nil, err
+		return nil, multierr.Combine(err, configProvider.release())
 	}
 
 	return &medianProvider{
@@ -113,6 +147,7 @@ func (r *Relayer) NewMedianProvider(rargs relaytypes.RelayArgs, pargs relaytypes
 		contract:       configProvider.contractCache,
 		transmitter: NewContractTransmitter(
 			configProvider.reader,
+			configProvider.contractCache,
 			rargs.ExternalJobID.String(),
 			configProvider.contractAddr,
 			senderAddr,
@@ -135,12 +170,14 @@ type configProvider struct {
 	transmitter types.ContractTransmitter
 
 	chain         Chain
+	cache         *sharedContractCache
+	release       func() error // releases cache
 	contractCache *ContractCache
 	reader        *OCR2Reader
 	contractAddr  cosmosSDK.AccAddress
 }
 
-func newConfigProvider(ctx context.Context, lggr logger.Logger, chainSet ChainSet, args relaytypes.RelayArgs) (*configProvider, error) {
+func (r *Relayer) newConfigProvider(args relaytypes.RelayArgs) (*configProvider, error) {
 	var relayConfig RelayConfig
 	err := json.Unmarshal(args.RelayConfig, &relayConfig)
 	if err != nil {
@@ -150,41 +187,47 @@ func newConfigProvider(ctx context.Context, lggr logger.Logger, chainSet ChainSe
 	if err != nil {
 		return nil, err
 	}
-	chain, err := chainSet.Chain(ctx, relayConfig.ChainID)
+	chain, err := r.chainSet.Chain(r.ctx, relayConfig.ChainID)
 	if err != nil {
 		return nil, err
 	}
-	chainReader, err := chain.Reader(relayConfig.NodeName)
+	key := contractCacheKey{ChainID: relayConfig.ChainID, Contract: contractAddr.String(), NodeName: relayConfig.NodeName}
+	cache, err := r.contractCaches.acquire(key, func() (*sharedContractCache, error) {
+		return newSharedContractCache(r.ctx, r.lggr, chain, r.chainReaders, r.pollCoordinators, r.cacheStore, relayConfig, contractAddr)
+	})
 	if err != nil {
 		return nil, err
 	}
-	reader := NewOCR2Reader(contractAddr, chainReader, lggr)
-	contract := NewContractCache(chain.Config(), reader, lggr)
-	tracker := NewContractTracker(chainReader, contract)
+	tracker := NewContractTracker(cache.chainReader, cache.ContractCache)
 	digester := NewOffchainConfigDigester(relayConfig.ChainID, contractAddr)
 	return &configProvider{
 		digester:      digester,
 		tracker:       tracker,
-		lggr:          lggr,
-		contractCache: contract,
-		reader:        reader,
+		lggr:          r.lggr,
+		contractCache: cache.ContractCache,
+		reader:        cache.reader,
 		chain:         chain,
-		contractAddr:  contractAddr,
+		cache:         cache,
+		release: func() error {
+			tracker.release()
+			return r.contractCaches.release(key)
+		},
+		contractAddr: contractAddr,
 	}, nil
 }
 
 // Start starts OCR2Provider respecting the given context.
-func (p *configProvider) Start(context.Context) error {
+func (p *configProvider) Start(ctx context.Context) error {
 	return p.StartOnce("TerraRelay", func() error {
 		p.lggr.Debugf("Starting")
-		return p.contractCache.Start()
+		return p.cache.start(ctx)
 	})
 }
 
 func (p *configProvider) Close() error {
 	return p.StopOnce("TerraRelay", func() error {
 		p.lggr.Debugf("Stopping")
-		return p.contractCache.Close()
+		return p.release()
 	})
 }
 
@@ -0,0 +1,45 @@
+package terra
+
+import (
+	"testing"
+
+	cosmosSDK "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	wasmtypes "github.com/terra-money/core/x/wasm/types"
+)
+
+// fakeTxManager is a TxManager which is not an UrgentTxManager.
+type fakeTxManager struct {
+	TxManager
+	fakeMsgEnqueuer
+}
+
+func (f *fakeTxManager) Enqueue(contractID string, msg cosmosSDK.Msg) (int64, error) {
+	return f.fakeMsgEnqueuer.Enqueue(contractID, msg)
+}
+
+type fakeUrgentTxManager struct {
+	fakeTxManager
+	urgent []cosmosSDK.Msg
+}
+
+func (f *fakeUrgentTxManager) EnqueueUrgent(_ string, msg cosmosSDK.Msg) (int64, error) {
+	f.urgent = append(f.urgent, msg)
+	return int64(len(f.urgent)), nil
+}
+
+func TestEnqueueUrgent(t *testing.T) {
+	msg := &wasmtypes.MsgExecuteContract{}
+
+	txm := &fakeTxManager{}
+	_, err := EnqueueUrgent(txm, "contract", msg)
+	require.NoError(t, err)
+	assert.Len(t, txm.msgs, 1, "falls back to Enqueue")
+
+	urgent := &fakeUrgentTxManager{}
+	_, err = EnqueueUrgent(urgent, "contract", msg)
+	require.NoError(t, err)
+	assert.Len(t, urgent.urgent, 1)
+	assert.Empty(t, urgent.msgs)
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 
 	"github.com/smartcontractkit/libocr/bigbigendian"
+	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/offchainreporting2/reportingplugin/median"
 	"github.com/smartcontractkit/libocr/offchainreporting2/types"
 )
@@ -60,25 +61,61 @@ func (c ReportCodec) BuildReport(oo []median.ParsedAttributedObservation) (types
 		return oo[i].Value.Cmp(oo[j].Value) < 0
 	})
 
-	var observers [32]byte
-	var observations []*big.Int
-	for i, o := range oo {
-		observers[i] = byte(o.Observer)
-		observations = append(observations, o.Value)
+	parsed := ParsedReport{ObservationsTimestamp: timestamp, JuelsPerFeeCoin: juelsPerFeeCoin}
+	for _, o := range oo {
+		parsed.Observers = append(parsed.Observers, o.Observer)
+		parsed.Observations = append(parsed.Observations, o.Value)
+	}
+	return encodeReport(parsed)
+}
+
+// ParsedReport is a decoded report. See ReportCodec.DecodeReport.
+type ParsedReport struct {
+	ObservationsTimestamp uint32
+	// Observers are the oracles whose observations are included, in the same order as Observations.
+	Observers []commontypes.OracleID
+	// Observations are sorted in ascending order in reports from BuildReport.
+	Observations    []*big.Int
+	JuelsPerFeeCoin *big.Int
+}
+
+// Median returns the n//2-th ranked observation, which is the report's answer.
+func (r ParsedReport) Median() *big.Int {
+	return r.Observations[len(r.Observations)/2]
+}
+
+func (r ParsedReport) String() string {
+	return fmt.Sprintf("observations timestamp %d, median %s, observations %v, observers %v, juels per fee coin %s",
+		r.ObservationsTimestamp, r.Median(), r.Observations, r.Observers, r.JuelsPerFeeCoin)
+}
+
+// encodeReport encodes r as: uint32 timestamp, 32 bytes of observer indices (zero padded), uint8 number of
+// observations, int128 observations, int128 juels per fee coin; all big-endian.
+func encodeReport(r ParsedReport) (types.Report, error) {
+	n := len(r.Observations)
+	if n == 0 || n > observersSizeBytes {
+		return nil, fmt.Errorf("cannot encode report with %d observations", n)
+	}
+	if len(r.Observers) != n {
+		return nil, fmt.Errorf("cannot encode report with %d observers for %d observations", len(r.Observers), n)
+	}
+	var observers [observersSizeBytes]byte
+	for i, o := range r.Observers {
+		observers[i] = byte(o)
 	}
 
 	// Add timestamp
 	var report []byte
 	time := make([]byte, 4)
-	binary.BigEndian.PutUint32(time, timestamp)
+	binary.BigEndian.PutUint32(time, r.ObservationsTimestamp)
 	report = append(report, time[:]...)
 
 	// Add observers
 	report = append(report, observers[:]...)
 	// Add length of observations
-	report = append(report, byte(len(observations)))
+	report = append(report, byte(n))
 	// Add observations
-	for _, o := range observations {
+	for _, o := range r.Observations {
 		obs, err := newObservationFromInt(o)
 		if err != nil {
 			return nil, err
@@ -87,7 +124,7 @@ func (c ReportCodec) BuildReport(oo []median.ParsedAttributedObservation) (types
 	}
 
 	// Add juels per fee coin value
-	jBytes, err := bigbigendian.SerializeSigned(juelsPerFeeCoinSizeBytes, juelsPerFeeCoin)
+	jBytes, err := bigbigendian.SerializeSigned(juelsPerFeeCoinSizeBytes, r.JuelsPerFeeCoin)
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +132,46 @@ func (c ReportCodec) BuildReport(oo []median.ParsedAttributedObservation) (types
 	return report, nil
 }
 
+// DecodeReport decodes all fields of a report. Unlike MedianFromReport, it is as strict as the contract: the report
+// must be exactly as long as its observations require. Decoding is the inverse of the encoding used by BuildReport,
+// so any report which decodes is re-encoded to the same bytes, except for unused observer bytes, which the
+// contract ignores and BuildReport leaves zero.
+func (c ReportCodec) DecodeReport(report types.Report) (ParsedReport, error) {
+	rLen := len(report)
+	if rLen < prefixSizeBytes {
+		return ParsedReport{}, fmt.Errorf("report length %d is less than the minimum %d", rLen, prefixSizeBytes)
+	}
+	n := int(report[timestampSizeBytes+observersSizeBytes])
+	if n == 0 {
+		return ParsedReport{}, fmt.Errorf("report has no observations")
+	}
+	if n > observersSizeBytes {
+		return ParsedReport{}, fmt.Errorf("report has %d observations, but at most %d observers", n, observersSizeBytes)
+	}
+	if expected := c.MaxReportLength(n); rLen != expected {
+		return ParsedReport{}, fmt.Errorf("report length %d does not match %d for %d observations", rLen, expected, n)
+	}
+
+	parsed := ParsedReport{ObservationsTimestamp: binary.BigEndian.Uint32(report[:timestampSizeBytes])}
+	for _, o := range report[timestampSizeBytes : timestampSizeBytes+n] {
+		parsed.Observers = append(parsed.Observers, commontypes.OracleID(o))
+	}
+	for i := 0; i < n; i++ {
+		start := prefixSizeBytes + observationSizeBytes*i
+		o, err := observation(report[start : start+observationSizeBytes]).ToBigInt()
+		if err != nil {
+			return ParsedReport{}, err
+		}
+		parsed.Observations = append(parsed.Observations, o)
+	}
+	juels, err := bigbigendian.DeserializeSigned(juelsPerFeeCoinSizeBytes, report[rLen-juelsPerFeeCoinSizeBytes:])
+	if err != nil {
+		return ParsedReport{}, err
+	}
+	parsed.JuelsPerFeeCoin = juels
+	return parsed, nil
+}
+
 func (c ReportCodec) MaxReportLength(n int) int {
 	return prefixSizeBytes + (n * observationSizeBytes) + juelsPerFeeCoinSizeBytes
 }
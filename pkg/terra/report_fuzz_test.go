@@ -33,3 +33,27 @@ func FuzzReportCodecMedianFromReport(f *testing.F) {
 		}
 	})
 }
+
+func FuzzReportCodecDecodeReport(f *testing.F) {
+	cdc := ReportCodec{}
+	report, err := cdc.BuildReport([]median.ParsedAttributedObservation{
+		{Timestamp: uint32(time.Now().Unix()), Value: big.NewInt(10), JuelsPerFeeCoin: big.NewInt(100000), Observer: 2},
+		{Timestamp: uint32(time.Now().Unix()), Value: big.NewInt(-10), JuelsPerFeeCoin: big.NewInt(200000), Observer: 0},
+		{Timestamp: uint32(time.Now().Unix()), Value: big.NewInt(11), JuelsPerFeeCoin: big.NewInt(300000), Observer: 1}})
+	require.NoError(f, err)
+
+	// Seed with valid report
+	f.Add([]byte(report))
+	f.Fuzz(func(t *testing.T, report []byte) {
+		parsed, err := cdc.DecodeReport(report)
+		if err == nil {
+			// Any report which decodes must re-encode to the same bytes, apart from unused observers
+			encoded, err := encodeReport(parsed)
+			require.NoError(t, err)
+			expected := append([]byte{}, report...)
+			n := len(parsed.Observations)
+			copy(expected[timestampSizeBytes+n:timestampSizeBytes+observersSizeBytes], make([]byte, observersSizeBytes-n))
+			require.Equal(t, expected, []byte(encoded))
+		}
+	})
+}
@@ -161,3 +161,76 @@ func TestMedianFromReport(t *testing.T) {
 }
 
 // TODO: TestHashReport - part of Solana report test suite
+
+func TestDecodeReport(t *testing.T) {
+	c := ReportCodec{}
+	oo := []median.ParsedAttributedObservation{
+		{Timestamp: 300, Value: big.NewInt(30), JuelsPerFeeCoin: big.NewInt(3000), Observer: 4},
+		{Timestamp: 100, Value: big.NewInt(-10), JuelsPerFeeCoin: big.NewInt(1000), Observer: 7},
+		{Timestamp: 200, Value: big.NewInt(20), JuelsPerFeeCoin: big.NewInt(2000), Observer: 1},
+	}
+	report, err := c.BuildReport(oo)
+	require.NoError(t, err)
+
+	parsed, err := c.DecodeReport(report)
+	require.NoError(t, err)
+	assert.Equal(t, ParsedReport{
+		ObservationsTimestamp: 200,
+		Observers:             []commontypes.OracleID{7, 1, 4},
+		Observations:          []*big.Int{big.NewInt(-10), big.NewInt(20), big.NewInt(30)},
+		JuelsPerFeeCoin:       big.NewInt(2000),
+	}, parsed)
+	med, err := c.MedianFromReport(report)
+	require.NoError(t, err)
+	assert.Equal(t, med, parsed.Median())
+
+	// round trip
+	encoded, err := encodeReport(parsed)
+	require.NoError(t, err)
+	assert.Equal(t, report, encoded)
+
+	t.Run("on chain report", func(t *testing.T) {
+		parsed, err := c.DecodeReport(types.Report{
+			97, 91, 43, 83, // observations_timestamp
+			0, 1, 2, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // observers
+			2,                                                   // len
+			0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 73, 150, 2, 210, // observation 1
+			0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 73, 150, 2, 210, // observation 2
+			0, 0, 0, 0, 0, 0, 0, 0, 13, 224, 182, 179, 167, 100, 0, 0, // juels per luna (1 with 18 decimal places)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1633364819), parsed.ObservationsTimestamp)
+		assert.Equal(t, []commontypes.OracleID{0, 1}, parsed.Observers)
+		assert.Equal(t, "1234567890", parsed.Median().String())
+		assert.Equal(t, "1000000000000000000", parsed.JuelsPerFeeCoin.String())
+
+		// unused observers are ignored
+		encoded, err := encodeReport(parsed)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0, 1, 0, 0}, []byte(encoded[timestampSizeBytes:timestampSizeBytes+4]))
+	})
+
+	for _, tt := range []struct {
+		name   string
+		modify func(types.Report) types.Report
+		err    string
+	}{
+		{"too short", func(r types.Report) types.Report { return r[:prefixSizeBytes-1] }, "less than the minimum"},
+		{"no observations", func(r types.Report) types.Report {
+			r[prefixSizeBytes-1] = 0
+			return r
+		}, "no observations"},
+		{"too many observations", func(r types.Report) types.Report {
+			r[prefixSizeBytes-1] = 33
+			return r
+		}, "at most 32 observers"},
+		{"missing juels", func(r types.Report) types.Report { return r[:len(r)-1] }, "does not match"},
+		{"trailing bytes", func(r types.Report) types.Report { return append(r, 0) }, "does not match"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.DecodeReport(tt.modify(append(types.Report{}, report...)))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package terra
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	cosmosSDK "github.com/cosmos/cosmos-sdk/types"
+	"go.uber.org/multierr"
+
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
+)
+
+// shared is a registry of values which are shared by key. Each value is created by the first acquire,
+// and closed by the last release.
+type shared[K comparable, V io.Closer] struct {
+	mu   sync.Mutex
+	vals map[K]*sharedVal[V]
+}
+
+type sharedVal[V io.Closer] struct {
+	refs  int
+	ready chan struct{} // closed once val and err are set by create
+	val   V
+	err   error
+}
+
+func newShared[K comparable, V io.Closer]() *shared[K, V] {
+	return &shared[K, V]{vals: make(map[K]*sharedVal[V])}
+}
+
+// acquire returns the value for key, calling create if there is none. Each successful acquire must be paired with
+// a release. create is called without holding the registry's lock, so it may be slow, and concurrent acquires of the
+// same key wait for its result.
+func (s *shared[K, V]) acquire(key K, create func() (V, error)) (V, error) {
+	s.mu.Lock()
+	if sv, ok := s.vals[key]; ok {
+		sv.refs++
+		s.mu.Unlock()
+		<-sv.ready
+		return sv.val, sv.err
+	}
+	sv := &sharedVal[V]{refs: 1, ready: make(chan struct{})}
+	s.vals[key] = sv
+	s.mu.Unlock()
+
+	sv.val, sv.err = create()
+	if sv.err != nil {
+		// Failures are not registered, so the next acquire tries again.
+		s.mu.Lock()
+		delete(s.vals, key)
+		s.mu.Unlock()
+	}
+	close(sv.ready)
+	return sv.val, sv.err
+}
+
+// release closes the value for key, once it has been released as many times as it was acquired.
+func (s *shared[K, V]) release(key K) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sv, ok := s.vals[key]
+	if !ok {
+		return fmt.Errorf("release of %v which was not acquired", key)
+	}
+	sv.refs--
+	if sv.refs > 0 {
+		return nil
+	}
+	delete(s.vals, key)
+	return sv.val.Close()
+}
+
+// chainReaderKey identifies the node(s) read from by a shared chain reader.
+type chainReaderKey struct {
+	ChainID  string
+	NodeName string // empty for all of the chain's nodes
+}
+
+// sharedChainReader is a chain's reader for the node(s) of a chainReaderKey, which owns its MultiNodeClient, if any.
+// It is shared by the PollCoordinator and ContractCaches reading from the same node(s).
+type sharedChainReader struct {
+	client.Reader
+	multiNode *client.MultiNodeClient
+}
+
+func newSharedChainReader(ctx context.Context, lggr logger.Logger, chain Chain, key chainReaderKey) (*sharedChainReader, error) {
+	reader, multiNode, err := newChainReader(ctx, lggr, chain, key.ChainID, key.NodeName)
+	if err != nil {
+		return nil, err
+	}
+	if multiNode != nil {
+		if err = multiNode.Start(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &sharedChainReader{Reader: reader, multiNode: multiNode}, nil
+}
+
+func (r *sharedChainReader) Close() error {
+	if r.multiNode != nil {
+		return r.multiNode.Close()
+	}
+	return nil
+}
+
+// sharedPollCoordinator is a PollCoordinator which holds its chain reader.
+type sharedPollCoordinator struct {
+	*PollCoordinator
+	release func() error // releases the chain reader
+}
+
+func newSharedPollCoordinator(ctx context.Context, lggr logger.Logger, chain Chain, readers *shared[chainReaderKey, *sharedChainReader], key chainReaderKey) (*sharedPollCoordinator, error) {
+	reader, err := readers.acquire(key, func() (*sharedChainReader, error) {
+		return newSharedChainReader(ctx, lggr, chain, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	pc := &sharedPollCoordinator{PollCoordinator: NewPollCoordinator(reader, chain.Config(), lggr), release: func() error { return readers.release(key) }}
+	if err = pc.PollCoordinator.Start(); err != nil {
+		return nil, multierr.Combine(err, pc.release())
+	}
+	return pc, nil
+}
+
+func (pc *sharedPollCoordinator) Close() error {
+	return multierr.Combine(pc.PollCoordinator.Close(), pc.release())
+}
+
+// contractCacheKey identifies a contract, and the node it is read from, whose ContractCache is shared by all of its providers.
+type contractCacheKey struct {
+	ChainID  string
+	Contract string
+	NodeName string // empty for all of the chain's nodes
+}
+
+// sharedContractCache is a ContractCache, along with the readers it holds, which is shared by all providers for the
+// same contract and node(s), e.g. both bootstrap and oracle jobs.
+type sharedContractCache struct {
+	*ContractCache
+	reader      *OCR2Reader
+	chainReader client.Reader
+	release     func() error // releases the chain reader, and the poll coordinator if any
+
+	mu       sync.Mutex
+	started  bool
+	startErr error
+}
+
+func newSharedContractCache(ctx context.Context, lggr logger.Logger, chain Chain, readers *shared[chainReaderKey, *sharedChainReader],
+	coordinators *shared[chainReaderKey, *sharedPollCoordinator], store ContractCacheStore, relayConfig RelayConfig, contractAddr cosmosSDK.AccAddress) (*sharedContractCache, error) {
+	key := chainReaderKey{ChainID: relayConfig.ChainID, NodeName: relayConfig.NodeName}
+	chainReader, err := readers.acquire(key, func() (*sharedChainReader, error) {
+		return newSharedChainReader(ctx, lggr, chain, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sc := &sharedContractCache{chainReader: chainReader, release: func() error { return readers.release(key) }}
+	if cfg := chain.Config(); cfg.TrustedBlockHeight() > 0 || cfg.TrustedBlockHash() != nil {
+		verifier, err2 := client.NewProofVerifier(ctx, chainReader, relayConfig.ChainID, cfg.TrustedBlockHeight(), cfg.TrustedBlockHash())
+		if err2 != nil {
+			return nil, multierr.Combine(fmt.Errorf("failed to initialize verified reads: %w", err2), sc.release())
+		}
+		sc.reader = NewVerifiedOCR2Reader(contractAddr, chainReader, verifier, cfg, lggr)
+		// proofs are read separately, so there's nothing to batch
+		sc.ContractCache = NewContractCache(cfg, sc.reader, lggr)
+		if store != nil {
+			sc.ContractCache.withStore(relayConfig.ChainID, store)
+		}
+		return sc, nil
+	}
+	sc.reader = NewOCR2Reader(contractAddr, chainReader, chain.Config(), lggr)
+	coordinator, err := coordinators.acquire(key, func() (*sharedPollCoordinator, error) {
+		return newSharedPollCoordinator(ctx, lggr, chain, readers, key)
+	})
+	if err != nil {
+		return nil, multierr.Combine(err, sc.release())
+	}
+	sc.ContractCache = NewBatchedContractCache(chain.Config(), sc.reader, coordinator.PollCoordinator, lggr)
+	if store != nil {
+		sc.ContractCache.withStore(relayConfig.ChainID, store)
+	}
+	releaseReader := sc.release
+	sc.release = func() error { return multierr.Combine(coordinators.release(key), releaseReader()) }
+	return sc, nil
+}
+
+// start starts the cache on the first call, and returns the same result from later calls.
+func (sc *sharedContractCache) start(ctx context.Context) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.started {
+		return sc.startErr
+	}
+	sc.started = true
+	sc.startErr = sc.ContractCache.Start()
+	return sc.startErr
+}
+
+func (sc *sharedContractCache) Close() error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	var err error
+	if sc.started {
+		err = sc.ContractCache.Close()
+	}
+	return multierr.Combine(err, sc.release())
+}
+
+// newChainReader returns a reader for the named node, or a MultiNodeClient for all nodes if nodeName is empty
+// and chain is a NodesChain.
+func newChainReader(ctx context.Context, lggr logger.Logger, chain Chain, chainID, nodeName string) (client.Reader, *client.MultiNodeClient, error) {
+	nodesChain, ok := chain.(NodesChain)
+	if nodeName != "" || !ok {
+		reader, err := chain.Reader(nodeName)
+		return reader, nil, err
+	}
+	nodes, err := nodesChain.Nodes(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	multiNode, err := client.NewMultiNodeClient(chainID, nodes, client.DefaultTimeout, lggr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return multiNode, multiNode, nil
+}
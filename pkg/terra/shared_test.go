@@ -0,0 +1,136 @@
+package terra
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+type countingCloser struct{ closed int }
+
+func (c *countingCloser) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestShared(t *testing.T) {
+	s := newShared[string, *countingCloser]()
+	var created int
+	create := func() (*countingCloser, error) {
+		created++
+		return &countingCloser{}, nil
+	}
+
+	a, err := s.acquire("a", create)
+	require.NoError(t, err)
+	a2, err := s.acquire("a", create)
+	require.NoError(t, err)
+	assert.Same(t, a, a2)
+	b, err := s.acquire("b", create)
+	require.NoError(t, err)
+	assert.NotSame(t, a, b)
+	assert.Equal(t, 2, created)
+
+	require.NoError(t, s.release("a"))
+	assert.Equal(t, 0, a.closed)
+	require.NoError(t, s.release("a"))
+	assert.Equal(t, 1, a.closed)
+	assert.Equal(t, 0, b.closed)
+	assert.Error(t, s.release("a"))
+
+	// Recreated after being closed.
+	a3, err := s.acquire("a", create)
+	require.NoError(t, err)
+	assert.NotSame(t, a, a3)
+	assert.Equal(t, 3, created)
+
+	// Failures are not registered.
+	_, err = s.acquire("c", func() (*countingCloser, error) { return nil, errors.New("boom") })
+	require.Error(t, err)
+	assert.Error(t, s.release("c"))
+}
+
+func TestShared_concurrentCreate(t *testing.T) {
+	s := newShared[string, *countingCloser]()
+	creating, unblock := make(chan struct{}), make(chan struct{})
+	slow := &countingCloser{}
+	go func() {
+		_, _ = s.acquire("slow", func() (*countingCloser, error) {
+			close(creating)
+			<-unblock
+			return slow, nil
+		})
+	}()
+	<-creating
+
+	// Other keys are not blocked by a slow create.
+	_, err := s.acquire("fast", func() (*countingCloser, error) { return &countingCloser{}, nil })
+	require.NoError(t, err)
+
+	// The same key waits for it, instead of creating another.
+	got := make(chan *countingCloser)
+	go func() {
+		v, err := s.acquire("slow", func() (*countingCloser, error) { return nil, errors.New("created twice") })
+		assert.NoError(t, err)
+		got <- v
+	}()
+	close(unblock)
+	assert.Same(t, slow, <-got)
+	require.NoError(t, s.release("slow"))
+	require.NoError(t, s.release("slow"))
+	assert.Equal(t, 1, slow.closed)
+}
+
+// readerChain is a Chain which only implements Reader, and records the nodes requested.
+type readerChain struct {
+	Chain
+	reader client.Reader
+	names  []string
+}
+
+func (c *readerChain) Reader(nodeName string) (client.Reader, error) {
+	c.names = append(c.names, nodeName)
+	return c.reader, nil
+}
+
+type nodesChain struct {
+	*readerChain
+	nodes []db.Node
+}
+
+func (c *nodesChain) Nodes(context.Context) ([]db.Node, error) { return c.nodes, nil }
+
+func TestNewChainReader(t *testing.T) {
+	ctx := context.Background()
+	lggr := logger.Test(t)
+	reader := &client.Client{}
+
+	chain := &readerChain{reader: reader}
+	r, multiNode, err := newChainReader(ctx, lggr, chain, "chain", "node")
+	require.NoError(t, err)
+	assert.Same(t, reader, r)
+	assert.Nil(t, multiNode)
+	// Chains which cannot list their nodes choose one.
+	r, multiNode, err = newChainReader(ctx, lggr, chain, "chain", "")
+	require.NoError(t, err)
+	assert.Same(t, reader, r)
+	assert.Nil(t, multiNode)
+	assert.Equal(t, []string{"node", ""}, chain.names)
+
+	nc := &nodesChain{readerChain: &readerChain{reader: reader}, nodes: []db.Node{
+		{Name: "a", TerraChainID: "chain", TendermintURL: "http://a.invalid"},
+		{Name: "b", TerraChainID: "chain", TendermintURL: "http://b.invalid"},
+	}}
+	r, multiNode, err = newChainReader(ctx, lggr, nc, "chain", "")
+	require.NoError(t, err)
+	require.NotNil(t, multiNode)
+	assert.Same(t, multiNode, r)
+	assert.Empty(t, nc.names)
+}
@@ -0,0 +1,309 @@
+package txm
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+// Key layout, within a prefix per chain:
+//
+//	seq                -> last id (uint64 big endian)
+//	msg/<id>           -> json(db.Msg)
+//	state/<state>/<id> -> nil, an index of msgs by state in id order
+//	urgent/<state>/<id> -> nil, the same index for urgent msgs only
+var (
+	seqKey       = []byte("seq")
+	msgPrefix    = []byte("msg/")
+	statePrefix  = []byte("state/")
+	urgentPrefix = []byte("urgent/")
+	errNotExists = errors.New("msg does not exist")
+)
+
+// Store persists msgs for a single chain in an embedded key-value store.
+type Store struct {
+	chainID string
+	db      dbm.DB
+	mu      sync.Mutex // serializes writes
+}
+
+// NewStore returns a Store for chainID, backed by kv. Multiple chains may share the same kv.
+func NewStore(chainID string, kv dbm.DB) *Store {
+	return &Store{
+		chainID: chainID,
+		db:      dbm.NewPrefixDB(kv, []byte(chainID+"/")),
+	}
+}
+
+// InsertMsg inserts a new Unstarted msg and returns its id.
+func (s *Store) InsertMsg(contractID, typeURL string, msg []byte, urgent bool) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seq, err := s.db.Get(seqKey)
+	if err != nil {
+		return 0, err
+	}
+	var id int64 = 1
+	if seq != nil {
+		id = int64(binary.BigEndian.Uint64(seq)) + 1
+	}
+	now := time.Now()
+	m := db.Msg{
+		ID:         id,
+		ChainID:    s.chainID,
+		ContractID: contractID,
+		State:      db.Unstarted,
+		Type:       typeURL,
+		Raw:        msg,
+		Urgent:     urgent,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return 0, err
+	}
+	batch := s.db.NewBatch()
+	defer batch.Close()
+	if err = batch.Set(seqKey, idBytes(id)); err != nil {
+		return 0, err
+	}
+	if err = batch.Set(msgKey(id), b); err != nil {
+		return 0, err
+	}
+	if err = batch.Set(stateKey(db.Unstarted, id), []byte{}); err != nil {
+		return 0, err
+	}
+	if urgent {
+		if err = batch.Set(urgentKey(db.Unstarted, id), []byte{}); err != nil {
+			return 0, err
+		}
+	}
+	return id, batch.WriteSync()
+}
+
+// GetMsgsState returns up to limit msgs in state, oldest first.
+func (s *Store) GetMsgsState(state db.State, limit int64) ([]db.Msg, error) {
+	return s.GetMsgsStateAfter(state, 0, limit)
+}
+
+// GetMsgsStateAfter returns up to limit msgs in state with ids greater than afterID, oldest first,
+// for paging through all msgs in state.
+func (s *Store) GetMsgsStateAfter(state db.State, afterID, limit int64) ([]db.Msg, error) {
+	return s.getIndexedMsgs(statePrefixKey(state), afterID, limit)
+}
+
+// GetUrgentMsgsState returns up to limit urgent msgs in state, oldest first.
+func (s *Store) GetUrgentMsgsState(state db.State, limit int64) ([]db.Msg, error) {
+	return s.getIndexedMsgs(urgentPrefixKey(state), 0, limit)
+}
+
+// getIndexedMsgs returns up to limit msgs from the index with prefix, with ids greater than afterID, oldest first.
+func (s *Store) getIndexedMsgs(prefix []byte, afterID, limit int64) ([]db.Msg, error) {
+	it, err := s.db.Iterator(append(append([]byte{}, prefix...), idBytes(afterID+1)...), prefixEnd(prefix))
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for ; it.Valid() && int64(len(ids)) < limit; it.Next() {
+		ids = append(ids, int64(binary.BigEndian.Uint64(it.Key()[len(prefix):])))
+	}
+	err = it.Error()
+	if cerr := it.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetMsgs(ids...)
+}
+
+// GetMsgs returns the msgs with ids, skipping any which do not exist.
+func (s *Store) GetMsgs(ids ...int64) ([]db.Msg, error) {
+	var msgs []db.Msg
+	for _, id := range ids {
+		m, err := s.getMsg(id)
+		if errors.Is(err, errNotExists) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
+
+func (s *Store) getMsg(id int64) (m db.Msg, err error) {
+	b, err := s.db.Get(msgKey(id))
+	if err != nil {
+		return
+	}
+	if b == nil {
+		err = errNotExists
+		return
+	}
+	err = json.Unmarshal(b, &m)
+	return
+}
+
+// UpdateMsgs sets the state of the msgs with ids, as well as the tx hash if not nil.
+// Each distinct tx hash is also recorded in TxHashes.
+func (s *Store) UpdateMsgs(ids []int64, state db.State, txHash *string) error {
+	if state == db.Broadcasted && txHash == nil {
+		return errors.New("txHash is required when updating to broadcasted")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	batch := s.db.NewBatch()
+	defer batch.Close()
+	now := time.Now()
+	for _, id := range ids {
+		m, err := s.getMsg(id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get msg %d", id)
+		}
+		if err = batch.Delete(stateKey(m.State, id)); err != nil {
+			return err
+		}
+		if m.Urgent {
+			if err = batch.Delete(urgentKey(m.State, id)); err != nil {
+				return err
+			}
+		}
+		m.State = state
+		m.UpdatedAt = now
+		if txHash != nil {
+			m.TxHash = txHash
+			if !contains(m.TxHashes, *txHash) {
+				m.TxHashes = append(m.TxHashes, *txHash)
+			}
+		}
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if err = batch.Set(msgKey(id), b); err != nil {
+			return err
+		}
+		if err = batch.Set(stateKey(state, id), []byte{}); err != nil {
+			return err
+		}
+		if m.Urgent {
+			if err = batch.Set(urgentKey(state, id), []byte{}); err != nil {
+				return err
+			}
+		}
+	}
+	return batch.WriteSync()
+}
+
+// PruneMsgs deletes Confirmed and Errored msgs which were last updated before before, and returns how many.
+func (s *Store) PruneMsgs(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	batch := s.db.NewBatch()
+	defer batch.Close()
+	var pruned int
+	for _, state := range []db.State{db.Confirmed, db.Errored} {
+		ids, err := s.terminalMsgsBefore(state, before)
+		if err != nil {
+			return 0, err
+		}
+		for _, id := range ids {
+			if err = batch.Delete(msgKey(id)); err != nil {
+				return 0, err
+			}
+			if err = batch.Delete(stateKey(state, id)); err != nil {
+				return 0, err
+			}
+			if err = batch.Delete(urgentKey(state, id)); err != nil {
+				return 0, err
+			}
+		}
+		pruned += len(ids)
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+	return pruned, batch.WriteSync()
+}
+
+// terminalMsgsBefore returns the ids of msgs in state which were last updated before before. Ids are assigned in
+// creation order, so the scan stops at the first msg created since before.
+func (s *Store) terminalMsgsBefore(state db.State, before time.Time) (ids []int64, err error) {
+	prefix := statePrefixKey(state)
+	it, err := s.db.Iterator(prefix, prefixEnd(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := it.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	for ; it.Valid(); it.Next() {
+		id := int64(binary.BigEndian.Uint64(it.Key()[len(prefix):]))
+		m, err := s.getMsg(id)
+		if errors.Is(err, errNotExists) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if !m.CreatedAt.Before(before) {
+			break
+		}
+		if m.UpdatedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, it.Error()
+}
+
+func idBytes(id int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
+}
+
+func msgKey(id int64) []byte {
+	return append(append([]byte{}, msgPrefix...), idBytes(id)...)
+}
+
+func statePrefixKey(state db.State) []byte {
+	return []byte(fmt.Sprintf("%s%s/", statePrefix, state))
+}
+
+func stateKey(state db.State, id int64) []byte {
+	return append(statePrefixKey(state), idBytes(id)...)
+}
+
+func urgentPrefixKey(state db.State) []byte {
+	return []byte(fmt.Sprintf("%s%s/", urgentPrefix, state))
+}
+
+func urgentKey(state db.State, id int64) []byte {
+	return append(urgentPrefixKey(state), idBytes(id)...)
+}
+
+// prefixEnd returns the end of the range of keys beginning with prefix, which must end with '/'.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	end[len(end)-1]++
+	return end
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
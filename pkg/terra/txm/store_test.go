@@ -0,0 +1,127 @@
+package txm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+func TestStore(t *testing.T) {
+	kv := dbm.NewMemDB()
+	s := NewStore("chain-1", kv)
+	other := NewStore("chain-2", kv)
+
+	var ids []int64
+	for _, raw := range []string{"a", "b", "c"} {
+		id, err := s.InsertMsg("contract", "/type", []byte(raw), false)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+	id, err := other.InsertMsg("contract", "/type", []byte("d"), false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id, "chains have independent ids")
+
+	unstarted, err := s.GetMsgsState(db.Unstarted, 2)
+	require.NoError(t, err)
+	require.Len(t, unstarted, 2)
+	assert.Equal(t, int64(1), unstarted[0].ID)
+	assert.Equal(t, []byte("a"), unstarted[0].Raw)
+	assert.Equal(t, "chain-1", unstarted[0].ChainID)
+	assert.Equal(t, int64(2), unstarted[1].ID)
+
+	txHash := "hash"
+	require.Error(t, s.UpdateMsgs([]int64{1}, db.Broadcasted, nil), "tx hash is required")
+	require.NoError(t, s.UpdateMsgs([]int64{1, 3}, db.Broadcasted, &txHash))
+	require.Error(t, s.UpdateMsgs([]int64{4}, db.Started, nil), "msg does not exist")
+
+	unstarted, err = s.GetMsgsState(db.Unstarted, 10)
+	require.NoError(t, err)
+	require.Len(t, unstarted, 1)
+	assert.Equal(t, int64(2), unstarted[0].ID)
+
+	broadcasted, err := s.GetMsgsState(db.Broadcasted, 10)
+	require.NoError(t, err)
+	require.Len(t, broadcasted, 2)
+	assert.Equal(t, int64(1), broadcasted[0].ID)
+	assert.Equal(t, int64(3), broadcasted[1].ID)
+	require.NotNil(t, broadcasted[1].TxHash)
+	assert.Equal(t, txHash, *broadcasted[1].TxHash)
+
+	broadcasted, err = s.GetMsgsStateAfter(db.Broadcasted, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, broadcasted, 1)
+	assert.Equal(t, int64(3), broadcasted[0].ID)
+	broadcasted, err = s.GetMsgsStateAfter(db.Broadcasted, 3, 10)
+	require.NoError(t, err)
+	assert.Empty(t, broadcasted)
+
+	require.NoError(t, s.UpdateMsgs([]int64{1}, db.Confirmed, nil))
+	msgs, err := s.GetMsgs(1, 5)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, db.Confirmed, msgs[0].State)
+	require.NotNil(t, msgs[0].TxHash, "tx hash is kept")
+
+	// reopen
+	s = NewStore("chain-1", kv)
+	id, err = s.InsertMsg("contract", "/type", []byte("e"), true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), id)
+
+	urgent, err := s.GetUrgentMsgsState(db.Unstarted, 10)
+	require.NoError(t, err)
+	require.Len(t, urgent, 1)
+	assert.Equal(t, int64(4), urgent[0].ID)
+	assert.True(t, urgent[0].Urgent)
+
+	// the urgent index follows the state
+	require.NoError(t, s.UpdateMsgs([]int64{4}, db.Started, nil))
+	urgent, err = s.GetUrgentMsgsState(db.Unstarted, 10)
+	require.NoError(t, err)
+	assert.Empty(t, urgent)
+	urgent, err = s.GetUrgentMsgsState(db.Started, 10)
+	require.NoError(t, err)
+	require.Len(t, urgent, 1)
+	assert.Equal(t, int64(4), urgent[0].ID)
+}
+
+func TestStore_PruneMsgs(t *testing.T) {
+	s := NewStore("chain", dbm.NewMemDB())
+	for _, raw := range []string{"a", "b", "c", "d"} {
+		_, err := s.InsertMsg("contract", "/type", []byte(raw), false)
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.UpdateMsgs([]int64{1}, db.Confirmed, nil))
+	require.NoError(t, s.UpdateMsgs([]int64{2}, db.Errored, nil))
+	time.Sleep(time.Millisecond)
+	before := time.Now()
+	time.Sleep(time.Millisecond)
+	// finished since before
+	require.NoError(t, s.UpdateMsgs([]int64{3}, db.Confirmed, nil))
+	id, err := s.InsertMsg("contract", "/type", []byte("e"), false)
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateMsgs([]int64{id}, db.Errored, nil))
+
+	pruned, err := s.PruneMsgs(before)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pruned)
+	msgs, err := s.GetMsgs(1, 2, 3, 4, 5)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, int64(3), msgs[0].ID)
+	assert.Equal(t, db.Unstarted, msgs[1].State, "not terminal")
+	errored, err := s.GetMsgsState(db.Errored, 10)
+	require.NoError(t, err)
+	require.Len(t, errored, 1)
+	assert.Equal(t, int64(5), errored[0].ID)
+
+	pruned, err = s.PruneMsgs(before)
+	require.NoError(t, err)
+	assert.Zero(t, pruned)
+}
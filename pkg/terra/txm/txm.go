@@ -0,0 +1,800 @@
+package txm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/x/authz"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	dbm "github.com/tendermint/tm-db"
+	wasmtypes "github.com/terra-money/core/x/wasm/types"
+
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/smartcontractkit/chainlink-relay/pkg/utils"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+var _ terra.UrgentTxManager = (*Txm)(nil)
+
+// pruneInterval is how often msgs past TxMsgRetention are pruned.
+const pruneInterval = time.Hour
+
+var promBroadcastErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "terra_txm_broadcast_errors",
+	Help: "The number of txs which failed to broadcast, by client.ErrorClass.",
+}, []string{"chain_id", "class"})
+
+// Keystore provides the signers for txs.
+type Keystore interface {
+	// Get returns the signer for the bech32 address.
+	Get(address string) (client.Signer, error)
+}
+
+// Txm is a terra.TxManager which persists msgs in a Store, and periodically broadcasts
+// them in batches of up to MaxMsgsPerBatch, one tx per sender. Sequence numbers are tracked locally,
+// so a sender's next batch may be broadcast before the previous one is confirmed.
+type Txm struct {
+	utils.StartStopOnce
+	chainID  string
+	store    *Store
+	tc       client.ReaderWriter
+	seqs     *client.SequenceManager
+	gas      *client.GasEstimator
+	gpe      client.GasPricesEstimator
+	keystore Keystore
+	cfg      terra.Config
+	lggr     logger.Logger
+
+	pending int64         // msgs enqueued since the last batch
+	full    chan struct{} // signalled when a full batch is pending
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewTxm returns a Txm for chainID, which persists msgs in kv.
+func NewTxm(chainID string, kv dbm.DB, tc client.ReaderWriter, gpe client.GasPricesEstimator, keystore Keystore, cfg terra.Config, lggr logger.Logger) *Txm {
+	return &Txm{
+		chainID:  chainID,
+		store:    NewStore(chainID, kv),
+		tc:       tc,
+		seqs:     client.NewSequenceManager(tc),
+		gas:      client.NewGasEstimator(tc, lggr),
+		gpe:      gpe,
+		keystore: keystore,
+		cfg:      cfg,
+		lggr:     logger.With(lggr, "service", "Txm"),
+		full:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start resumes any msgs left in flight by a previous run, and starts broadcasting queued msgs, and pruning old ones.
+func (txm *Txm) Start(context.Context) error {
+	return txm.StartOnce("Txm", func() error {
+		txm.wg.Add(2)
+		go txm.run()
+		go txm.prune()
+		return nil
+	})
+}
+
+// Close stops broadcasting and waits for any in flight batch or confirmation to finish.
+func (txm *Txm) Close() error {
+	return txm.StopOnce("Txm", func() error {
+		close(txm.stop)
+		txm.wg.Wait()
+		return nil
+	})
+}
+
+func (txm *Txm) run() {
+	defer txm.wg.Done()
+	ctx, cancel := utils.ContextFromChan(txm.stop)
+	defer cancel()
+	txm.resume(ctx)
+	tick := time.After(0)
+	for {
+		select {
+		case <-txm.stop:
+			return
+		case <-txm.full:
+		case <-tick:
+		}
+		atomic.StoreInt64(&txm.pending, 0)
+		txm.sendMsgBatch(ctx)
+		// No point broadcasting more than once per block.
+		tick = time.After(utils.WithJitter(txm.cfg.BlockRate()))
+	}
+}
+
+// prune periodically deletes msgs which have been Confirmed or Errored for longer than TxMsgRetention.
+func (txm *Txm) prune() {
+	defer txm.wg.Done()
+	tick := time.After(0)
+	for {
+		select {
+		case <-txm.stop:
+			return
+		case <-tick:
+		}
+		retention := txm.cfg.TxMsgRetention()
+		if pruned, err := txm.store.PruneMsgs(time.Now().Add(-retention)); err != nil {
+			txm.lggr.Errorw("Failed to prune msgs", "err", err)
+		} else if pruned > 0 {
+			txm.lggr.Debugw("Pruned msgs", "msgs", pruned, "retention", retention)
+		}
+		tick = time.After(utils.WithJitter(pruneInterval))
+	}
+}
+
+// resume handles msgs left Started or Broadcasted by a previous run.
+// Started msgs may or may not have been broadcast, so they are retried,
+// relying on the contract to reject duplicates. Broadcasted msgs are confirmed in the background, see confirmBroadcasted.
+func (txm *Txm) resume(ctx context.Context) {
+	err := txm.forEachMsgsState(db.Started, func(started []db.Msg) error {
+		ids := terra.Msgs(wrap(started)).GetIDs()
+		return errors.Wrapf(txm.store.UpdateMsgs(ids, db.Unstarted, nil), "failed to reset started msgs %v", ids)
+	})
+	if err != nil {
+		txm.lggr.Errorw("Failed to reset started msgs", "err", err)
+	}
+	var broadcasted []db.Msg
+	err = txm.forEachMsgsState(db.Broadcasted, func(msgs []db.Msg) error {
+		broadcasted = append(broadcasted, msgs...)
+		return nil
+	})
+	if err != nil {
+		// Confirm those which were read, and leave the rest for the next restart.
+		txm.lggr.Errorw("Failed to get broadcasted msgs", "err", err)
+	}
+	if len(broadcasted) == 0 {
+		return
+	}
+	txm.wg.Add(1)
+	go txm.confirmBroadcasted(ctx, broadcasted)
+}
+
+// confirmBroadcasted confirms msgs left Broadcasted by a previous run, once the latest height is known.
+// The timeout height and signing details were not persisted, so they are given the full timeout from then,
+// without gas bumping.
+func (txm *Txm) confirmBroadcasted(ctx context.Context, broadcasted []db.Msg) {
+	defer txm.wg.Done()
+	height, err := txm.latestHeight(ctx)
+	for err != nil {
+		txm.lggr.Warnw("Failed to get latest block to resume broadcasted txs, retrying", "err", err)
+		select {
+		case <-ctx.Done():
+			return // still broadcasted, to be confirmed on restart
+		case <-time.After(utils.WithJitter(txm.cfg.BlockRate())):
+		}
+		height, err = txm.latestHeight(ctx)
+	}
+	byTx := make(map[string]*pendingTx)
+	for _, m := range broadcasted {
+		tx, ok := byTx[*m.TxHash]
+		if !ok {
+			timeout := height + txm.cfg.BlocksUntilTxTimeout()
+			tx = &pendingTx{timeoutHeight: timeout, attemptTimeoutHeight: timeout, hashes: m.TxHashes}
+			if len(tx.hashes) == 0 {
+				tx.hashes = []string{*m.TxHash}
+			}
+			byTx[*m.TxHash] = tx
+		}
+		tx.ids = append(tx.ids, m.ID)
+	}
+	txm.lggr.Infow("Resuming confirmation of broadcasted txs", "txs", len(byTx), "msgs", len(broadcasted))
+	for _, tx := range byTx {
+		tx := tx
+		txm.wg.Add(1)
+		go func() {
+			defer txm.wg.Done()
+			txm.confirmTx(ctx, tx)
+		}()
+	}
+}
+
+// forEachMsgsState calls fn with each page of up to MaxMsgsPerBatch msgs in state, oldest first.
+// fn may move msgs out of state.
+func (txm *Txm) forEachMsgsState(state db.State, fn func([]db.Msg) error) error {
+	var afterID int64
+	for {
+		msgs, err := txm.store.GetMsgsStateAfter(state, afterID, txm.cfg.MaxMsgsPerBatch())
+		if err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+		if err = fn(msgs); err != nil {
+			return err
+		}
+		afterID = msgs[len(msgs)-1].ID
+	}
+}
+
+func wrap(msgs []db.Msg) []terra.Msg {
+	tms := make([]terra.Msg, len(msgs))
+	for i := range msgs {
+		tms[i] = terra.Msg{Msg: msgs[i]}
+	}
+	return tms
+}
+
+// sendMsgBatch broadcasts the oldest batch of unstarted msgs, one tx per sender, to be confirmed in the background.
+func (txm *Txm) sendMsgBatch(ctx context.Context) {
+	unstarted, err := txm.store.GetMsgsState(db.Unstarted, txm.cfg.MaxMsgsPerBatch())
+	if err != nil {
+		txm.lggr.Errorw("Failed to get unstarted msgs", "err", err)
+		return
+	}
+	// The oldest msgs are expired even while they are held, so each batch expires any which have timed out.
+	if unstarted = txm.expireMsgs(unstarted); len(unstarted) == 0 {
+		return
+	}
+	prices, err := txm.gpe.GasPrices()
+	if err != nil {
+		txm.lggr.Errorw("Failed to estimate gas prices", "err", err)
+		return
+	}
+	if p, ok := txm.gpe.(client.PausableGasPricesEstimator); ok && p.Paused() {
+		unstarted, err = txm.store.GetUrgentMsgsState(db.Unstarted, txm.cfg.MaxMsgsPerBatch())
+		if err != nil {
+			txm.lggr.Errorw("Failed to get unstarted urgent msgs", "err", err)
+			return
+		}
+		unstarted = txm.expireMsgs(unstarted)
+		txm.lggr.Warnw("Gas prices paused: holding non-urgent msgs", "urgentMsgs", len(unstarted))
+		if len(unstarted) == 0 {
+			return
+		}
+	}
+	txm.lggr.Debugw("Building batch", "msgs", len(unstarted))
+
+	var invalid []int64
+	bySender := make(map[string]terra.Msgs)
+	for _, m := range unstarted {
+		decoded, sender, err := decodeMsg(m)
+		if err != nil {
+			txm.lggr.Errorw("Failed to decode msg", "err", err, "id", m.ID)
+			invalid = append(invalid, m.ID)
+			continue
+		}
+		bySender[sender] = append(bySender[sender], terra.Msg{Msg: m, DecodedMsg: decoded})
+	}
+	if len(invalid) > 0 {
+		if err = txm.store.UpdateMsgs(invalid, db.Errored, nil); err != nil {
+			txm.lggr.Errorw("Failed to mark invalid msgs as errored", "err", err, "ids", invalid)
+		}
+	}
+
+	for sender, msgs := range bySender {
+		if err = txm.store.UpdateMsgs(msgs.GetIDs(), db.Started, nil); err != nil {
+			txm.lggr.Errorw("Failed to mark msgs as started", "err", err, "sender", sender)
+			continue
+		}
+		if err = txm.sendMsgBatchFromAddress(ctx, sender, msgs, prices); err != nil {
+			txm.lggr.Errorw("Failed to send msg batch", "err", err, "sender", sender, "ids", msgs.GetIDs())
+		}
+	}
+}
+
+// expireMsgs marks msgs which were enqueued more than TxMsgTimeout ago as Errored, and returns the rest.
+func (txm *Txm) expireMsgs(msgs []db.Msg) []db.Msg {
+	var expired []int64
+	var live []db.Msg
+	for _, m := range msgs {
+		if time.Since(m.CreatedAt) > txm.cfg.TxMsgTimeout() {
+			expired = append(expired, m.ID)
+		} else {
+			live = append(live, m)
+		}
+	}
+	if len(expired) > 0 {
+		txm.lggr.Warnw("Expiring msgs", "ids", expired, "timeout", txm.cfg.TxMsgTimeout())
+		if err := txm.store.UpdateMsgs(expired, db.Errored, nil); err != nil {
+			txm.lggr.Errorw("Failed to mark expired msgs as errored", "err", err, "ids", expired)
+		}
+	}
+	return live
+}
+
+func (txm *Txm) sendMsgBatchFromAddress(ctx context.Context, sender string, msgs terra.Msgs, prices map[string]sdk.DecCoin) error {
+	// retry resets msgs to be picked up again by the next batch.
+	retry := func(ids []int64) {
+		if err := txm.store.UpdateMsgs(ids, db.Unstarted, nil); err != nil {
+			txm.lggr.Errorw("Failed to reset msgs", "err", err, "ids", ids)
+		}
+	}
+	signer, err := txm.keystore.Get(sender)
+	if err != nil {
+		if uerr := txm.store.UpdateMsgs(msgs.GetIDs(), db.Errored, nil); uerr != nil {
+			txm.lggr.Errorw("Failed to mark msgs as errored", "err", uerr, "ids", msgs.GetIDs())
+		}
+		return errors.Wrap(err, "failed to get key")
+	}
+	senderAddr, err := sdk.AccAddressFromBech32(sender)
+	if err != nil {
+		return err // unreachable: validated when decoded
+	}
+	accountNum, sequence, err := txm.seqs.Next(ctx, senderAddr)
+	if err != nil {
+		retry(msgs.GetIDs())
+		return err
+	}
+	// release returns the sequence if no tx is broadcast with it.
+	release := func(err error) {
+		txm.seqs.Failed(senderAddr, sequence, err)
+	}
+
+	simResults, err := txm.tc.BatchSimulateUnsigned(ctx, msgs.GetSimMsgs(), sequence)
+	if err != nil {
+		release(err)
+		retry(msgs.GetIDs())
+		return errors.Wrap(err, "failed to simulate")
+	}
+	if len(simResults.Failed) > 0 {
+		failed := simResults.Failed.GetSimMsgsIDs()
+		txm.lggr.Warnw("Some msgs failed simulation", "ids", failed, "errs", simResults.Errors)
+		for _, m := range simResults.Failed {
+			if report, ok := terra.TransmitReport(m.Msg); ok {
+				if parsed, err := (terra.ReportCodec{}).DecodeReport(report); err == nil {
+					txm.lggr.Warnw("Transmission failed simulation", "id", m.ID, "report", parsed.String(), "err", simResults.Errors[m.ID])
+				} else {
+					txm.lggr.Warnw("Transmission with undecodable report failed simulation", "id", m.ID, "decodeErr", err, "err", simResults.Errors[m.ID])
+				}
+			}
+		}
+		if err = txm.store.UpdateMsgs(failed, db.Errored, nil); err != nil {
+			txm.lggr.Errorw("Failed to mark failed msgs as errored", "err", err, "ids", failed)
+		}
+	}
+	if len(simResults.Succeeded) == 0 {
+		release(nil)
+		return nil
+	}
+	ids := simResults.Succeeded.GetSimMsgsIDs()
+
+	candidates := txm.feeGasPrices(prices)
+	if len(candidates) == 0 {
+		err = fmt.Errorf("no gas price for any of FeeDenoms %v", txm.cfg.FeeDenoms())
+		release(err)
+		retry(ids)
+		return err
+	}
+	height, err := txm.latestHeight(ctx)
+	if err != nil {
+		release(err)
+		retry(ids)
+		return err
+	}
+	tx := &pendingTx{
+		ids:           ids,
+		msgs:          simResults.Succeeded.GetMsgs(),
+		sender:        senderAddr,
+		feeGranter:    txm.cfg.FeeGranter(),
+		accountNum:    accountNum,
+		sequence:      sequence,
+		gasPrice:      candidates[0],
+		signer:        signer,
+		timeoutHeight: height + txm.cfg.BlocksUntilTxTimeout(),
+	}
+	tx.attemptTimeoutHeight = txm.attemptTimeoutHeight(height, tx.timeoutHeight)
+	// Simulate the successful msgs together for the gas limit.
+	gas, err := txm.estimateGas(ctx, tx)
+	if err != nil {
+		release(err)
+		retry(ids)
+		return err
+	}
+	if len(candidates) > 1 {
+		tx.gasPrice, err = txm.chooseGasPrice(ctx, tx, candidates, gas.Limit)
+		if err != nil {
+			release(err)
+			retry(ids)
+			return err
+		}
+		if tx.gasPrice.Denom != candidates[0].Denom {
+			// The fee denom affects the tx size.
+			if gas, err = txm.estimateGas(ctx, tx); err != nil {
+				release(err)
+				retry(ids)
+				return err
+			}
+		}
+	}
+	tx.gasLimit, tx.simulatedGas = gas.Limit, gas.Simulated
+	txHash, err := txm.signAndBroadcast(ctx, tx)
+	if err != nil {
+		release(err)
+		retry(ids)
+		return err
+	}
+	if err = txm.store.UpdateMsgs(ids, db.Broadcasted, &txHash); err != nil {
+		// Still confirmed below. If this run stops first, the msgs are left Started, and retried on restart.
+		txm.lggr.Errorw("Failed to mark msgs as broadcasted", "err", err, "txHash", txHash, "ids", ids)
+	}
+	txm.lggr.Infow("Broadcasted tx", "txHash", txHash, "ids", ids, "sequence", sequence, "gasLimit", gas.Limit, "simulatedGas", gas.Simulated, "gasPrice", tx.gasPrice)
+
+	txm.wg.Add(1)
+	go func() {
+		defer txm.wg.Done()
+		txm.confirmTx(ctx, tx)
+	}()
+	return nil
+}
+
+func (txm *Txm) estimateGas(ctx context.Context, tx *pendingTx) (client.GasEstimate, error) {
+	gas, err := txm.gas.Estimate(ctx, client.UnsignedTx{
+		Msgs:          tx.msgs,
+		Sequence:      tx.sequence,
+		PubKey:        tx.signer.PubKey(),
+		GasPrice:      tx.gasPrice,
+		FeeGranter:    tx.feeGranter,
+		TimeoutHeight: uint64(tx.attemptTimeoutHeight),
+	}, txm.cfg.GasLimitMultiplier())
+	if err != nil {
+		return client.GasEstimate{}, errors.Wrap(err, "failed to estimate gas for succeeded msgs")
+	}
+	return gas, nil
+}
+
+// feeGasPrices returns the estimated gas prices for FeeDenoms, in order of preference, skipping any without an estimate.
+func (txm *Txm) feeGasPrices(prices map[string]sdk.DecCoin) (candidates []sdk.DecCoin) {
+	for _, denom := range txm.cfg.FeeDenoms() {
+		if price, ok := prices[denom]; ok {
+			candidates = append(candidates, price)
+		}
+	}
+	return
+}
+
+// chooseGasPrice returns the first of candidates in which the fee payer's balance covers the fee for gasLimit.
+func (txm *Txm) chooseGasPrice(ctx context.Context, tx *pendingTx, candidates []sdk.DecCoin, gasLimit uint64) (sdk.DecCoin, error) {
+	payer := tx.sender
+	if tx.feeGranter != nil {
+		payer = tx.feeGranter
+	}
+	for _, price := range candidates {
+		balance, err := txm.tc.Balance(ctx, payer, price.Denom)
+		if err != nil {
+			txm.lggr.Warnw("Failed to get balance", "err", err, "payer", payer, "denom", price.Denom)
+			continue
+		}
+		fee := price.Amount.MulInt64(int64(gasLimit)).Ceil().RoundInt()
+		if balance.Amount.GTE(fee) {
+			return price, nil
+		}
+		txm.lggr.Debugw("Insufficient balance for fee", "payer", payer, "balance", balance, "fee", fee)
+	}
+	return sdk.DecCoin{}, fmt.Errorf("insufficient balance of %s for fees in any of %v", payer, candidates)
+}
+
+// pendingTx is a broadcasted tx awaiting confirmation.
+type pendingTx struct {
+	ids    []int64
+	msgs   []sdk.Msg
+	sender sdk.AccAddress // nil if unknown
+	// For re-signing with a higher gas price. signer is nil if the tx cannot be re-signed.
+	accountNum, sequence, gasLimit uint64
+	simulatedGas                   uint64 // 0 if unknown
+	gasPrice                       sdk.DecCoin
+	signer                         client.Signer
+	feeGranter                     sdk.AccAddress
+
+	timeoutHeight        int64    // after which the tx is abandoned
+	attemptTimeoutHeight int64    // after which the latest attempt can no longer be included
+	hashes               []string // all attempts, oldest first
+}
+
+// attemptTimeoutHeight returns the timeout height for an attempt signed at height: BlocksUntilGasBump blocks later,
+// if gas bumping is enabled, but no later than timeoutHeight.
+func (txm *Txm) attemptTimeoutHeight(height, timeoutHeight int64) int64 {
+	if blocks := txm.cfg.BlocksUntilGasBump(); blocks > 0 && height+blocks < timeoutHeight {
+		return height + blocks
+	}
+	return timeoutHeight
+}
+
+// signAndBroadcast signs tx at its current gas price and attempt timeout height, broadcasts it, and records the hash.
+func (txm *Txm) signAndBroadcast(ctx context.Context, tx *pendingTx) (string, error) {
+	txBytes, err := txm.tc.CreateAndSign(ctx, tx.msgs, tx.accountNum, tx.sequence, tx.gasLimit,
+		1, tx.gasPrice, tx.signer, tx.feeGranter, uint64(tx.attemptTimeoutHeight))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign tx")
+	}
+	resp, err := txm.tc.Broadcast(ctx, txBytes, txtypes.BroadcastMode_BROADCAST_MODE_SYNC)
+	if err != nil {
+		promBroadcastErrors.WithLabelValues(txm.chainID, client.ErrorClass(err)).Inc()
+		return "", errors.Wrap(err, "failed to broadcast tx")
+	}
+	txHash := resp.TxResponse.TxHash
+	tx.hashes = append(tx.hashes, txHash)
+	return txHash, nil
+}
+
+// resubmit re-signs tx with the same sequence and a gas price GasBumpPercent higher, up to MaxGasPriceULuna, and a new
+// attempt timeout height, then broadcasts it. It must only be called once the latest attempt has expired: the mempool
+// has no replace-by-fee, so nodes reject the same sequence until the previous attempt is evicted by its timeout height.
+func (txm *Txm) resubmit(ctx context.Context, tx *pendingTx, height int64, lggr logger.Logger) {
+	prev, prevTimeout := tx.gasPrice, tx.attemptTimeoutHeight
+	if maxPrice, err := txm.maxGasPrice(prev.Denom); err != nil {
+		lggr.Warnw("Not bumping gas price: unknown max", "err", err, "gasPrice", prev)
+	} else if prev.Amount.GTE(maxPrice) {
+		lggr.Warnw("Not bumping gas price: already at max", "gasPrice", prev, "max", maxPrice)
+	} else {
+		bumped := prev.Amount.MulInt64(100 + txm.cfg.GasBumpPercent()).QuoInt64(100)
+		if bumped.GT(maxPrice) {
+			bumped = maxPrice
+		}
+		tx.gasPrice = sdk.NewDecCoinFromDec(prev.Denom, bumped)
+	}
+	tx.attemptTimeoutHeight = txm.attemptTimeoutHeight(height, tx.timeoutHeight)
+	txHash, err := txm.signAndBroadcast(ctx, tx)
+	if err != nil {
+		// Retried on the next poll.
+		bumpedPrice := tx.gasPrice
+		tx.gasPrice, tx.attemptTimeoutHeight = prev, prevTimeout
+		var seqErr *client.ErrSequenceMismatch
+		if errors.As(err, &seqErr) && seqErr.Expected > tx.sequence {
+			// CheckTx reports the same mismatch whether a previous attempt was included, or the node has not yet
+			// evicted it from its mempool.
+			if txm.anyAttemptFound(ctx, tx) {
+				lggr.Debugw("Not resubmitting tx: a previous attempt was already included", "err", err)
+				return
+			}
+			lggr.Warnw("Failed to resubmit tx: the expired attempt has not been evicted from the mempool yet",
+				"err", err, "gasPrice", bumpedPrice)
+			return
+		}
+		lggr.Warnw("Failed to resubmit tx", "err", err, "gasPrice", bumpedPrice)
+		return
+	}
+	if err = txm.store.UpdateMsgs(tx.ids, db.Broadcasted, &txHash); err != nil {
+		lggr.Errorw("Failed to record resubmitted tx", "err", err, "attempt", txHash)
+	}
+	lggr.Infow("Resubmitted expired tx", "attempt", txHash, "gasPrice", tx.gasPrice, "prevGasPrice", prev,
+		"attemptTimeoutHeight", tx.attemptTimeoutHeight)
+}
+
+// anyAttemptFound returns true if any attempt of tx has been included in a block.
+func (txm *Txm) anyAttemptFound(ctx context.Context, tx *pendingTx) bool {
+	for _, txHash := range tx.hashes {
+		if resp, err := txm.tc.Tx(ctx, txHash); err == nil && resp.TxResponse != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (txm *Txm) latestHeight(ctx context.Context) (int64, error) {
+	latest, err := txm.tc.LatestBlock(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get latest block")
+	}
+	return latest.Block.Header.Height, nil
+}
+
+// confirmTx polls for each attempt of tx every ConfirmPollPeriod until one is found, or the chain passes the
+// timeout height. If gas bumping is enabled, each attempt expires after BlocksUntilGasBump blocks, and is then
+// resubmitted with a higher gas price.
+func (txm *Txm) confirmTx(ctx context.Context, tx *pendingTx) {
+	lggr := logger.With(txm.lggr, "txHash", tx.hashes[0], "ids", tx.ids)
+	for {
+		select {
+		case <-ctx.Done():
+			return // left broadcasted, to be confirmed on restart
+		case <-time.After(txm.cfg.ConfirmPollPeriod()):
+		}
+		for i := len(tx.hashes) - 1; i >= 0; i-- {
+			txHash := tx.hashes[i]
+			resp, err := txm.tc.Tx(ctx, txHash)
+			if err != nil || resp.TxResponse == nil {
+				lggr.Debugw("Tx not found yet", "err", err, "attempt", txHash)
+				continue
+			}
+			state := db.Confirmed
+			gasUsed := uint64(resp.TxResponse.GasUsed)
+			if resp.TxResponse.Code != 0 {
+				lggr.Errorw("Tx reverted", "code", resp.TxResponse.Code, "log", resp.TxResponse.RawLog, "attempt", txHash)
+				state = db.Errored
+				if resp.TxResponse.Codespace == sdkerrors.RootCodespace && resp.TxResponse.Code == sdkerrors.ErrOutOfGas.ABCICode() {
+					// Ran out at the limit, so the gas needed is at least the limit.
+					txm.gas.Observe(tx.msgs, tx.simulatedGas, gasUsed)
+				}
+			} else {
+				lggr.Infow("Tx confirmed", "height", resp.TxResponse.Height, "attempt", txHash, "gasUsed", gasUsed, "gasLimit", tx.gasLimit)
+				txm.gas.Observe(tx.msgs, tx.simulatedGas, gasUsed)
+			}
+			if err = txm.store.UpdateMsgs(tx.ids, state, &txHash); err != nil {
+				lggr.Errorw("Failed to update msgs", "err", err, "state", state)
+			}
+			return
+		}
+		height, err := txm.latestHeight(ctx)
+		if err != nil {
+			lggr.Warnw("Failed to get latest block", "err", err)
+			continue
+		}
+		if height > tx.timeoutHeight {
+			lggr.Errorw("Tx timed out waiting for confirmation", "height", height, "timeoutHeight", tx.timeoutHeight, "attempts", len(tx.hashes))
+			if err = txm.store.UpdateMsgs(tx.ids, db.Errored, nil); err != nil {
+				lggr.Errorw("Failed to mark msgs as errored", "err", err)
+			}
+			if tx.sender != nil {
+				// Any later txs from the sender are stuck behind this sequence.
+				txm.seqs.Reset(tx.sender)
+			}
+			return
+		}
+		if tx.signer != nil && height > tx.attemptTimeoutHeight {
+			txm.resubmit(ctx, tx, height, lggr)
+		}
+	}
+}
+
+// Enqueue persists msg to be broadcast with the next batch, or once gas prices are no longer paused.
+// Only MsgExecuteContract is supported, optionally wrapped in an authz MsgExec, and the signer must be in the Keystore.
+func (txm *Txm) Enqueue(contractID string, msg sdk.Msg) (int64, error) {
+	return txm.enqueue(contractID, msg, false)
+}
+
+// EnqueueUrgent is like Enqueue, but msg is broadcast even while gas prices are paused.
+func (txm *Txm) EnqueueUrgent(contractID string, msg sdk.Msg) (int64, error) {
+	return txm.enqueue(contractID, msg, true)
+}
+
+func (txm *Txm) enqueue(contractID string, msg sdk.Msg, urgent bool) (int64, error) {
+	var signer string
+	var raw []byte
+	var err error
+	switch m := msg.(type) {
+	case *wasmtypes.MsgExecuteContract:
+		signer = m.Sender
+		raw, err = m.Marshal()
+	case *authz.MsgExec:
+		inner, ierr := m.GetMessages()
+		if ierr != nil || len(inner) == 0 {
+			return 0, &terra.ErrMsgUnsupported{Msg: msg}
+		}
+		for _, im := range inner {
+			if _, ok := im.(*wasmtypes.MsgExecuteContract); !ok {
+				return 0, &terra.ErrMsgUnsupported{Msg: msg}
+			}
+		}
+		signer = m.Grantee
+		raw, err = m.Marshal()
+	default:
+		return 0, &terra.ErrMsgUnsupported{Msg: msg}
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to marshal msg")
+	}
+	if _, err = txm.keystore.Get(signer); err != nil {
+		return 0, errors.Wrapf(err, "failed to get key for sender %s", signer)
+	}
+	id, err := txm.store.InsertMsg(contractID, sdk.MsgTypeURL(msg), raw, urgent)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to insert msg")
+	}
+	if atomic.AddInt64(&txm.pending, 1) >= txm.cfg.MaxMsgsPerBatch() {
+		select {
+		case txm.full <- struct{}{}:
+		default:
+		}
+	}
+	return id, nil
+}
+
+// GetMsgs returns any msgs matching ids.
+func (txm *Txm) GetMsgs(ids ...int64) (terra.Msgs, error) {
+	msgs, err := txm.store.GetMsgs(ids...)
+	if err != nil {
+		return nil, err
+	}
+	tms := wrap(msgs)
+	for i := range tms {
+		decoded, _, err := decodeMsg(msgs[i])
+		if err != nil {
+			return nil, err
+		}
+		tms[i].DecodedMsg = decoded
+	}
+	return tms, nil
+}
+
+// maxGasPrice returns MaxGasPriceULuna, converted to denom at the ratio of their estimated gas prices.
+func (txm *Txm) maxGasPrice(denom string) (sdk.Dec, error) {
+	maxPrice := txm.cfg.MaxGasPriceULuna()
+	if denom == "uluna" {
+		return maxPrice, nil
+	}
+	prices, err := txm.gpe.GasPrices()
+	if err != nil {
+		return sdk.Dec{}, errors.Wrap(err, "failed to estimate gas prices")
+	}
+	uluna, ok := prices["uluna"]
+	if !ok || !uluna.Amount.IsPositive() {
+		return sdk.Dec{}, errors.New("no uluna gas price")
+	}
+	price, ok := prices[denom]
+	if !ok {
+		return sdk.Dec{}, fmt.Errorf("no %s gas price", denom)
+	}
+	return maxPrice.Mul(price.Amount).Quo(uluna.Amount), nil
+}
+
+// GasPrice returns the gas price in uluna.
+func (txm *Txm) GasPrice() (sdk.DecCoin, error) {
+	prices, err := txm.gpe.GasPrices()
+	if err != nil {
+		return sdk.DecCoin{}, errors.Wrap(err, "failed to estimate gas prices")
+	}
+	price, ok := prices["uluna"]
+	if !ok {
+		return sdk.DecCoin{}, errors.New("no uluna gas price")
+	}
+	return price, nil
+}
+
+var (
+	executeContractTypeURL = sdk.MsgTypeURL(&wasmtypes.MsgExecuteContract{})
+	execTypeURL            = sdk.MsgTypeURL(&authz.MsgExec{})
+)
+
+// decodeMsg returns the decoded msg, and the address of its signer.
+func decodeMsg(m db.Msg) (sdk.Msg, string, error) {
+	switch m.Type {
+	case executeContractTypeURL:
+		decoded, err := decodeExecuteContract(m.Raw)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to decode msg %d", m.ID)
+		}
+		return decoded, decoded.Sender, nil
+	case execTypeURL:
+		var exec authz.MsgExec
+		if err := exec.Unmarshal(m.Raw); err != nil {
+			return nil, "", errors.Wrapf(err, "failed to unmarshal msg %d", m.ID)
+		}
+		grantee, err := sdk.AccAddressFromBech32(exec.Grantee)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "invalid grantee for msg %d", m.ID)
+		}
+		inner := make([]sdk.Msg, len(exec.Msgs))
+		for i, a := range exec.Msgs {
+			if a.TypeUrl != executeContractTypeURL {
+				return nil, "", fmt.Errorf("unsupported msg type %s in msg %d", a.TypeUrl, m.ID)
+			}
+			if inner[i], err = decodeExecuteContract(a.Value); err != nil {
+				return nil, "", errors.Wrapf(err, "failed to decode msg %d", m.ID)
+			}
+		}
+		// Re-wrap, so the inner msgs are cached.
+		decoded := authz.NewMsgExec(grantee, inner)
+		return &decoded, exec.Grantee, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported msg type %s", m.Type)
+	}
+}
+
+func decodeExecuteContract(raw []byte) (*wasmtypes.MsgExecuteContract, error) {
+	var decoded wasmtypes.MsgExecuteContract
+	if err := decoded.Unmarshal(raw); err != nil {
+		return nil, err
+	}
+	if _, err := sdk.AccAddressFromBech32(decoded.Sender); err != nil {
+		return nil, errors.Wrap(err, "invalid sender")
+	}
+	return &decoded, nil
+}
@@ -0,0 +1,514 @@
+package txm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	tmtypes "github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/x/authz"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+	wasmtypes "github.com/terra-money/core/x/wasm/types"
+	"go.uber.org/zap"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/smartcontractkit/chainlink-relay/pkg/logger"
+	"github.com/smartcontractkit/chainlink-relay/pkg/utils"
+
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client/mocks"
+	"github.com/smartcontractkit/chainlink-terra/pkg/terra/db"
+)
+
+type keystore map[string]client.Signer
+
+func (ks keystore) Get(address string) (client.Signer, error) {
+	k, ok := ks[address]
+	if !ok {
+		return nil, fmt.Errorf("no key for %s", address)
+	}
+	return k, nil
+}
+
+// pausedEstimator is a client.PausableGasPricesEstimator which is always paused.
+type pausedEstimator struct {
+	client.GasPricesEstimator
+}
+
+func (pausedEstimator) Paused() bool { return true }
+
+func TestTxm(t *testing.T) {
+	lggr := logger.Test(t)
+	signer := client.NewPrivKeySigner(secp256k1.GenPrivKey())
+	sender := signer.Address()
+	ks := keystore{sender.String(): signer}
+	contract := sdk.AccAddress("contract")
+	gpe := client.NewFixedGasPriceEstimator(map[string]sdk.DecCoin{
+		"uluna": sdk.NewDecCoinFromDec("uluna", sdk.MustNewDecFromStr("0.01")),
+	})
+	newCfg := func(timeout time.Duration) terra.Config {
+		return terra.NewConfig(db.ChainCfg{
+			BlockRate:            utils.MustNewDuration(10 * time.Millisecond),
+			BlocksUntilTxTimeout: null.IntFrom(2),
+			ConfirmPollPeriod:    utils.MustNewDuration(10 * time.Millisecond),
+			TxMsgTimeout:         utils.MustNewDuration(timeout),
+		}, lggr)
+	}
+	// 1000 simulated gas, plus the cost of the fee and gas limit bytes, times the default GasLimitMultiplier.
+	fallbackGasLimit := mock.MatchedBy(func(limit uint64) bool {
+		return limit > 1500 && limit < 1600
+	})
+	newMsg := func(body string) sdk.Msg {
+		return wasmtypes.NewMsgExecuteContract(sender, contract, []byte(body), sdk.Coins{})
+	}
+	requireStates := func(t *testing.T, txm *Txm, states map[int64]db.State) {
+		require.Eventually(t, func() bool {
+			msgs, err := txm.GetMsgs(1, 2, 3)
+			require.NoError(t, err)
+			for _, m := range msgs {
+				if states[m.ID] != m.State {
+					return false
+				}
+			}
+			return true
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		txm := NewTxm("chain", dbm.NewMemDB(), mocks.NewReaderWriter(t), gpe, ks, newCfg(time.Minute), lggr)
+		_, err := txm.Enqueue(contract.String(), &wasmtypes.MsgStoreCode{})
+		var unsupported *terra.ErrMsgUnsupported
+		require.ErrorAs(t, err, &unsupported)
+
+		other := wasmtypes.NewMsgExecuteContract(contract, contract, []byte(`{}`), sdk.Coins{})
+		_, err = txm.Enqueue(contract.String(), other)
+		require.ErrorContains(t, err, "no key")
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		tc := mocks.NewReaderWriter(t)
+		txm := NewTxm("chain", dbm.NewMemDB(), tc, gpe, ks, newCfg(time.Minute), lggr)
+		for _, body := range []string{`"a"`, `"b"`} {
+			_, err := txm.Enqueue(contract.String(), newMsg(body))
+			require.NoError(t, err)
+		}
+		msgs, err := txm.GetMsgs(1, 2)
+		require.NoError(t, err)
+		require.Len(t, msgs, 2)
+		assert.Equal(t, db.Unstarted, msgs[0].State)
+		assert.Equal(t, []byte(`"a"`), []byte(msgs[0].DecodedMsg.(*wasmtypes.MsgExecuteContract).ExecuteMsg))
+
+		tc.On("Account", mock.Anything, sender).Return(uint64(1), uint64(7), nil)
+		tc.On("BatchSimulateUnsigned", mock.Anything, mock.Anything, uint64(7)).Return(func(_ context.Context, msgs client.SimMsgs, _ uint64) *client.BatchSimResults {
+			return &client.BatchSimResults{Succeeded: msgs[:1], Failed: msgs[1:]}
+		}, nil).Once()
+		tc.On("Simulate", mock.Anything, mock.Anything).Return(&txtypes.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 1000}}, nil).Once()
+		tc.On("LatestBlock", mock.Anything).Return(&tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: 10}}}, nil)
+		tc.On("CreateAndSign", mock.Anything, mock.Anything, uint64(1), uint64(7), fallbackGasLimit, float64(1), mock.Anything, signer, sdk.AccAddress(nil), uint64(12)).Return([]byte("tx"), nil).Once()
+		tc.On("Broadcast", mock.Anything, []byte("tx"), txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(&txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x123"}}, nil).Once()
+		tc.On("Tx", mock.Anything, "0x123").Return(nil, errors.New("not found")).Once()
+		tc.On("Tx", mock.Anything, "0x123").Return(&txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x123", Height: 11}}, nil).Once()
+
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		requireStates(t, txm, map[int64]db.State{1: db.Confirmed, 2: db.Errored})
+		msgs, err = txm.GetMsgs(1)
+		require.NoError(t, err)
+		require.NotNil(t, msgs[0].TxHash)
+		assert.Equal(t, "0x123", *msgs[0].TxHash)
+	})
+
+	t.Run("in flight", func(t *testing.T) {
+		tc := mocks.NewReaderWriter(t)
+		cfg := terra.NewConfig(db.ChainCfg{
+			BlockRate:            utils.MustNewDuration(10 * time.Millisecond),
+			BlocksUntilTxTimeout: null.IntFrom(2),
+			ConfirmPollPeriod:    utils.MustNewDuration(50 * time.Millisecond),
+			MaxMsgsPerBatch:      null.IntFrom(1),
+			TxMsgTimeout:         utils.MustNewDuration(time.Minute),
+		}, lggr)
+		txm := NewTxm("chain", dbm.NewMemDB(), tc, gpe, ks, cfg, lggr)
+		for _, body := range []string{`"a"`, `"b"`} {
+			_, err := txm.Enqueue(contract.String(), newMsg(body))
+			require.NoError(t, err)
+		}
+
+		// The second batch uses the next sequence, without waiting for the first to be confirmed.
+		tc.On("Account", mock.Anything, sender).Return(uint64(1), uint64(7), nil).Once()
+		tc.On("LatestBlock", mock.Anything).Return(&tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: 10}}}, nil)
+		for _, seq := range []uint64{7, 8} {
+			txBytes := []byte(fmt.Sprintf("tx%d", seq))
+			txHash := fmt.Sprintf("0x%d", seq)
+			tc.On("BatchSimulateUnsigned", mock.Anything, mock.Anything, seq).Return(func(_ context.Context, msgs client.SimMsgs, _ uint64) *client.BatchSimResults {
+				return &client.BatchSimResults{Succeeded: msgs}
+			}, nil).Once()
+			tc.On("Simulate", mock.Anything, mock.Anything).Return(&txtypes.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 1000}}, nil).Once()
+			tc.On("CreateAndSign", mock.Anything, mock.Anything, uint64(1), seq, fallbackGasLimit, float64(1), mock.Anything, signer, sdk.AccAddress(nil), uint64(12)).Return(txBytes, nil).Once()
+			tc.On("Broadcast", mock.Anything, txBytes, txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(&txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: txHash}}, nil).Once()
+			tc.On("Tx", mock.Anything, txHash).Return(&txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: txHash, Height: 11}}, nil).Once()
+		}
+
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		requireStates(t, txm, map[int64]db.State{1: db.Confirmed, 2: db.Confirmed})
+	})
+
+	t.Run("authz", func(t *testing.T) {
+		tc := mocks.NewReaderWriter(t)
+		granter := sdk.AccAddress("granter_____________")
+		feeGranter := sdk.AccAddress("fee_granter_________")
+		cfg := terra.NewConfig(db.ChainCfg{
+			BlockRate:            utils.MustNewDuration(10 * time.Millisecond),
+			BlocksUntilTxTimeout: null.IntFrom(2),
+			ConfirmPollPeriod:    utils.MustNewDuration(10 * time.Millisecond),
+			FeeGranter:           null.StringFrom(feeGranter.String()),
+			TxMsgTimeout:         utils.MustNewDuration(time.Minute),
+		}, lggr)
+		txm := NewTxm("chain", dbm.NewMemDB(), tc, gpe, ks, cfg, lggr)
+
+		unsupported := authz.NewMsgExec(sender, []sdk.Msg{&wasmtypes.MsgStoreCode{}})
+		_, err := txm.Enqueue(contract.String(), &unsupported)
+		var errUnsupported *terra.ErrMsgUnsupported
+		require.ErrorAs(t, err, &errUnsupported)
+
+		inner := wasmtypes.NewMsgExecuteContract(granter, contract, []byte(`"a"`), nil)
+		exec := authz.NewMsgExec(sender, []sdk.Msg{inner})
+		_, err = txm.Enqueue(contract.String(), &exec)
+		require.NoError(t, err)
+		msgs, err := txm.GetMsgs(1)
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		decoded, ok := msgs[0].DecodedMsg.(*authz.MsgExec)
+		require.True(t, ok)
+		assert.Equal(t, sender.String(), decoded.Grantee)
+		decodedInner, err := decoded.GetMessages()
+		require.NoError(t, err)
+		assert.Equal(t, []sdk.Msg{inner}, decodedInner)
+
+		// Signed by the grantee, with fees paid by the fee granter.
+		tc.On("Account", mock.Anything, sender).Return(uint64(1), uint64(7), nil)
+		tc.On("BatchSimulateUnsigned", mock.Anything, mock.Anything, uint64(7)).Return(func(_ context.Context, msgs client.SimMsgs, _ uint64) *client.BatchSimResults {
+			return &client.BatchSimResults{Succeeded: msgs}
+		}, nil).Once()
+		tc.On("Simulate", mock.Anything, mock.Anything).Return(&txtypes.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 1000}}, nil).Once()
+		tc.On("LatestBlock", mock.Anything).Return(&tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: 10}}}, nil)
+		tc.On("CreateAndSign", mock.Anything, []sdk.Msg{decoded}, uint64(1), uint64(7), fallbackGasLimit, float64(1), mock.Anything, signer, feeGranter, uint64(12)).Return([]byte("tx"), nil).Once()
+		tc.On("Broadcast", mock.Anything, []byte("tx"), txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(&txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x123"}}, nil).Once()
+		tc.On("Tx", mock.Anything, "0x123").Return(&txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x123", Height: 11}}, nil).Once()
+
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		requireStates(t, txm, map[int64]db.State{1: db.Confirmed})
+	})
+
+	t.Run("tx timeout", func(t *testing.T) {
+		tc := mocks.NewReaderWriter(t)
+		txm := NewTxm("chain", dbm.NewMemDB(), tc, gpe, ks, newCfg(time.Minute), lggr)
+		_, err := txm.Enqueue(contract.String(), newMsg(`"a"`))
+		require.NoError(t, err)
+
+		tc.On("Account", mock.Anything, sender).Return(uint64(1), uint64(7), nil)
+		tc.On("BatchSimulateUnsigned", mock.Anything, mock.Anything, uint64(7)).Return(func(_ context.Context, msgs client.SimMsgs, _ uint64) *client.BatchSimResults {
+			return &client.BatchSimResults{Succeeded: msgs}
+		}, nil).Once()
+		tc.On("Simulate", mock.Anything, mock.Anything).Return(&txtypes.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 1000}}, nil).Once()
+		tc.On("LatestBlock", mock.Anything).Return(&tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: 10}}}, nil).Once()
+		tc.On("LatestBlock", mock.Anything).Return(&tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: 13}}}, nil)
+		tc.On("CreateAndSign", mock.Anything, mock.Anything, uint64(1), uint64(7), fallbackGasLimit, float64(1), mock.Anything, signer, sdk.AccAddress(nil), uint64(12)).Return([]byte("tx"), nil).Once()
+		tc.On("Broadcast", mock.Anything, []byte("tx"), txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(&txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x123"}}, nil).Once()
+		tc.On("Tx", mock.Anything, "0x123").Return(nil, errors.New("not found"))
+
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		requireStates(t, txm, map[int64]db.State{1: db.Errored})
+	})
+
+	t.Run("gas bump", func(t *testing.T) {
+		tc := mocks.NewReaderWriter(t)
+		cfg := terra.NewConfig(db.ChainCfg{
+			BlockRate:            utils.MustNewDuration(10 * time.Millisecond),
+			BlocksUntilGasBump:   null.IntFrom(1),
+			BlocksUntilTxTimeout: null.IntFrom(5),
+			ConfirmPollPeriod:    utils.MustNewDuration(10 * time.Millisecond),
+			GasBumpPercent:       null.IntFrom(20),
+			MaxGasPriceULuna:     null.StringFrom("0.013"),
+		}, lggr)
+		txm := NewTxm("chain", dbm.NewMemDB(), tc, gpe, ks, cfg, lggr)
+		_, err := txm.Enqueue(contract.String(), newMsg(`"a"`))
+		require.NoError(t, err)
+
+		gasPrice := func(price string) interface{} {
+			return mock.MatchedBy(func(p sdk.DecCoin) bool {
+				return p.IsEqual(sdk.NewDecCoinFromDec("uluna", sdk.MustNewDecFromStr(price)))
+			})
+		}
+		height := int64(9)
+		tc.On("Account", mock.Anything, sender).Return(uint64(1), uint64(7), nil)
+		tc.On("BatchSimulateUnsigned", mock.Anything, mock.Anything, uint64(7)).Return(func(_ context.Context, msgs client.SimMsgs, _ uint64) *client.BatchSimResults {
+			return &client.BatchSimResults{Succeeded: msgs}
+		}, nil).Once()
+		tc.On("Simulate", mock.Anything, mock.Anything).Return(&txtypes.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 1000}}, nil).Once()
+		tc.On("LatestBlock", mock.Anything).Return(func(context.Context) *tmtypes.GetLatestBlockResponse {
+			height++
+			return &tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: height}}}
+		}, nil)
+		// Each attempt expires after a block, and is resubmitted with the same sequence at a higher price.
+		tc.On("CreateAndSign", mock.Anything, mock.Anything, uint64(1), uint64(7), fallbackGasLimit, float64(1), gasPrice("0.01"), signer, sdk.AccAddress(nil), uint64(11)).Return([]byte("tx1"), nil).Once()
+		tc.On("CreateAndSign", mock.Anything, mock.Anything, uint64(1), uint64(7), fallbackGasLimit, float64(1), gasPrice("0.012"), signer, sdk.AccAddress(nil), uint64(13)).Return([]byte("tx2"), nil).Once()
+		// capped at the max, and at the tx timeout height
+		tc.On("CreateAndSign", mock.Anything, mock.Anything, uint64(1), uint64(7), fallbackGasLimit, float64(1), gasPrice("0.013"), signer, sdk.AccAddress(nil), uint64(15)).Return([]byte("tx3"), nil).Once()
+		tc.On("Broadcast", mock.Anything, []byte("tx1"), txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(&txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x1"}}, nil).Once()
+		tc.On("Broadcast", mock.Anything, []byte("tx2"), txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(&txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x2"}}, nil).Once()
+		tc.On("Broadcast", mock.Anything, []byte("tx3"), txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(nil, errors.New("node unavailable")).Once()
+		tc.On("Tx", mock.Anything, "0x1").Return(nil, errors.New("not found"))
+		// included after the third attempt failed to broadcast
+		tc.On("Tx", mock.Anything, "0x2").Return(func(context.Context, string) *txtypes.GetTxResponse {
+			if height < 14 {
+				return nil
+			}
+			return &txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x2", Height: 13}}
+		}, func(context.Context, string) error {
+			if height < 14 {
+				return errors.New("not found")
+			}
+			return nil
+		})
+
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		requireStates(t, txm, map[int64]db.State{1: db.Confirmed})
+		msgs, err := txm.GetMsgs(1)
+		require.NoError(t, err)
+		require.NotNil(t, msgs[0].TxHash)
+		assert.Equal(t, "0x2", *msgs[0].TxHash)
+		assert.Equal(t, []string{"0x1", "0x2"}, msgs[0].TxHashes)
+	})
+
+	t.Run("resubmit while pending", func(t *testing.T) {
+		// The mempool has no replace-by-fee, so a resubmission is rejected until the expired attempt is evicted.
+		tc := mocks.NewReaderWriter(t)
+		cfg := terra.NewConfig(db.ChainCfg{
+			BlockRate:            utils.MustNewDuration(10 * time.Millisecond),
+			BlocksUntilGasBump:   null.IntFrom(1),
+			BlocksUntilTxTimeout: null.IntFrom(5),
+			ConfirmPollPeriod:    utils.MustNewDuration(10 * time.Millisecond),
+			GasBumpPercent:       null.IntFrom(20),
+		}, lggr)
+		obsLggr, logs := logger.TestObserved(t, zap.WarnLevel)
+		txm := NewTxm("chain", dbm.NewMemDB(), tc, gpe, ks, cfg, obsLggr)
+		_, err := txm.Enqueue(contract.String(), newMsg(`"a"`))
+		require.NoError(t, err)
+		seqErrs := func() float64 {
+			return testutil.ToFloat64(promBroadcastErrors.WithLabelValues("chain", "sequence_mismatch"))
+		}
+		initialSeqErrs := seqErrs()
+
+		height := int64(9)
+		tc.On("Account", mock.Anything, sender).Return(uint64(1), uint64(7), nil)
+		tc.On("BatchSimulateUnsigned", mock.Anything, mock.Anything, uint64(7)).Return(func(_ context.Context, msgs client.SimMsgs, _ uint64) *client.BatchSimResults {
+			return &client.BatchSimResults{Succeeded: msgs}
+		}, nil).Once()
+		tc.On("Simulate", mock.Anything, mock.Anything).Return(&txtypes.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 1000}}, nil).Once()
+		tc.On("LatestBlock", mock.Anything).Return(func(context.Context) *tmtypes.GetLatestBlockResponse {
+			height++
+			return &tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: height}}}
+		}, nil)
+		tc.On("CreateAndSign", mock.Anything, mock.Anything, uint64(1), uint64(7), fallbackGasLimit, float64(1), mock.Anything, signer, sdk.AccAddress(nil), uint64(11)).Return([]byte("tx1"), nil).Once()
+		tc.On("CreateAndSign", mock.Anything, mock.Anything, uint64(1), uint64(7), fallbackGasLimit, float64(1), mock.Anything, signer, sdk.AccAddress(nil), uint64(13)).Return([]byte("tx2"), nil).Once()
+		tc.On("Broadcast", mock.Anything, []byte("tx1"), txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(&txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x1"}}, nil).Once()
+		tc.On("Broadcast", mock.Anything, []byte("tx2"), txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(nil, &client.ErrSequenceMismatch{
+			ABCIError: &client.ABCIError{Codespace: "sdk", Code: 32, Log: "account sequence mismatch, expected 8, got 7: incorrect account sequence"},
+			Expected:  8,
+			Got:       7,
+		}).Once()
+		// still pending when the resubmission is rejected, then included
+		tc.On("Tx", mock.Anything, "0x1").Return(nil, errors.New("not found")).Times(3)
+		tc.On("Tx", mock.Anything, "0x1").Return(&txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x1", Height: 12}}, nil).Once()
+
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		requireStates(t, txm, map[int64]db.State{1: db.Confirmed})
+		msgs, err := txm.GetMsgs(1)
+		require.NoError(t, err)
+		require.NotNil(t, msgs[0].TxHash)
+		assert.Equal(t, "0x1", *msgs[0].TxHash)
+		assert.Equal(t, []string{"0x1"}, msgs[0].TxHashes)
+		assert.Equal(t, 1, logs.FilterMessageSnippet("has not been evicted").Len())
+		assert.Equal(t, initialSeqErrs+1, seqErrs())
+	})
+
+	t.Run("fee denom", func(t *testing.T) {
+		tc := mocks.NewReaderWriter(t)
+		cfg := terra.NewConfig(db.ChainCfg{
+			BlockRate:            utils.MustNewDuration(10 * time.Millisecond),
+			BlocksUntilTxTimeout: null.IntFrom(2),
+			ConfirmPollPeriod:    utils.MustNewDuration(10 * time.Millisecond),
+			FeeDenoms:            null.StringFrom("uusd,ukrw,uluna"),
+			TxMsgTimeout:         utils.MustNewDuration(time.Minute),
+		}, lggr)
+		gpe := client.NewFixedGasPriceEstimator(map[string]sdk.DecCoin{
+			"uluna": sdk.NewDecCoinFromDec("uluna", sdk.MustNewDecFromStr("0.01")),
+			"uusd":  sdk.NewDecCoinFromDec("uusd", sdk.MustNewDecFromStr("0.1")),
+		})
+		txm := NewTxm("chain", dbm.NewMemDB(), tc, gpe, ks, cfg, lggr)
+		_, err := txm.Enqueue(contract.String(), newMsg(`"a"`))
+		require.NoError(t, err)
+
+		// Bumps are capped at the equivalent of MaxGasPriceULuna.
+		maxUSD, err := txm.maxGasPrice("uusd")
+		require.NoError(t, err)
+		assert.Equal(t, sdk.NewDec(10), maxUSD)
+		_, err = txm.maxGasPrice("ukrw")
+		require.Error(t, err)
+
+		tc.On("Account", mock.Anything, sender).Return(uint64(1), uint64(7), nil)
+		tc.On("BatchSimulateUnsigned", mock.Anything, mock.Anything, uint64(7)).Return(func(_ context.Context, msgs client.SimMsgs, _ uint64) *client.BatchSimResults {
+			return &client.BatchSimResults{Succeeded: msgs}
+		}, nil).Once()
+		// Estimated again for the chosen denom.
+		tc.On("Simulate", mock.Anything, mock.Anything).Return(&txtypes.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 1000}}, nil).Twice()
+		tc.On("LatestBlock", mock.Anything).Return(&tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: 10}}}, nil)
+		// Not enough uusd, and no ukrw price.
+		usd := sdk.NewInt64Coin("uusd", 100)
+		tc.On("Balance", mock.Anything, sender, "uusd").Return(&usd, nil).Once()
+		luna := sdk.NewInt64Coin("uluna", 1_000_000)
+		tc.On("Balance", mock.Anything, sender, "uluna").Return(&luna, nil).Once()
+		ulunaPrice := sdk.NewDecCoinFromDec("uluna", sdk.MustNewDecFromStr("0.01"))
+		tc.On("CreateAndSign", mock.Anything, mock.Anything, uint64(1), uint64(7), fallbackGasLimit, float64(1), ulunaPrice, signer, sdk.AccAddress(nil), uint64(12)).Return([]byte("tx"), nil).Once()
+		tc.On("Broadcast", mock.Anything, []byte("tx"), txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(&txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x123"}}, nil).Once()
+		tc.On("Tx", mock.Anything, "0x123").Return(&txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x123", Height: 11}}, nil).Once()
+
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		requireStates(t, txm, map[int64]db.State{1: db.Confirmed})
+	})
+
+	t.Run("paused", func(t *testing.T) {
+		tc := mocks.NewReaderWriter(t)
+		txm := NewTxm("chain", dbm.NewMemDB(), tc, pausedEstimator{gpe}, ks, newCfg(time.Minute), lggr)
+		_, err := txm.Enqueue(contract.String(), newMsg(`"a"`))
+		require.NoError(t, err)
+		_, err = terra.EnqueueUrgent(txm, contract.String(), newMsg(`"b"`))
+		require.NoError(t, err)
+
+		// Only the urgent msg is broadcast.
+		tc.On("Account", mock.Anything, sender).Return(uint64(1), uint64(7), nil)
+		tc.On("BatchSimulateUnsigned", mock.Anything, mock.MatchedBy(func(msgs client.SimMsgs) bool {
+			return len(msgs) == 1 && msgs[0].ID == 2
+		}), uint64(7)).Return(func(_ context.Context, msgs client.SimMsgs, _ uint64) *client.BatchSimResults {
+			return &client.BatchSimResults{Succeeded: msgs}
+		}, nil).Once()
+		tc.On("Simulate", mock.Anything, mock.Anything).Return(&txtypes.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 1000}}, nil).Once()
+		tc.On("LatestBlock", mock.Anything).Return(&tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: 10}}}, nil)
+		tc.On("CreateAndSign", mock.Anything, mock.Anything, uint64(1), uint64(7), fallbackGasLimit, float64(1), mock.Anything, signer, sdk.AccAddress(nil), uint64(12)).Return([]byte("tx"), nil).Once()
+		tc.On("Broadcast", mock.Anything, []byte("tx"), txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(&txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x123"}}, nil).Once()
+		tc.On("Tx", mock.Anything, "0x123").Return(&txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0x123", Height: 11}}, nil).Once()
+
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		requireStates(t, txm, map[int64]db.State{1: db.Unstarted, 2: db.Confirmed})
+	})
+
+	t.Run("paused expired", func(t *testing.T) {
+		txm := NewTxm("chain", dbm.NewMemDB(), mocks.NewReaderWriter(t), pausedEstimator{gpe}, ks, newCfg(time.Nanosecond), lggr)
+		_, err := txm.Enqueue(contract.String(), newMsg(`"a"`))
+		require.NoError(t, err)
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		// held msgs still expire
+		requireStates(t, txm, map[int64]db.State{1: db.Errored})
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		tc := mocks.NewReaderWriter(t)
+		cfg := terra.NewConfig(db.ChainCfg{
+			BlockRate:            utils.MustNewDuration(10 * time.Millisecond),
+			BlocksUntilTxTimeout: null.IntFrom(100),
+			ConfirmPollPeriod:    utils.MustNewDuration(10 * time.Millisecond),
+			MaxMsgsPerBatch:      null.IntFrom(1),
+		}, lggr)
+		txm := NewTxm("chain", dbm.NewMemDB(), tc, gpe, ks, cfg, lggr)
+		for _, body := range []string{`"a"`, `"b"`, `"c"`} {
+			_, err := txm.Enqueue(contract.String(), newMsg(body))
+			require.NoError(t, err)
+		}
+		// left broadcasted by a previous run, in more than one batch
+		stuck, included := "0xa", "0xb"
+		require.NoError(t, txm.store.UpdateMsgs([]int64{1}, db.Broadcasted, &stuck))
+		require.NoError(t, txm.store.UpdateMsgs([]int64{2}, db.Broadcasted, &included))
+
+		tc.On("LatestBlock", mock.Anything).Return(&tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: 10}}}, nil)
+		tc.On("Tx", mock.Anything, stuck).Return(nil, errors.New("not found"))
+		tc.On("Tx", mock.Anything, included).Return(&txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: included, Height: 9}}, nil).Once()
+		// new msgs are sent while the stuck tx is still being confirmed
+		tc.On("Account", mock.Anything, sender).Return(uint64(1), uint64(7), nil)
+		tc.On("BatchSimulateUnsigned", mock.Anything, mock.Anything, uint64(7)).Return(func(_ context.Context, msgs client.SimMsgs, _ uint64) *client.BatchSimResults {
+			return &client.BatchSimResults{Succeeded: msgs}
+		}, nil).Once()
+		tc.On("Simulate", mock.Anything, mock.Anything).Return(&txtypes.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 1000}}, nil).Once()
+		tc.On("CreateAndSign", mock.Anything, mock.Anything, uint64(1), uint64(7), fallbackGasLimit, float64(1), mock.Anything, signer, sdk.AccAddress(nil), uint64(15)).Return([]byte("tx"), nil).Once() // the first attempt expires after BlocksUntilGasBump
+		tc.On("Broadcast", mock.Anything, []byte("tx"), txtypes.BroadcastMode_BROADCAST_MODE_SYNC).Return(&txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0xc"}}, nil).Once()
+		tc.On("Tx", mock.Anything, "0xc").Return(&txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: "0xc", Height: 11}}, nil).Once()
+
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		requireStates(t, txm, map[int64]db.State{1: db.Broadcasted, 2: db.Confirmed, 3: db.Confirmed})
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		txm := NewTxm("chain", dbm.NewMemDB(), mocks.NewReaderWriter(t), gpe, ks, newCfg(time.Nanosecond), lggr)
+		_, err := txm.Enqueue(contract.String(), newMsg(`"a"`))
+		require.NoError(t, err)
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		requireStates(t, txm, map[int64]db.State{1: db.Errored})
+	})
+
+	t.Run("resume retries latest block", func(t *testing.T) {
+		tc := mocks.NewReaderWriter(t)
+		txm := NewTxm("chain", dbm.NewMemDB(), tc, gpe, ks, newCfg(time.Minute), lggr)
+		_, err := txm.Enqueue(contract.String(), newMsg(`"a"`))
+		require.NoError(t, err)
+		txHash := "0xa"
+		require.NoError(t, txm.store.UpdateMsgs([]int64{1}, db.Broadcasted, &txHash))
+
+		tc.On("LatestBlock", mock.Anything).Return(nil, errors.New("unavailable")).Twice()
+		tc.On("LatestBlock", mock.Anything).Return(&tmtypes.GetLatestBlockResponse{Block: &tmproto.Block{Header: tmproto.Header{Height: 10}}}, nil)
+		tc.On("Tx", mock.Anything, txHash).Return(&txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: txHash, Height: 9}}, nil).Once()
+
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		requireStates(t, txm, map[int64]db.State{1: db.Confirmed})
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		cfg := terra.NewConfig(db.ChainCfg{TxMsgRetention: utils.MustNewDuration(time.Nanosecond)}, lggr)
+		txm := NewTxm("chain", dbm.NewMemDB(), mocks.NewReaderWriter(t), gpe, ks, cfg, lggr)
+		for _, body := range []string{`"a"`, `"b"`} {
+			_, err := txm.Enqueue(contract.String(), newMsg(body))
+			require.NoError(t, err)
+		}
+		txHash := "0xa"
+		require.NoError(t, txm.store.UpdateMsgs([]int64{1}, db.Confirmed, &txHash))
+		require.NoError(t, txm.store.UpdateMsgs([]int64{2}, db.Errored, nil))
+
+		require.NoError(t, txm.Start(context.Background()))
+		t.Cleanup(func() { assert.NoError(t, txm.Close()) })
+		require.Eventually(t, func() bool {
+			msgs, err := txm.GetMsgs(1, 2)
+			require.NoError(t, err)
+			return len(msgs) == 0
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
@@ -1,6 +1,9 @@
 package terra
 
 import (
+	"encoding/binary"
+	"math/big"
+
 	"github.com/smartcontractkit/terra.go/msg"
 
 	"github.com/smartcontractkit/chainlink-terra/pkg/terra/client"
@@ -32,11 +35,50 @@ type LatestTransmissionDetails struct {
 	LatestTimestamp    int64              `json:"latest_timestamp"`
 }
 
+// ContractMetadata is the OCR2 contract's metadata, which is set on instantiation and never changes.
+type ContractMetadata struct {
+	// MinAnswer and MaxAnswer bound the median of accepted reports, inclusively.
+	MinAnswer   *big.Int
+	MaxAnswer   *big.Int
+	Decimals    uint8
+	Description string
+}
+
 type LatestConfigDigestAndEpoch struct {
 	ConfigDigest types.ConfigDigest `json:"config_digest"`
 	Epoch        uint32             `json:"epoch"`
 }
 
+// RawConfig is the subset of the OCR2 contract's stored config which is read by verified reads.
+type RawConfig struct {
+	LatestConfigDigest      types.ConfigDigest `json:"latest_config_digest"`
+	LatestConfigBlockNumber uint64             `json:"latest_config_block_number"`
+	LatestAggregatorRoundID uint32             `json:"latest_aggregator_round_id"`
+	Epoch                   uint32             `json:"epoch"`
+	Round                   uint8              `json:"round"`
+}
+
+// RawTransmission is a transmission stored by the OCR2 contract.
+type RawTransmission struct {
+	Answer                string `json:"answer"`
+	ObservationsTimestamp int64  `json:"observations_timestamp"`
+	TransmissionTimestamp int64  `json:"transmission_timestamp"`
+}
+
+// rawConfigKey is the OCR2 contract's storage key for its config.
+var rawConfigKey = []byte("config")
+
+// rawTransmissionKey returns the OCR2 contract's storage key for the transmission of roundID.
+// Like all cw-storage-plus maps, this is the length-prefixed namespace followed by the key, which is big-endian.
+func rawTransmissionKey(roundID uint32) []byte {
+	const namespace = "transmissions"
+	key := make([]byte, 2+len(namespace)+4)
+	binary.BigEndian.PutUint16(key, uint16(len(namespace)))
+	copy(key[2:], namespace)
+	binary.BigEndian.PutUint32(key[2+len(namespace):], roundID)
+	return key
+}
+
 type Msg struct {
 	db.Msg
 